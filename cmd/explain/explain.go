@@ -0,0 +1,35 @@
+package explain
+
+import (
+	"fmt"
+
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var ExplainCmd = &cobra.Command{
+	Use:   "explain [code]",
+	RunE:  runExplainCmd,
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Explain an error code",
+	Long:  "Explain an error code like LOX2001 that golox reports, or list all error codes when no code is given",
+}
+
+func runExplainCmd(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	out := cmd.OutOrStdout()
+	if len(args) == 0 {
+		for _, code := range loxerr.Codes() {
+			entry, _ := loxerr.Explain(code)
+			fmt.Fprintf(out, "%s  %-8s %s\n", entry.Code, entry.Stage, entry.Summary)
+		}
+		return nil
+	}
+
+	entry, ok := loxerr.Explain(loxerr.Code(args[0]))
+	if !ok {
+		return fmt.Errorf("unknown error code '%s', run 'golox explain' to list all error codes", args[0])
+	}
+	fmt.Fprintf(out, "%s: %s (%s error)\n\n%s\n", entry.Code, entry.Summary, entry.Stage, entry.Explanation)
+	return nil
+}
@@ -2,9 +2,12 @@ package interpreter
 
 import (
 	"fmt"
+	"io"
+	"os"
 
 	ast_interpreter "github.com/kaschnit/golox/pkg/ast/interpreter"
 	"github.com/kaschnit/golox/pkg/cli"
+	"github.com/kaschnit/golox/pkg/vm"
 	"github.com/spf13/cobra"
 )
 
@@ -18,48 +21,106 @@ const (
 type InterpreterFlags struct {
 	interactive bool
 	algorithm   string
+	stepBudget  int
+	maxDepth    int
+	diagnostics string
+}
+
+// An interpreter backend that can be selected with the algorithm flag.
+type sourceInterpreter interface {
+	InterpretSourceFile(filepath string) error
+	EvaluateLine(line string) (interface{}, error)
+	Globals() map[string]interface{}
+	SetStepBudget(budget int)
+	SetMaxDepth(depth int)
+	SetStdin(stdin io.Reader)
 }
 
 var (
 	flags          = &InterpreterFlags{}
 	InterpreterCmd = &cobra.Command{
 		Use:   "interpreter",
-		Run:   runInterpreterCmd,
+		RunE:  runInterpreterCmd,
 		Args:  cobra.OnlyValidArgs,
 		Short: "Run the golox interpreter",
-		Long:  "Run the golox interpreter to execute lox code",
+		Long:  "Run the golox interpreter to execute lox code.\nImported modules are looked for relative to the importing file, then in the directories listed in GOLOX_PATH.",
 	}
 )
 
 func init() {
 	InterpreterCmd.Flags().BoolVarP(&flags.interactive, "interactive", "i", false, "Run in interactive mode.")
 	InterpreterCmd.Flags().StringVarP(&flags.algorithm, "algorithm", "a", string(InterpreterAlgorithmByteCode), "The interpreter algorithm to use. One of: 'ast', 'bytecode'.")
+	InterpreterCmd.Flags().IntVar(&flags.stepBudget, "step-budget", 0, "The number of loop iterations a program may run before it's stopped. 0 means no limit.")
+	InterpreterCmd.Flags().IntVar(&flags.maxDepth, "max-depth", vm.FramesMax, fmt.Sprintf("The maximum depth of nested calls before a stack overflow is reported. At most %d with 'ast'.", ast_interpreter.MaxDepthLimit))
+	InterpreterCmd.Flags().StringVar(&flags.diagnostics, "diagnostics-format", string(cli.DiagnosticsText), cli.DiagnosticsFormatUsage)
 }
 
-func runInterpreterCmd(_ *cobra.Command, args []string) {
+func runInterpreterCmd(cmd *cobra.Command, args []string) error {
+	interp, err := newConfiguredInterpreter()
+	if err != nil {
+		return err
+	}
+	format, err := cli.ParseDiagnosticsFormat(flags.diagnostics)
+	if err != nil {
+		return err
+	}
+
+	// Errors in the program are reported as diagnostics rather than with the command's usage.
+	cmd.SilenceUsage = true
 	if flags.interactive {
-		startInterpreterRepl()
+		startInterpreterRepl(interp, format)
 	} else if len(args) > 0 {
-		interpretSourceFile(args[0])
+		return interpretSourceFile(interp, args[0], format)
 	} else {
 		fmt.Println("No input provided. Exiting.")
 	}
+	return nil
 }
 
-func interpretSourceFile(filepath string) {
-	interp := ast_interpreter.NewInterpreterWrapper()
-	err := interp.InterpretSourceFile(filepath)
+// Create the interpreter backend selected by the flags, limited as the flags say.
+func newConfiguredInterpreter() (sourceInterpreter, error) {
+	algorithm := InterpreterAlgorithm(flags.algorithm)
+	interp, err := newInterpreter(algorithm)
 	if err != nil {
-		fmt.Println(err)
+		return nil, err
 	}
+	if err := checkMaxDepth(algorithm, flags.maxDepth); err != nil {
+		return nil, err
+	}
+	interp.SetStepBudget(flags.stepBudget)
+	interp.SetMaxDepth(flags.maxDepth)
+	return interp, nil
 }
 
-func startInterpreterRepl() {
-	interp := ast_interpreter.NewInterpreterWrapper()
-	cli.NewRepl(func(line string) {
-		err := interp.InterpretLine(line)
-		if err != nil {
-			fmt.Println(err)
-		}
-	}).Start()
+// Create the interpreter backend for the algorithm.
+func newInterpreter(algorithm InterpreterAlgorithm) (sourceInterpreter, error) {
+	switch algorithm {
+	case InterpreterAlgorithmAST:
+		return ast_interpreter.NewInterpreterWrapper(), nil
+	case InterpreterAlgorithmByteCode:
+		return vm.NewVMWrapper(), nil
+	default:
+		return nil, fmt.Errorf("unknown interpreter algorithm '%s', expected one of: '%s', '%s'",
+			algorithm, InterpreterAlgorithmAST, InterpreterAlgorithmByteCode)
+	}
+}
+
+// Check that the maximum depth of nested calls is one that the backend for the algorithm can reach.
+func checkMaxDepth(algorithm InterpreterAlgorithm, depth int) error {
+	if depth < 1 {
+		return fmt.Errorf("invalid max depth %d, expected at least 1", depth)
+	}
+	if algorithm == InterpreterAlgorithmAST && depth > ast_interpreter.MaxDepthLimit {
+		return fmt.Errorf("invalid max depth %d, the '%s' algorithm supports at most %d",
+			depth, algorithm, ast_interpreter.MaxDepthLimit)
+	}
+	return nil
+}
+
+func interpretSourceFile(interp sourceInterpreter, filepath string, format cli.DiagnosticsFormat) error {
+	err := interp.InterpretSourceFile(filepath)
+	if err != nil {
+		return cli.ReportFileError(os.Stderr, format, err, filepath)
+	}
+	return nil
 }
@@ -0,0 +1,135 @@
+package interpreter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kaschnit/golox/pkg/cli"
+	"github.com/kaschnit/golox/pkg/native"
+)
+
+// An interactive session of the interpreter, whose globals are kept between inputs until it's reset.
+type replSession struct {
+	interp sourceInterpreter
+	stdin  io.Reader
+	stdout io.Writer
+	repl   *cli.Repl
+}
+
+func startInterpreterRepl(interp sourceInterpreter, format cli.DiagnosticsFormat) {
+	// The REPL and the readLine native share a reader, so that neither buffers input meant for the other.
+	stdin := bufio.NewReader(os.Stdin)
+	interp.SetStdin(stdin)
+	session := &replSession{interp: interp, stdin: stdin, stdout: os.Stdout}
+	session.repl = cli.NewReplWithIO(session.evaluate, stdin, session.stdout, os.Stderr)
+	session.repl.SetDiagnosticsFormat(format)
+	for _, command := range session.commands() {
+		session.repl.AddCommand(command)
+	}
+	session.repl.SetCompleter(session.complete)
+	session.repl.EnableLineEditing(os.Stdin, cli.DefaultHistoryPath())
+	session.repl.Start()
+}
+
+// Run the input, showing the value of an expression statement entered on its own without needing 'print'.
+func (s *replSession) evaluate(input string) error {
+	value, err := s.interp.EvaluateLine(input)
+	if err == nil && value != nil {
+		fmt.Fprintln(s.stdout, value)
+	}
+	return err
+}
+
+// Get the names of the globals that complete the word, or the properties of a global, like "point.x",
+// when the word is the global's name followed by a '.'.
+func (s *replSession) complete(word string) []string {
+	globals := s.interp.Globals()
+	candidates := make([]string, 0)
+	if name, prefix, ok := strings.Cut(word, "."); ok {
+		if lister, ok := globals[name].(native.PropertyLister); ok {
+			for _, property := range lister.PropertyNames() {
+				if strings.HasPrefix(property, prefix) {
+					candidates = append(candidates, name+"."+property)
+				}
+			}
+		}
+		return candidates
+	}
+	for name := range globals {
+		if strings.HasPrefix(name, word) {
+			candidates = append(candidates, name)
+		}
+	}
+	return candidates
+}
+
+// Get the commands for inspecting and managing the session.
+func (s *replSession) commands() []*cli.ReplCommand {
+	return []*cli.ReplCommand{
+		{Name: "env", Help: "List the global variables and their values.", Run: s.listGlobals},
+		{Name: "type", Args: "<expr>", Help: "Evaluate the expression and print the name of its type.", Run: s.printType},
+		cli.AstCommand(),
+		cli.TokensCommand(),
+		{Name: "load", Args: "<file>", Help: "Run the file in this session.", Run: s.load},
+		{Name: "reset", Help: "Start a new session, forgetting all variables.", Run: s.reset},
+	}
+}
+
+func (s *replSession) listGlobals(out io.Writer, _ string) error {
+	globals := s.interp.Globals()
+	names := make([]string, 0, len(globals))
+	for name := range globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "%s = %v\n", name, globals[name])
+	}
+	return nil
+}
+
+func (s *replSession) printType(out io.Writer, expr string) error {
+	if expr == "" {
+		return errors.New("Expected an expression.")
+	}
+	value, err := s.interp.EvaluateLine(expr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, native.TypeName(value))
+	return nil
+}
+
+func (s *replSession) load(out io.Writer, path string) error {
+	if path == "" {
+		return errors.New("Expected a file to load.")
+	}
+	if err := s.interp.InterpretSourceFile(path); err != nil {
+		return cli.InFile(err, path)
+	}
+
+	// The file is saved with the session, so that the saved session defines what the file defines.
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s.repl.Record(string(source))
+	return nil
+}
+
+func (s *replSession) reset(out io.Writer, _ string) error {
+	interp, err := newConfiguredInterpreter()
+	if err != nil {
+		return err
+	}
+	interp.SetStdin(s.stdin)
+	s.interp = interp
+	s.repl.ClearRecord()
+	fmt.Fprintln(out, "Session reset.")
+	return nil
+}
@@ -2,54 +2,101 @@ package parser
 
 import (
 	"fmt"
+	"io"
+	"os"
 
+	"github.com/kaschnit/golox/pkg/ast"
 	"github.com/kaschnit/golox/pkg/ast/astutil"
 	"github.com/kaschnit/golox/pkg/ast/printer"
+	"github.com/kaschnit/golox/pkg/ast/serialize"
 	"github.com/kaschnit/golox/pkg/cli"
 	"github.com/spf13/cobra"
 )
 
 type ParserFlags struct {
 	interactive bool
+	format      string
+	diagnostics string
 }
 
 var (
 	flags     = &ParserFlags{}
 	ParserCmd = &cobra.Command{
-		Use:   "parser",
-		Run:   runParserCmd,
-		Args:  cobra.OnlyValidArgs,
+		Use:   "parser [file]",
+		RunE:  runParserCmd,
+		Args:  cobra.MaximumNArgs(1),
 		Short: "Run the golox parser",
-		Long:  "Run the golox parser to produce an AST from lox source code",
+		Long: "Run the golox parser to produce an AST from lox source code.\n" +
+			"The source code is read from the file, or from stdin if the file is '-'.\n" +
+			"With --format=json, the AST is written as JSON, with the kind of each node and the tokens it was parsed from.",
 	}
 )
 
 func init() {
 	ParserCmd.Flags().BoolVarP(&flags.interactive, "interactive", "i", false, "Run in interactive mode.")
+	ParserCmd.Flags().StringVar(&flags.format, "format", string(cli.OutputText), cli.OutputFormatUsage)
+	ParserCmd.Flags().StringVar(&flags.diagnostics, "diagnostics-format", string(cli.DiagnosticsText), cli.DiagnosticsFormatUsage)
 }
 
-func runParserCmd(_ *cobra.Command, args []string) {
+func runParserCmd(cmd *cobra.Command, args []string) error {
+	output, err := cli.ParseOutputFormat(flags.format)
+	if err != nil {
+		return err
+	}
+	format, err := cli.ParseDiagnosticsFormat(flags.diagnostics)
+	if err != nil {
+		return err
+	}
+
+	// Errors in the source code are reported as diagnostics rather than with the command's usage.
+	cmd.SilenceUsage = true
 	if flags.interactive {
-		startParserRepl()
+		startParserRepl(output, format)
 	} else if len(args) > 0 {
-		parseSourceFile(args[0])
+		return parseSourceFile(args[0], output, format)
+	} else {
+		fmt.Println("No input provided. Exiting.")
 	}
+	return nil
 }
 
-func parseSourceFile(filepath string) {
-	visitor := printer.NewAstPrinter()
-	err := astutil.ParseSourceFileAndVisit(filepath, visitor)
+func parseSourceFile(path string, output cli.OutputFormat, format cli.DiagnosticsFormat) error {
+	source, err := cli.ReadSource(path, os.Stdin)
+	if err != nil {
+		return err
+	}
+	programAst, err := astutil.ParseSource(source)
 	if err != nil {
-		fmt.Println(err)
+		return cli.ReportSourceError(os.Stderr, format, err, source, cli.SourceName(path))
+	}
+	return writeAst(os.Stdout, programAst, output)
+}
+
+// Write the AST in the output format.
+func writeAst(w io.Writer, programAst *ast.Program, output cli.OutputFormat) error {
+	if output == cli.OutputJSON {
+		data, err := serialize.MarshalIndent(programAst, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
 	}
+	_, err := programAst.Accept(printer.NewAstPrinterWithWriter(w))
+	return err
 }
 
-func startParserRepl() {
-	visitor := printer.NewAstPrinter()
-	cli.NewRepl(func(line string) {
-		err := astutil.ParseLineAndVisit(line, visitor)
+func startParserRepl(output cli.OutputFormat, format cli.DiagnosticsFormat) {
+	repl := cli.NewRepl(func(line string) error {
+		programAst, err := astutil.ParseLine(line)
 		if err != nil {
-			fmt.Println(err)
+			return err
 		}
-	}).Start()
+		return writeAst(os.Stdout, programAst, output)
+	})
+	repl.SetDiagnosticsFormat(format)
+	repl.AddCommand(cli.AstCommand())
+	repl.AddCommand(cli.TokensCommand())
+	repl.EnableLineEditing(os.Stdin, cli.DefaultHistoryPath())
+	repl.Start()
 }
@@ -1,27 +1,39 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/kaschnit/golox/cmd/explain"
 	"github.com/kaschnit/golox/cmd/interpreter"
 	"github.com/kaschnit/golox/cmd/parser"
 	"github.com/kaschnit/golox/cmd/scanner"
+	"github.com/kaschnit/golox/pkg/cli"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
 	Use: "golox",
+
+	// Errors are printed once by Execute.
+	SilenceErrors: true,
 }
 
 func init() {
 	rootCmd.AddCommand(scanner.ScannerCmd)
 	rootCmd.AddCommand(parser.ParserCmd)
 	rootCmd.AddCommand(interpreter.InterpreterCmd)
+	rootCmd.AddCommand(explain.ExplainCmd)
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		// Commands report errors in the source code themselves, then exit with the code for the error.
+		var exitErr *cli.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
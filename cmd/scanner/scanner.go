@@ -1,51 +1,107 @@
 package scanner
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/kaschnit/golox/pkg/cli"
 	"github.com/kaschnit/golox/pkg/scanner"
+	"github.com/kaschnit/golox/pkg/token"
 	"github.com/spf13/cobra"
 )
 
 type ScannerFlags struct {
 	interactive bool
+	format      string
+	diagnostics string
 }
 
 var (
 	flags      = &ScannerFlags{}
 	ScannerCmd = &cobra.Command{
-		Use:   "scanner",
-		Run:   runScannerCmd,
-		Args:  cobra.OnlyValidArgs,
+		Use:   "scanner [file]",
+		RunE:  runScannerCmd,
+		Args:  cobra.MaximumNArgs(1),
 		Short: "Run the golox scanner",
-		Long:  "Run the golox scanner to produce a stream of tokens from lox source code",
+		Long: "Run the golox scanner to produce a stream of tokens from lox source code.\n" +
+			"The source code is read from the file, or from stdin if the file is '-'.\n" +
+			"With --format=json, each token is written as a JSON object on its own line, with its type, lexeme, literal and position.",
 	}
 )
 
 func init() {
 	ScannerCmd.Flags().BoolVarP(&flags.interactive, "interactive", "i", false, "Run in interactive mode.")
+	ScannerCmd.Flags().StringVar(&flags.format, "format", string(cli.OutputText), cli.OutputFormatUsage)
+	ScannerCmd.Flags().StringVar(&flags.diagnostics, "diagnostics-format", string(cli.DiagnosticsText), cli.DiagnosticsFormatUsage)
 }
 
-func runScannerCmd(_ *cobra.Command, _ []string) {
+func runScannerCmd(cmd *cobra.Command, args []string) error {
+	output, err := cli.ParseOutputFormat(flags.format)
+	if err != nil {
+		return err
+	}
+	format, err := cli.ParseDiagnosticsFormat(flags.diagnostics)
+	if err != nil {
+		return err
+	}
+
+	// Errors in the source code are reported as diagnostics rather than with the command's usage.
+	cmd.SilenceUsage = true
 	if flags.interactive {
-		startScannerRepl()
+		startScannerRepl(output, format)
+	} else if len(args) > 0 {
+		return scanSourceFile(args[0], output, format)
+	} else {
+		fmt.Println("No input provided. Exiting.")
+	}
+	return nil
+}
+
+func scanSourceFile(path string, output cli.OutputFormat, format cli.DiagnosticsFormat) error {
+	source, err := cli.ReadSource(path, os.Stdin)
+	if err != nil {
+		return err
+	}
+	tokens, err := scanner.NewScanner(source).ScanAllTokens()
+	if err != nil {
+		return cli.ReportSourceError(os.Stderr, format, err, source, cli.SourceName(path))
 	}
+	return writeTokens(os.Stdout, tokens, output)
 }
 
-func startScannerRepl() {
-	repl := cli.NewRepl(func(line string) {
+// Write the tokens in the output format, one token per line.
+func writeTokens(w io.Writer, tokens []*token.Token, output cli.OutputFormat) error {
+	if output == cli.OutputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		for _, t := range tokens {
+			if err := encoder.Encode(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, t := range tokens {
+		fmt.Fprintln(w, t)
+	}
+	return nil
+}
+
+func startScannerRepl(output cli.OutputFormat, format cli.DiagnosticsFormat) {
+	repl := cli.NewRepl(func(line string) error {
 		// Tokenize the input.
 		scanner := scanner.NewScanner(line)
 		tokens, err := scanner.ScanAllTokens()
 		if err != nil {
-			fmt.Println(err)
-			return
-		}
-
-		for i := 0; i < len(tokens); i++ {
-			fmt.Println(tokens[i])
+			return err
 		}
+		return writeTokens(os.Stdout, tokens, output)
 	})
+	repl.SetDiagnosticsFormat(format)
+	repl.AddCommand(cli.AstCommand())
+	repl.AddCommand(cli.TokensCommand())
+	repl.EnableLineEditing(os.Stdin, cli.DefaultHistoryPath())
 	repl.Start()
 }
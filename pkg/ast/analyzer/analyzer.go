@@ -4,6 +4,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/kaschnit/golox/pkg/ast"
 	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
 )
 
 type Scope map[string]bool
@@ -13,6 +14,7 @@ type ClassType int
 const (
 	ClassTypeNone ClassType = iota
 	ClassTypeClass
+	ClassTypeSubclass
 )
 
 type FunctionType int
@@ -29,6 +31,9 @@ type AstAnalyzer struct {
 	resolutionDistance  map[ast.Expr]int
 	currentClassType    ClassType
 	currentFunctionType FunctionType
+
+	// The number of loops enclosing the current node within the current function.
+	loopDepth int
 }
 
 func NewAstAnalyzer() *AstAnalyzer {
@@ -46,7 +51,7 @@ func (r *AstAnalyzer) VisitProgram(prg *ast.Program) (interface{}, error) {
 		_, err := stmt.Accept(r)
 		errs = multierror.Append(errs, err)
 	}
-	return nil, errs.ErrorOrNil()
+	return nil, loxerr.WithStage(errs.ErrorOrNil(), loxerr.StageAnalyze)
 }
 
 func (r *AstAnalyzer) VisitPrintStmt(s *ast.PrintStmt) (interface{}, error) {
@@ -58,7 +63,7 @@ func (r *AstAnalyzer) VisitReturnStmt(s *ast.ReturnStmt) (interface{}, error) {
 	errs := new(multierror.Error)
 	if s.Expression != nil {
 		if r.currentFunctionType == FunctionTypeConstructor {
-			err := loxerr.AtToken(s.Keyword, "Can't return a value from a constructor.")
+			err := loxerr.AtToken(s.Keyword, loxerr.ReturnFromInitializer, "Can't return a value from a constructor.")
 			errs = multierror.Append(errs, err)
 		}
 
@@ -69,6 +74,48 @@ func (r *AstAnalyzer) VisitReturnStmt(s *ast.ReturnStmt) (interface{}, error) {
 	return nil, errs.ErrorOrNil()
 }
 
+func (r *AstAnalyzer) VisitBreakStmt(s *ast.BreakStmt) (interface{}, error) {
+	if r.loopDepth == 0 {
+		return nil, loxerr.AtToken(s.Keyword, loxerr.LoopControlOutsideLoop, "Can't use 'break' outside of a loop.")
+	}
+	return nil, nil
+}
+
+func (r *AstAnalyzer) VisitContinueStmt(s *ast.ContinueStmt) (interface{}, error) {
+	if r.loopDepth == 0 {
+		return nil, loxerr.AtToken(s.Keyword, loxerr.LoopControlOutsideLoop, "Can't use 'continue' outside of a loop.")
+	}
+	return nil, nil
+}
+
+func (r *AstAnalyzer) VisitThrowStmt(s *ast.ThrowStmt) (interface{}, error) {
+	_, err := s.Value.Accept(r)
+	return nil, err
+}
+
+func (r *AstAnalyzer) VisitTryStmt(s *ast.TryStmt) (interface{}, error) {
+	errs := new(multierror.Error)
+
+	_, err := s.Body.Accept(r)
+	errs = multierror.Append(errs, err)
+
+	// The caught value is bound in its own scope, enclosing the catch block's scope.
+	if s.CatchBody != nil {
+		r.beginScope()
+		r.defineName(s.CatchName.Lexeme)
+		_, err = s.CatchBody.Accept(r)
+		errs = multierror.Append(errs, err)
+		r.endScope()
+	}
+
+	if s.FinallyBody != nil {
+		_, err = s.FinallyBody.Accept(r)
+		errs = multierror.Append(errs, err)
+	}
+
+	return nil, errs.ErrorOrNil()
+}
+
 func (r *AstAnalyzer) VisitExprStmt(s *ast.ExprStmt) (interface{}, error) {
 	_, err := s.Expression.Accept(r)
 	return nil, err
@@ -96,8 +143,15 @@ func (r *AstAnalyzer) VisitWhileStmt(s *ast.WhileStmt) (interface{}, error) {
 	_, err := s.Condition.Accept(r)
 	errs = multierror.Append(errs, err)
 
+	r.loopDepth++
 	_, err = s.LoopStatement.Accept(r)
 	errs = multierror.Append(errs, err)
+	r.loopDepth--
+
+	if s.Increment != nil {
+		_, err = s.Increment.Accept(r)
+		errs = multierror.Append(errs, err)
+	}
 
 	return nil, errs.ErrorOrNil()
 }
@@ -127,6 +181,20 @@ func (r *AstAnalyzer) VisitClassStmt(s *ast.ClassStmt) (interface{}, error) {
 
 	r.defineName(s.Name.Lexeme)
 
+	if s.Superclass != nil {
+		if s.Superclass.Name.Lexeme == s.Name.Lexeme {
+			err := loxerr.AtToken(s.Superclass.Name, loxerr.InheritFromSelf, "A class can't inherit from itself.")
+			errs = multierror.Append(errs, err)
+		}
+
+		r.currentClassType = ClassTypeSubclass
+		_, err := s.Superclass.Accept(r)
+		errs = multierror.Append(errs, err)
+
+		r.beginScope()
+		r.defineName("super")
+	}
+
 	r.beginScope()
 
 	r.defineName("this")
@@ -139,8 +207,17 @@ func (r *AstAnalyzer) VisitClassStmt(s *ast.ClassStmt) (interface{}, error) {
 		err := r.resolveFunction(method, FunctionTypeMethod)
 		errs = multierror.Append(errs, err)
 	}
+	for _, method := range s.StaticMethods {
+		err := r.resolveFunction(method, FunctionTypeMethod)
+		errs = multierror.Append(errs, err)
+	}
 
 	r.endScope()
+
+	if s.Superclass != nil {
+		r.endScope()
+	}
+
 	return nil, errs.ErrorOrNil()
 }
 
@@ -163,15 +240,23 @@ func (r *AstAnalyzer) VisitVarStmt(s *ast.VarStmt) (interface{}, error) {
 	return nil, errs.ErrorOrNil()
 }
 
+func (r *AstAnalyzer) VisitImportStmt(s *ast.ImportStmt) (interface{}, error) {
+	r.defineName(s.Name.Lexeme)
+	return nil, nil
+}
+
 func (r *AstAnalyzer) VisitAssignExpr(e *ast.AssignExpr) (interface{}, error) {
 	_, err := e.Right.Accept(r)
+	r.resolveLocal(e, e.Left.Lexeme)
 	return nil, err
 }
 
 func (r *AstAnalyzer) VisitCallExpr(e *ast.CallExpr) (interface{}, error) {
 	errs := new(multierror.Error)
 
-	e.Callee.Accept(r)
+	_, err := e.Callee.Accept(r)
+	errs = multierror.Append(errs, err)
+
 	for _, arg := range e.Args {
 		_, err := arg.Accept(r)
 		errs = multierror.Append(errs, err)
@@ -191,6 +276,18 @@ func (r *AstAnalyzer) VisitBinaryExpr(e *ast.BinaryExpr) (interface{}, error) {
 	return nil, errs.ErrorOrNil()
 }
 
+func (r *AstAnalyzer) VisitLogicalExpr(e *ast.LogicalExpr) (interface{}, error) {
+	errs := new(multierror.Error)
+
+	_, err := e.Left.Accept(r)
+	errs = multierror.Append(errs, err)
+
+	_, err = e.Right.Accept(r)
+	errs = multierror.Append(errs, err)
+
+	return nil, errs.ErrorOrNil()
+}
+
 func (r *AstAnalyzer) VisitUnaryExpr(e *ast.UnaryExpr) (interface{}, error) {
 	_, err := e.Right.Accept(r)
 	return nil, err
@@ -210,10 +307,11 @@ func (r *AstAnalyzer) VisitVarExpr(e *ast.VarExpr) (interface{}, error) {
 
 	if len(r.scopes) > 0 {
 		if val, ok := r.scopes[len(r.scopes)-1][e.Name.Lexeme]; ok && !val {
-			err := loxerr.AtToken(e.Name, "Can't read local variable in its own initializer.")
+			err := loxerr.AtToken(e.Name, loxerr.LocalInOwnInitializer, "Can't read local variable in its own initializer.")
 			errs = multierror.Append(errs, err)
 		}
 	}
+	r.resolveLocal(e, e.Name.Lexeme)
 
 	return nil, errs.ErrorOrNil()
 }
@@ -235,29 +333,119 @@ func (r *AstAnalyzer) VisitSetPropertyExpr(e *ast.SetPropertyExpr) (interface{},
 	return nil, errs.ErrorOrNil()
 }
 
+func (r *AstAnalyzer) VisitListExpr(e *ast.ListExpr) (interface{}, error) {
+	errs := new(multierror.Error)
+	for _, element := range e.Elements {
+		_, err := element.Accept(r)
+		errs = multierror.Append(errs, err)
+	}
+	return nil, errs.ErrorOrNil()
+}
+
+func (r *AstAnalyzer) VisitMapExpr(e *ast.MapExpr) (interface{}, error) {
+	errs := new(multierror.Error)
+	for i := range e.Keys {
+		_, err := e.Keys[i].Accept(r)
+		errs = multierror.Append(errs, err)
+
+		_, err = e.Values[i].Accept(r)
+		errs = multierror.Append(errs, err)
+	}
+	return nil, errs.ErrorOrNil()
+}
+
+func (r *AstAnalyzer) VisitFunctionExpr(e *ast.FunctionExpr) (interface{}, error) {
+	err := r.resolveFunctionBody(e.Params, e.Body, FunctionTypeFunction)
+	return nil, err
+}
+
+func (r *AstAnalyzer) VisitIndexGetExpr(e *ast.IndexGetExpr) (interface{}, error) {
+	errs := new(multierror.Error)
+
+	_, err := e.Object.Accept(r)
+	errs = multierror.Append(errs, err)
+
+	_, err = e.Index.Accept(r)
+	errs = multierror.Append(errs, err)
+
+	return nil, errs.ErrorOrNil()
+}
+
+func (r *AstAnalyzer) VisitIndexSetExpr(e *ast.IndexSetExpr) (interface{}, error) {
+	errs := new(multierror.Error)
+
+	_, err := e.Object.Accept(r)
+	errs = multierror.Append(errs, err)
+
+	_, err = e.Index.Accept(r)
+	errs = multierror.Append(errs, err)
+
+	_, err = e.Value.Accept(r)
+	errs = multierror.Append(errs, err)
+
+	return nil, errs.ErrorOrNil()
+}
+
 func (r *AstAnalyzer) VisitThisExpr(e *ast.ThisExpr) (interface{}, error) {
 	errs := new(multierror.Error)
 	if r.currentClassType == ClassTypeNone {
-		err := loxerr.AtToken(e.Keyword, "Can't use 'this' outside of a class.")
+		err := loxerr.AtToken(e.Keyword, loxerr.ThisOutsideClass, "Can't use 'this' outside of a class.")
+		errs = multierror.Append(errs, err)
+	}
+	r.resolveLocal(e, e.Keyword.Lexeme)
+	return nil, errs.ErrorOrNil()
+}
+
+func (r *AstAnalyzer) VisitSuperExpr(e *ast.SuperExpr) (interface{}, error) {
+	errs := new(multierror.Error)
+	if r.currentClassType == ClassTypeNone {
+		err := loxerr.AtToken(e.Keyword, loxerr.SuperOutsideClass, "Can't use 'super' outside of a class.")
+		errs = multierror.Append(errs, err)
+	} else if r.currentClassType != ClassTypeSubclass {
+		err := loxerr.AtToken(e.Keyword, loxerr.SuperWithoutSuperclass, "Can't use 'super' in a class with no superclass.")
 		errs = multierror.Append(errs, err)
 	}
+	r.resolveLocal(e, e.Keyword.Lexeme)
 	return nil, errs.ErrorOrNil()
 }
 
+// Get the number of scopes between the expression and the scope that declares the variable it
+// refers to. Returns false if the variable isn't declared in any enclosing scope, which means it's global.
+func (r *AstAnalyzer) ResolutionDistance(expr ast.Expr) (distance int, ok bool) {
+	distance, ok = r.resolutionDistance[expr]
+	return distance, ok
+}
+
+func (r *AstAnalyzer) resolveLocal(expr ast.Expr, name string) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name]; ok {
+			r.resolutionDistance[expr] = len(r.scopes) - 1 - i
+			return
+		}
+	}
+}
+
 func (r *AstAnalyzer) resolveFunction(f *ast.FunctionStmt, kind FunctionType) error {
+	return r.resolveFunctionBody(f.Params, f.Body, kind)
+}
+
+func (r *AstAnalyzer) resolveFunctionBody(params []*token.Token, body []ast.Stmt, kind FunctionType) error {
 	errs := new(multierror.Error)
 
-	enclosingFunctionType := r.currentFunctionType
+	// Loops outside of the function can't be exited from inside of it.
+	enclosingFunctionType, enclosingLoopDepth := r.currentFunctionType, r.loopDepth
 	defer func() {
 		r.currentFunctionType = enclosingFunctionType
+		r.loopDepth = enclosingLoopDepth
 	}()
 	r.currentFunctionType = kind
+	r.loopDepth = 0
 
 	r.beginScope()
-	for _, param := range f.Params {
+	for _, param := range params {
 		r.defineName(param.Lexeme)
 	}
-	for _, stmt := range f.Body {
+	for _, stmt := range body {
 		_, err := stmt.Accept(r)
 		errs = multierror.Append(errs, err)
 	}
@@ -85,3 +85,63 @@ func TestAnalyzer_InvalidProgram_ThisOutsideOfClass(t *testing.T) {
 	assert.Equal(t, tokentype.THIS, errs[1].(*loxerr.LoxErrorAtToken).Token.Type)
 	assert.ErrorContains(t, errs[1], "this")
 }
+
+func TestAnalyzer_InvalidProgram_SuperOutsideOfSubclass(t *testing.T) {
+	result, err := analyzeProgram(t, "invalid/analyzer/SuperOutsideOfSubclass.lox")
+	assert.Nil(t, result)
+	assert.IsType(t, &multierror.Error{}, err)
+
+	errs := err.(*multierror.Error).Errors
+	assert.Len(t, errs, 3)
+
+	assert.IsType(t, &loxerr.LoxErrorAtToken{}, errs[0])
+	assert.Equal(t, tokentype.SUPER, errs[0].(*loxerr.LoxErrorAtToken).Token.Type)
+	assert.ErrorContains(t, errs[0], "no superclass")
+
+	assert.IsType(t, &loxerr.LoxErrorAtToken{}, errs[1])
+	assert.Equal(t, tokentype.SUPER, errs[1].(*loxerr.LoxErrorAtToken).Token.Type)
+	assert.ErrorContains(t, errs[1], "outside of a class")
+
+	assert.IsType(t, &loxerr.LoxErrorAtToken{}, errs[2])
+	assert.Equal(t, tokentype.SUPER, errs[2].(*loxerr.LoxErrorAtToken).Token.Type)
+	assert.ErrorContains(t, errs[2], "outside of a class")
+}
+
+func TestAnalyzer_InvalidProgram_ClassInheritsFromItself(t *testing.T) {
+	result, err := analyzeProgram(t, "invalid/analyzer/ClassInheritsFromItself.lox")
+	assert.Nil(t, result)
+	assert.IsType(t, &multierror.Error{}, err)
+
+	errs := err.(*multierror.Error).Errors
+	assert.Len(t, errs, 1)
+
+	assert.IsType(t, &loxerr.LoxErrorAtToken{}, errs[0])
+	assert.Equal(t, "Ouroboros", errs[0].(*loxerr.LoxErrorAtToken).Token.Lexeme)
+	assert.ErrorContains(t, errs[0], "inherit from itself")
+}
+
+func TestAnalyzer_InvalidProgram_BreakOutsideOfLoop(t *testing.T) {
+	result, err := analyzeProgram(t, "invalid/analyzer/BreakOutsideOfLoop.lox")
+	assert.Nil(t, result)
+	assert.IsType(t, &multierror.Error{}, err)
+
+	errs := err.(*multierror.Error).Errors
+	assert.Len(t, errs, 1)
+
+	assert.IsType(t, &loxerr.LoxErrorAtToken{}, errs[0])
+	assert.Equal(t, tokentype.BREAK, errs[0].(*loxerr.LoxErrorAtToken).Token.Type)
+	assert.ErrorContains(t, errs[0], "Can't use 'break' outside of a loop.")
+}
+
+func TestAnalyzer_InvalidProgram_ContinueInFunctionInLoop(t *testing.T) {
+	result, err := analyzeProgram(t, "invalid/analyzer/ContinueInFunctionInLoop.lox")
+	assert.Nil(t, result)
+	assert.IsType(t, &multierror.Error{}, err)
+
+	errs := err.(*multierror.Error).Errors
+	assert.Len(t, errs, 1)
+
+	assert.IsType(t, &loxerr.LoxErrorAtToken{}, errs[0])
+	assert.Equal(t, tokentype.CONTINUE, errs[0].(*loxerr.LoxErrorAtToken).Token.Type)
+	assert.ErrorContains(t, errs[0], "Can't use 'continue' outside of a loop.")
+}
@@ -57,3 +57,63 @@ func TestAnalyzer_ConstructProgram_IfElse(t *testing.T) {
 func TestAnalyzer_ConstructProgram_IfElseIf(t *testing.T) {
 	assertProgramHasNoAnalyzerErrors(t, "constructs/IfElseIf.lox")
 }
+
+func TestAnalyzer_ConstructProgram_ClassInheritance(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/ClassInheritance.lox")
+}
+
+func TestAnalyzer_ConstructProgram_ClassSuperCall(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/ClassSuperCall.lox")
+}
+
+func TestAnalyzer_ConstructProgram_ClassStaticInheritance(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/ClassStaticInheritance.lox")
+}
+
+func TestAnalyzer_ConstructProgram_ShadowedClosure(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/ShadowedClosure.lox")
+}
+
+func TestAnalyzer_ConstructProgram_LongRunningLoop(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/LongRunningLoop.lox")
+}
+
+func TestAnalyzer_ConstructProgram_LogicalShortCircuit(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/LogicalShortCircuit.lox")
+}
+
+func TestAnalyzer_ConstructProgram_StringOperations(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/StringOperations.lox")
+}
+
+func TestAnalyzer_ConstructProgram_Imports(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/Imports.lox")
+}
+
+func TestAnalyzer_ConstructProgram_Lambdas(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/Lambdas.lox")
+}
+
+func TestAnalyzer_ConstructProgram_Lists(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/Lists.lox")
+}
+
+func TestAnalyzer_ConstructProgram_Maps(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/Maps.lox")
+}
+
+func TestAnalyzer_ConstructProgram_BreakContinue(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/BreakContinue.lox")
+}
+
+func TestAnalyzer_ConstructProgram_ClassConstructorReinit(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/ClassConstructorReinit.lox")
+}
+
+func TestAnalyzer_ConstructProgram_Exceptions(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/Exceptions.lox")
+}
+
+func TestAnalyzer_ConstructProgram_NativeMath(t *testing.T) {
+	assertProgramHasNoAnalyzerErrors(t, "constructs/NativeMath.lox")
+}
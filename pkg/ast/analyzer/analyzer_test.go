@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/kaschnit/golox/pkg/ast"
+	"github.com/kaschnit/golox/pkg/ast/astutil"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func analyzeLine(t *testing.T, line string) (*AstAnalyzer, *ast.Program) {
+	programAst, err := astutil.ParseLine(line)
+	assert.Nil(t, err)
+
+	analyzer := NewAstAnalyzer()
+	_, err = analyzer.VisitProgram(programAst)
+	assert.Nil(t, err)
+
+	return analyzer, programAst
+}
+
+func TestAnalyzer_ResolutionDistance_Global(t *testing.T) {
+	analyzer, programAst := analyzeLine(t, "var a = 1; print a;")
+
+	printStmt := programAst.Statements[1].(*ast.PrintStmt)
+	_, ok := analyzer.ResolutionDistance(printStmt.Expression)
+	assert.False(t, ok)
+}
+
+func TestAnalyzer_ResolutionDistance_EnclosingBlock(t *testing.T) {
+	analyzer, programAst := analyzeLine(t, "{ var a = 1; { print a; a = 2; } }")
+
+	outer := programAst.Statements[0].(*ast.BlockStmt)
+	inner := outer.Statements[1].(*ast.BlockStmt)
+
+	printStmt := inner.Statements[0].(*ast.PrintStmt)
+	distance, ok := analyzer.ResolutionDistance(printStmt.Expression)
+	assert.True(t, ok)
+	assert.Equal(t, 1, distance)
+
+	assignStmt := inner.Statements[1].(*ast.ExprStmt)
+	distance, ok = analyzer.ResolutionDistance(assignStmt.Expression)
+	assert.True(t, ok)
+	assert.Equal(t, 1, distance)
+}
+
+func TestAnalyzer_ResolutionDistance_ShadowedLater(t *testing.T) {
+	analyzer, programAst := analyzeLine(t, "var a = 1; { fun f() { print a; } var a = 2; }")
+
+	block := programAst.Statements[1].(*ast.BlockStmt)
+	function := block.Statements[0].(*ast.FunctionStmt)
+	printStmt := function.Body[0].(*ast.PrintStmt)
+
+	// The block's "a" is declared after the function, so the function refers to the global.
+	_, ok := analyzer.ResolutionDistance(printStmt.Expression)
+	assert.False(t, ok)
+}
+
+func TestAnalyzer_ResolutionDistance_This(t *testing.T) {
+	analyzer, programAst := analyzeLine(t, "class A { m() { return this; } }")
+
+	classStmt := programAst.Statements[0].(*ast.ClassStmt)
+	returnStmt := classStmt.Methods[0].Body[0].(*ast.ReturnStmt)
+	distance, ok := analyzer.ResolutionDistance(returnStmt.Expression)
+	assert.True(t, ok)
+	assert.Equal(t, 1, distance)
+}
+
+func TestAnalyzer_ResolutionDistance_CatchVariable(t *testing.T) {
+	analyzer, programAst := analyzeLine(t, "{ try {} catch (e) { print e; } }")
+
+	block := programAst.Statements[0].(*ast.BlockStmt)
+	tryStmt := block.Statements[0].(*ast.TryStmt)
+	printStmt := tryStmt.CatchBody.Statements[0].(*ast.PrintStmt)
+
+	// The catch variable is in its own scope, which encloses the catch block's scope.
+	distance, ok := analyzer.ResolutionDistance(printStmt.Expression)
+	assert.True(t, ok)
+	assert.Equal(t, 1, distance)
+}
+
+func TestAnalyzer_ResolutionDistance_ArrowFunctionParam(t *testing.T) {
+	analyzer, programAst := analyzeLine(t, "{ var a = 1; var f = (b) => a + b; }")
+
+	block := programAst.Statements[0].(*ast.BlockStmt)
+	varStmt := block.Statements[1].(*ast.VarStmt)
+	function := varStmt.Right.(*ast.FunctionExpr)
+	returnStmt := function.Body[0].(*ast.ReturnStmt)
+	sum := returnStmt.Expression.(*ast.BinaryExpr)
+
+	distance, ok := analyzer.ResolutionDistance(sum.Left)
+	assert.True(t, ok)
+	assert.Equal(t, 1, distance)
+
+	distance, ok = analyzer.ResolutionDistance(sum.Right)
+	assert.True(t, ok)
+	assert.Equal(t, 0, distance)
+}
+
+func TestAnalyzer_ReturnValueFromLambdaInConstructor(t *testing.T) {
+	// Only the constructor itself can't return a value.
+	analyzeLine(t, "class A { init() { this.f = () => 1; } }")
+}
+
+func TestAnalyzer_ErrorsAreInAnalyzeStage(t *testing.T) {
+	programAst, err := astutil.ParseLine("print this;")
+	assert.Nil(t, err)
+
+	_, err = NewAstAnalyzer().VisitProgram(programAst)
+	assert.Error(t, err)
+	assert.Equal(t, loxerr.StageAnalyze, err.(*multierror.Error).Errors[0].(loxerr.Staged).Stage())
+	assert.Equal(t, loxerr.ThisOutsideClass, err.(*multierror.Error).Errors[0].(loxerr.Coded).Code())
+}
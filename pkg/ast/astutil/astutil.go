@@ -27,16 +27,7 @@ func ParseSourceFileAndVisit(filepath string, visitors ...ast.AstVisitor) error
 // Parse the line of source code and apply the visitor to the root
 // of the AST that is produced, visiting each node in the AST.
 func ParseLineAndVisit(line string, visitors ...ast.AstVisitor) error {
-	// Tokenize the input.
-	scanner := scanner.NewScanner(line)
-	tokens, err := scanner.ScanAllTokens()
-	if err != nil {
-		return err
-	}
-
-	// Parse the input.
-	parser := parser.NewParser(tokens)
-	programAst, err := parser.Parse()
+	programAst, err := ParseLine(line)
 	if err != nil {
 		return err
 	}
@@ -51,3 +42,33 @@ func ParseLineAndVisit(line string, visitors ...ast.AstVisitor) error {
 
 	return nil
 }
+
+// Parse the line of source code, producing an AST.
+func ParseLine(line string) (*ast.Program, error) {
+	return ParseSource(line)
+}
+
+// Parse the source code of a whole program, producing an AST.
+func ParseSource(source string) (*ast.Program, error) {
+	// Tokenize the input.
+	scanner := scanner.NewScanner(source)
+	tokens, err := scanner.ScanAllTokens()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the input.
+	parser := parser.NewParser(tokens)
+	return parser.Parse()
+}
+
+// Parse source code entered at a REPL, producing an AST. The semicolon after a final expression
+// statement may be left out.
+func ParseReplInput(input string) (*ast.Program, error) {
+	scanner := scanner.NewScanner(input)
+	tokens, err := scanner.ScanAllTokens()
+	if err != nil {
+		return nil, err
+	}
+	return parser.NewParser(tokens).ParseRepl()
+}
@@ -1,38 +1,1264 @@
 package emitter
 
 import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
 	"github.com/kaschnit/golox/pkg/ast"
+	"github.com/kaschnit/golox/pkg/bytecode"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+)
+
+// The maximum number of locals, upvalues, params or args that fit in a 1-byte operand.
+const maxByteOperand = 256
+
+// The maximum distance that fits in a 2-byte jump operand.
+const maxJump = 1<<16 - 1
+
+// The maximum number of list elements that fit in a 2-byte operand.
+const maxListElements = 1<<16 - 1
+
+// The maximum number of map entries that fit in a 2-byte operand.
+const maxMapEntries = 1<<16 - 1
+
+type functionType int
+
+const (
+	functionTypeScript functionType = iota
+	functionTypeFunction
+	functionTypeMethod
+	functionTypeInitializer
 )
 
+// A local variable living in a stack slot of the function being compiled.
+type local struct {
+	name string
+
+	// The scope depth the local was declared in, or -1 while its initializer is being compiled.
+	depth int
+
+	// Whether a closure captures the local, meaning it must be moved to the heap when it goes out of scope.
+	isCaptured bool
+
+	// Whether the local is out of scope for the code being compiled, which happens while a finally
+	// block is compiled inline at a point where locals of the try statement are still on the stack.
+	hidden bool
+}
+
+// A reference from a closure to a variable of an enclosing function.
+type upvalue struct {
+	// The slot of the enclosing function's local if isLocal, otherwise the index of the enclosing function's upvalue.
+	index   byte
+	isLocal bool
+}
+
+// A loop being compiled, whose break and continue jumps are patched once the loop is finished.
+type loop struct {
+	enclosing *loop
+
+	// The scope depth the loop statement is in. Locals deeper than this are discarded by break and continue.
+	scopeDepth int
+
+	// The innermost try statement enclosing the loop.
+	tries *tryBlock
+
+	breakJumps    []int
+	continueJumps []int
+}
+
+// A try or catch block being compiled. Jumping out of it with return, break or continue
+// first removes its exception handler, if it has one, and then runs its finally block, if any.
+type tryBlock struct {
+	enclosing *tryBlock
+
+	// The scope depth the try statement is in. Locals deeper than this are hidden from the finally block.
+	scopeDepth int
+
+	// The innermost loop enclosing the try statement, which break and continue in the finally block refer to.
+	loop *loop
+
+	hasHandler bool
+	finally    *ast.BlockStmt
+}
+
+// The compilation state of a single function. Each nested function declaration
+// gets its own functionScope that points back to the enclosing one.
+type functionScope struct {
+	enclosing  *functionScope
+	function   *bytecode.Function
+	kind       functionType
+	locals     []local
+	upvalues   []upvalue
+	scopeDepth int
+
+	// The innermost loop being compiled in the function, if any.
+	loop *loop
+
+	// The innermost try or catch block being compiled in the function, if any.
+	tries *tryBlock
+}
+
+func newFunctionScope(enclosing *functionScope, name string, kind functionType) *functionScope {
+	// Slot 0 holds the callee, or the receiver for methods.
+	slotZero := ""
+	if kind == functionTypeMethod || kind == functionTypeInitializer {
+		slotZero = "this"
+	}
+	return &functionScope{
+		enclosing:  enclosing,
+		function:   bytecode.NewFunction(name),
+		kind:       kind,
+		locals:     []local{{name: slotZero, depth: 0}},
+		upvalues:   make([]upvalue, 0),
+		scopeDepth: 0,
+	}
+}
+
 // Implementation of AstVisitor that emits bytecode corresponding to the visited AST.
-type AstEmitter struct{}
+type AstEmitter struct {
+	current *functionScope
+
+	// The most recently visited token, used to attribute emitted bytes to a source location.
+	token *token.Token
+}
 
 // Create an AstEmitter.
 func NewAstEmitter() *AstEmitter {
-	return &AstEmitter{}
+	return &AstEmitter{
+		current: newFunctionScope(nil, "", functionTypeScript),
+		token:   nil,
+	}
+}
+
+// Compile the program to a function representing the top-level script.
+func Compile(program *ast.Program) (*bytecode.Function, error) {
+	result, err := NewAstEmitter().VisitProgram(program)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*bytecode.Function), nil
+}
+
+// Compile the program to a function representing the top-level script, which returns the value of
+// the program's last statement if it's an expression statement, or nil otherwise.
+func CompileWithResult(program *ast.Program) (*bytecode.Function, error) {
+	if len(program.Statements) == 0 {
+		return Compile(program)
+	}
+	last, ok := program.Statements[len(program.Statements)-1].(*ast.ExprStmt)
+	if !ok {
+		return Compile(program)
+	}
+
+	e := NewAstEmitter()
+	if err := e.emitStatements(program.Statements[:len(program.Statements)-1]); err != nil {
+		return nil, err
+	}
+	if _, err := last.Expression.Accept(e); err != nil {
+		return nil, loxerr.WithStage(err, loxerr.StageAnalyze)
+	}
+	e.emitOp(bytecode.OP_RETURN)
+	return e.current.function, nil
+}
+
+func (e *AstEmitter) VisitProgram(p *ast.Program) (interface{}, error) {
+	if err := e.emitStatements(p.Statements); err != nil {
+		return nil, err
+	}
+
+	e.emitReturn()
+	return e.current.function, nil
+}
+
+// Emit each of the statements, collecting the errors of all of them.
+func (e *AstEmitter) emitStatements(statements []ast.Stmt) error {
+	errs := new(multierror.Error)
+	for _, stmt := range statements {
+		_, err := stmt.Accept(e)
+		errs = multierror.Append(errs, err)
+	}
+
+	// Errors found while compiling are reported like the errors found by static analysis.
+	return loxerr.WithStage(errs.ErrorOrNil(), loxerr.StageAnalyze)
+}
+
+func (e *AstEmitter) VisitPrintStmt(s *ast.PrintStmt) (interface{}, error) {
+	if _, err := s.Expression.Accept(e); err != nil {
+		return nil, err
+	}
+	e.emitOp(bytecode.OP_PRINT)
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitReturnStmt(s *ast.ReturnStmt) (interface{}, error) {
+	e.token = s.Keyword
+	if e.current.kind == functionTypeScript {
+		return nil, loxerr.AtToken(s.Keyword, loxerr.ReturnFromTopLevel, "Can't return from top-level code.")
+	}
+
+	if s.Expression == nil {
+		e.emitReturnValue()
+	} else if e.current.kind == functionTypeInitializer {
+		return nil, loxerr.AtToken(s.Keyword, loxerr.ReturnFromInitializer, "Can't return a value from a constructor.")
+	} else if _, err := s.Expression.Accept(e); err != nil {
+		return nil, err
+	}
+
+	// The return value stays on the stack while the enclosing finally blocks run.
+	if e.current.tries != nil {
+		if err := e.addSyntheticLocal(); err != nil {
+			return nil, err
+		}
+		if err := e.exitTries(nil); err != nil {
+			return nil, err
+		}
+		e.current.locals = e.current.locals[:len(e.current.locals)-1]
+	}
+
+	e.token = s.Keyword
+	e.emitOp(bytecode.OP_RETURN)
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitBreakStmt(s *ast.BreakStmt) (interface{}, error) {
+	e.token = s.Keyword
+	l := e.current.loop
+	if l == nil {
+		return nil, loxerr.AtToken(s.Keyword, loxerr.LoopControlOutsideLoop, "Can't use 'break' outside of a loop.")
+	}
+	if err := e.exitTries(l.tries); err != nil {
+		return nil, err
+	}
+	e.token = s.Keyword
+	e.discardLocals(l.scopeDepth)
+	l.breakJumps = append(l.breakJumps, e.emitJump(bytecode.OP_JUMP))
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitContinueStmt(s *ast.ContinueStmt) (interface{}, error) {
+	e.token = s.Keyword
+	l := e.current.loop
+	if l == nil {
+		return nil, loxerr.AtToken(s.Keyword, loxerr.LoopControlOutsideLoop, "Can't use 'continue' outside of a loop.")
+	}
+	if err := e.exitTries(l.tries); err != nil {
+		return nil, err
+	}
+	e.token = s.Keyword
+	e.discardLocals(l.scopeDepth)
+	l.continueJumps = append(l.continueJumps, e.emitJump(bytecode.OP_JUMP))
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitThrowStmt(s *ast.ThrowStmt) (interface{}, error) {
+	if _, err := s.Value.Accept(e); err != nil {
+		return nil, err
+	}
+	e.token = s.Keyword
+	e.emitOp(bytecode.OP_THROW)
+	return nil, nil
+}
+
+// Compile a try statement. The try block runs with an exception handler installed, which jumps
+// to the catch block with the exception on the stack. When there is a finally block, it is
+// compiled inline on every path out of the statement. Exceptions thrown out of the catch block
+// are caught by a second handler that runs the finally block and then rethrows them.
+func (e *AstEmitter) VisitTryStmt(s *ast.TryStmt) (interface{}, error) {
+	scopeDepth := e.current.scopeDepth
+	endJumps := make([]int, 0)
+
+	e.token = s.Keyword
+	handlerJump := e.emitJump(bytecode.OP_TRY)
+	if err := e.tryBlock(s.Body, scopeDepth, true, s.FinallyBody); err != nil {
+		return nil, err
+	}
+	e.token = s.Keyword
+	e.emitOp(bytecode.OP_END_TRY)
+	if err := e.finallyBlock(s.FinallyBody); err != nil {
+		return nil, err
+	}
+	endJumps = append(endJumps, e.emitJump(bytecode.OP_JUMP))
+
+	if err := e.patchJump(handlerJump); err != nil {
+		return nil, err
+	}
+	if s.CatchBody == nil {
+		// The exception is the only value on the stack above the locals.
+		if err := e.rethrowAfterFinally(1, s.FinallyBody); err != nil {
+			return nil, err
+		}
+	} else {
+		// The thrown value replaces the exception on top of the stack and becomes the catch variable's slot.
+		e.emitOp(bytecode.OP_CATCH)
+		e.beginScope()
+		if err := e.declareVariable(s.CatchName); err != nil {
+			return nil, err
+		}
+		e.markInitialized()
+
+		catchHandlerJump := -1
+		if s.FinallyBody != nil {
+			e.token = s.Keyword
+			catchHandlerJump = e.emitJump(bytecode.OP_TRY)
+		}
+		if err := e.tryBlock(s.CatchBody, scopeDepth, s.FinallyBody != nil, s.FinallyBody); err != nil {
+			return nil, err
+		}
+		if s.FinallyBody != nil {
+			e.token = s.Keyword
+			e.emitOp(bytecode.OP_END_TRY)
+		}
+		e.endScope()
+
+		if s.FinallyBody != nil {
+			if err := e.finallyBlock(s.FinallyBody); err != nil {
+				return nil, err
+			}
+			endJumps = append(endJumps, e.emitJump(bytecode.OP_JUMP))
+
+			// The catch variable and the new exception are on the stack above the locals.
+			if err := e.patchJump(catchHandlerJump); err != nil {
+				return nil, err
+			}
+			if err := e.rethrowAfterFinally(2, s.FinallyBody); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, endJump := range endJumps {
+		if err := e.patchJump(endJump); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitExprStmt(s *ast.ExprStmt) (interface{}, error) {
+	if _, err := s.Expression.Accept(e); err != nil {
+		return nil, err
+	}
+	e.emitOp(bytecode.OP_POP)
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitIfStmt(s *ast.IfStmt) (interface{}, error) {
+	if _, err := s.Condition.Accept(e); err != nil {
+		return nil, err
+	}
+
+	thenJump := e.emitJump(bytecode.OP_JUMP_IF_FALSE)
+	e.emitOp(bytecode.OP_POP)
+	if _, err := s.ThenStatement.Accept(e); err != nil {
+		return nil, err
+	}
+
+	elseJump := e.emitJump(bytecode.OP_JUMP)
+	if err := e.patchJump(thenJump); err != nil {
+		return nil, err
+	}
+	e.emitOp(bytecode.OP_POP)
+	if s.ElseStatement != nil {
+		if _, err := s.ElseStatement.Accept(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, e.patchJump(elseJump)
+}
+
+func (e *AstEmitter) VisitWhileStmt(s *ast.WhileStmt) (interface{}, error) {
+	loopStart := len(e.chunk().Code)
+	if _, err := s.Condition.Accept(e); err != nil {
+		return nil, err
+	}
+
+	exitJump := e.emitJump(bytecode.OP_JUMP_IF_FALSE)
+	e.emitOp(bytecode.OP_POP)
+
+	l := &loop{enclosing: e.current.loop, scopeDepth: e.current.scopeDepth, tries: e.current.tries}
+	e.current.loop = l
+	_, err := s.LoopStatement.Accept(e)
+	e.current.loop = l.enclosing
+	if err != nil {
+		return nil, err
+	}
+
+	// Continue jumps to the increment, which every iteration runs before the condition is checked again.
+	for _, continueJump := range l.continueJumps {
+		if err := e.patchJump(continueJump); err != nil {
+			return nil, err
+		}
+	}
+	if s.Increment != nil {
+		if _, err := s.Increment.Accept(e); err != nil {
+			return nil, err
+		}
+		e.emitOp(bytecode.OP_POP)
+	}
+
+	// Runtime errors raised when jumping back, such as running out of the step budget, name the loop.
+	if s.Keyword != nil {
+		e.token = s.Keyword
+	}
+	if err := e.emitLoop(loopStart); err != nil {
+		return nil, err
+	}
+
+	if err := e.patchJump(exitJump); err != nil {
+		return nil, err
+	}
+	e.emitOp(bytecode.OP_POP)
+
+	// Break jumps past the pop of the condition, since the body already popped it.
+	for _, breakJump := range l.breakJumps {
+		if err := e.patchJump(breakJump); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitBlockStmt(s *ast.BlockStmt) (interface{}, error) {
+	e.beginScope()
+	for _, stmt := range s.Statements {
+		if _, err := stmt.Accept(e); err != nil {
+			return nil, err
+		}
+	}
+	e.endScope()
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitClassStmt(s *ast.ClassStmt) (interface{}, error) {
+	e.token = s.Name
+	nameConstant, err := e.identifierConstant(s.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.declareVariable(s.Name); err != nil {
+		return nil, err
+	}
+
+	e.emitOp(bytecode.OP_CLASS)
+	e.emitShort(nameConstant)
+	e.defineVariable(nameConstant)
+
+	// Bind the superclass to a local named "super" in a scope surrounding the methods,
+	// so that methods capture it like any other variable.
+	if s.Superclass != nil {
+		if _, err := s.Superclass.Accept(e); err != nil {
+			return nil, err
+		}
+
+		e.beginScope()
+		if err := e.declareVariable(syntheticToken(s.Superclass.Name, "super")); err != nil {
+			return nil, err
+		}
+		e.markInitialized()
+
+		if err := e.namedVariable(s.Name, nil); err != nil {
+			return nil, err
+		}
+		e.token = s.Superclass.Name
+		e.emitOp(bytecode.OP_INHERIT)
+	}
+
+	// Load the class back onto the stack so that methods can be attached to it.
+	if err := e.namedVariable(s.Name, nil); err != nil {
+		return nil, err
+	}
+
+	if s.Constructor != nil {
+		if err := e.method(s.Constructor, functionTypeInitializer, bytecode.OP_METHOD); err != nil {
+			return nil, err
+		}
+	}
+	for _, method := range s.Methods {
+		if err := e.method(method, functionTypeMethod, bytecode.OP_METHOD); err != nil {
+			return nil, err
+		}
+	}
+	for _, method := range s.StaticMethods {
+		if err := e.method(method, functionTypeMethod, bytecode.OP_STATIC_METHOD); err != nil {
+			return nil, err
+		}
+	}
+
+	e.emitOp(bytecode.OP_POP)
+
+	if s.Superclass != nil {
+		e.endScope()
+	}
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitFunctionStmt(s *ast.FunctionStmt) (interface{}, error) {
+	e.token = s.Name
+	nameConstant, err := e.identifierConstant(s.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.declareVariable(s.Name); err != nil {
+		return nil, err
+	}
+
+	// Mark the function's name as initialized right away so the body can refer to it recursively.
+	e.markInitialized()
+	if err := e.function(s, functionTypeFunction); err != nil {
+		return nil, err
+	}
+	e.defineVariable(nameConstant)
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitFunctionExpr(ex *ast.FunctionExpr) (interface{}, error) {
+	// An anonymous function compiles like a declaration, but leaves the closure on the stack.
+	declaration := &ast.FunctionStmt{
+		Name:   syntheticToken(ex.Keyword, "anonymous"),
+		Params: ex.Params,
+		Body:   ex.Body,
+	}
+	return nil, e.function(declaration, functionTypeFunction)
+}
+
+func (e *AstEmitter) VisitVarStmt(s *ast.VarStmt) (interface{}, error) {
+	e.token = s.Left
+	nameConstant, err := e.identifierConstant(s.Left)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.declareVariable(s.Left); err != nil {
+		return nil, err
+	}
+
+	if s.Right == nil {
+		e.emitOp(bytecode.OP_NIL)
+	} else if _, err := s.Right.Accept(e); err != nil {
+		return nil, err
+	}
+
+	e.token = s.Left
+	e.defineVariable(nameConstant)
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitImportStmt(s *ast.ImportStmt) (interface{}, error) {
+	e.token = s.Name
+	nameConstant, err := e.identifierConstant(s.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.declareVariable(s.Name); err != nil {
+		return nil, err
+	}
+
+	e.token = s.Path
+	pathConstant, err := e.makeConstant(s.Path.Literal.(string))
+	if err != nil {
+		return nil, err
+	}
+	e.emitOp(bytecode.OP_IMPORT)
+	e.emitShort(pathConstant)
+
+	e.token = s.Name
+	e.defineVariable(nameConstant)
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitAssignExpr(ex *ast.AssignExpr) (interface{}, error) {
+	return nil, e.namedVariable(ex.Left, ex.Right)
+}
+
+func (e *AstEmitter) VisitCallExpr(ex *ast.CallExpr) (interface{}, error) {
+	if _, err := ex.Callee.Accept(e); err != nil {
+		return nil, err
+	}
+
+	if len(ex.Args) >= maxByteOperand {
+		return nil, loxerr.AtToken(ex.OpenParen, loxerr.LimitExceeded, fmt.Sprintf("Can't have more than %d arguments.", maxByteOperand-1))
+	}
+	for _, arg := range ex.Args {
+		if _, err := arg.Accept(e); err != nil {
+			return nil, err
+		}
+	}
+
+	e.token = ex.OpenParen
+	e.emitOp(bytecode.OP_CALL)
+	e.emitByte(byte(len(ex.Args)))
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitBinaryExpr(ex *ast.BinaryExpr) (interface{}, error) {
+	if _, err := ex.Left.Accept(e); err != nil {
+		return nil, err
+	}
+	if _, err := ex.Right.Accept(e); err != nil {
+		return nil, err
+	}
+
+	e.token = ex.Operator
+	switch ex.Operator.Type {
+	case tokentype.MINUS:
+		e.emitOp(bytecode.OP_SUBTRACT)
+	case tokentype.PLUS:
+		e.emitOp(bytecode.OP_ADD)
+	case tokentype.SLASH:
+		e.emitOp(bytecode.OP_DIVIDE)
+	case tokentype.STAR:
+		e.emitOp(bytecode.OP_MULTIPLY)
+	case tokentype.BANG_EQUAL:
+		e.emitOp(bytecode.OP_NOT_EQUAL)
+	case tokentype.EQUAL_EQUAL:
+		e.emitOp(bytecode.OP_EQUAL)
+	case tokentype.GREATER:
+		e.emitOp(bytecode.OP_GREATER)
+	case tokentype.GREATER_EQUAL:
+		e.emitOp(bytecode.OP_GREATER_EQUAL)
+	case tokentype.LESS:
+		e.emitOp(bytecode.OP_LESS)
+	case tokentype.LESS_EQUAL:
+		e.emitOp(bytecode.OP_LESS_EQUAL)
+	default:
+		return nil, loxerr.Internal(fmt.Sprintf("Unknown binary operator '%s' reached emitter!", ex.Operator.Lexeme))
+	}
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitUnaryExpr(ex *ast.UnaryExpr) (interface{}, error) {
+	if _, err := ex.Right.Accept(e); err != nil {
+		return nil, err
+	}
+
+	e.token = ex.Operator
+	switch ex.Operator.Type {
+	case tokentype.BANG:
+		e.emitOp(bytecode.OP_NOT)
+	case tokentype.MINUS:
+		e.emitOp(bytecode.OP_NEGATE)
+	default:
+		return nil, loxerr.Internal(fmt.Sprintf("Unknown unary operator '%s' reached emitter!", ex.Operator.Lexeme))
+	}
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitGroupingExpr(ex *ast.GroupingExpr) (interface{}, error) {
+	return ex.Expression.Accept(e)
+}
+
+func (e *AstEmitter) VisitLiteralExpr(ex *ast.LiteralExpr) (interface{}, error) {
+	switch ex.Value {
+	case nil:
+		e.emitOp(bytecode.OP_NIL)
+	case true:
+		e.emitOp(bytecode.OP_TRUE)
+	case false:
+		e.emitOp(bytecode.OP_FALSE)
+	default:
+		return nil, e.emitConstant(ex.Value)
+	}
+	return nil, nil
 }
 
-func (p *AstEmitter) VisitAssignExpr(e *ast.AssignExpr) interface{} {
+func (e *AstEmitter) VisitVarExpr(ex *ast.VarExpr) (interface{}, error) {
+	return nil, e.namedVariable(ex.Name, nil)
+}
+
+func (e *AstEmitter) VisitGetPropertyExpr(ex *ast.GetPropertyExpr) (interface{}, error) {
+	if _, err := ex.ParentObject.Accept(e); err != nil {
+		return nil, err
+	}
+
+	e.token = ex.Name
+	nameConstant, err := e.identifierConstant(ex.Name)
+	if err != nil {
+		return nil, err
+	}
+	e.emitOp(bytecode.OP_GET_PROPERTY)
+	e.emitShort(nameConstant)
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitSetPropertyExpr(ex *ast.SetPropertyExpr) (interface{}, error) {
+	if _, err := ex.ParentObject.Accept(e); err != nil {
+		return nil, err
+	}
+	if _, err := ex.Value.Accept(e); err != nil {
+		return nil, err
+	}
+
+	e.token = ex.Name
+	nameConstant, err := e.identifierConstant(ex.Name)
+	if err != nil {
+		return nil, err
+	}
+	e.emitOp(bytecode.OP_SET_PROPERTY)
+	e.emitShort(nameConstant)
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitListExpr(ex *ast.ListExpr) (interface{}, error) {
+	if len(ex.Elements) > maxListElements {
+		return nil, loxerr.AtToken(ex.OpenBracket, loxerr.LimitExceeded, fmt.Sprintf("Can't have more than %d elements in a list literal.", maxListElements))
+	}
+	for _, element := range ex.Elements {
+		if _, err := element.Accept(e); err != nil {
+			return nil, err
+		}
+	}
+
+	e.token = ex.OpenBracket
+	e.emitOp(bytecode.OP_LIST)
+	e.emitShort(uint16(len(ex.Elements)))
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitMapExpr(ex *ast.MapExpr) (interface{}, error) {
+	if len(ex.Keys) > maxMapEntries {
+		return nil, loxerr.AtToken(ex.OpenBrace, loxerr.LimitExceeded, fmt.Sprintf("Can't have more than %d entries in a map literal.", maxMapEntries))
+	}
+	for i := range ex.Keys {
+		if _, err := ex.Keys[i].Accept(e); err != nil {
+			return nil, err
+		}
+		if _, err := ex.Values[i].Accept(e); err != nil {
+			return nil, err
+		}
+	}
+
+	e.token = ex.OpenBrace
+	e.emitOp(bytecode.OP_MAP)
+	e.emitShort(uint16(len(ex.Keys)))
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitIndexGetExpr(ex *ast.IndexGetExpr) (interface{}, error) {
+	if _, err := ex.Object.Accept(e); err != nil {
+		return nil, err
+	}
+	if _, err := ex.Index.Accept(e); err != nil {
+		return nil, err
+	}
+
+	e.token = ex.OpenBracket
+	e.emitOp(bytecode.OP_INDEX_GET)
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitIndexSetExpr(ex *ast.IndexSetExpr) (interface{}, error) {
+	if _, err := ex.Object.Accept(e); err != nil {
+		return nil, err
+	}
+	if _, err := ex.Index.Accept(e); err != nil {
+		return nil, err
+	}
+	if _, err := ex.Value.Accept(e); err != nil {
+		return nil, err
+	}
+
+	e.token = ex.OpenBracket
+	e.emitOp(bytecode.OP_INDEX_SET)
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitThisExpr(ex *ast.ThisExpr) (interface{}, error) {
+	return nil, e.namedVariable(ex.Keyword, nil)
+}
+
+func (e *AstEmitter) VisitSuperExpr(ex *ast.SuperExpr) (interface{}, error) {
+	if err := e.namedVariable(syntheticToken(ex.Keyword, "this"), nil); err != nil {
+		return nil, err
+	}
+	if err := e.namedVariable(ex.Keyword, nil); err != nil {
+		return nil, err
+	}
+
+	e.token = ex.Method
+	nameConstant, err := e.identifierConstant(ex.Method)
+	if err != nil {
+		return nil, err
+	}
+	e.emitOp(bytecode.OP_GET_SUPER)
+	e.emitShort(nameConstant)
+	return nil, nil
+}
+
+func (e *AstEmitter) VisitLogicalExpr(ex *ast.LogicalExpr) (interface{}, error) {
+	switch ex.Operator.Type {
+	case tokentype.AND:
+		return nil, e.logicalAnd(ex)
+	case tokentype.OR:
+		return nil, e.logicalOr(ex)
+	default:
+		return nil, loxerr.Internal(fmt.Sprintf("Unknown logical operator '%s' reached emitter!", ex.Operator.Lexeme))
+	}
+}
+
+// Emit "left and right" so that right is only evaluated if left is truthy.
+// The result is the deciding operand.
+func (e *AstEmitter) logicalAnd(ex *ast.LogicalExpr) error {
+	if _, err := ex.Left.Accept(e); err != nil {
+		return err
+	}
+
+	endJump := e.emitJump(bytecode.OP_JUMP_IF_FALSE)
+	e.emitOp(bytecode.OP_POP)
+	if _, err := ex.Right.Accept(e); err != nil {
+		return err
+	}
+	return e.patchJump(endJump)
+}
+
+// Emit "left or right" so that right is only evaluated if left is falsy.
+// The result is the deciding operand.
+func (e *AstEmitter) logicalOr(ex *ast.LogicalExpr) error {
+	if _, err := ex.Left.Accept(e); err != nil {
+		return err
+	}
+
+	elseJump := e.emitJump(bytecode.OP_JUMP_IF_FALSE)
+	endJump := e.emitJump(bytecode.OP_JUMP)
+	if err := e.patchJump(elseJump); err != nil {
+		return err
+	}
+	e.emitOp(bytecode.OP_POP)
+	if _, err := ex.Right.Accept(e); err != nil {
+		return err
+	}
+	return e.patchJump(endJump)
+}
+
+// Compile a function body into its own bytecode.Function, then emit the
+// instruction that creates a closure of it in the enclosing function.
+func (e *AstEmitter) function(s *ast.FunctionStmt, kind functionType) error {
+	compiled, err := e.functionBody(s, kind)
+	if err != nil {
+		return err
+	}
+
+	e.token = s.Name
+	functionConstant, err := e.makeConstant(compiled.function)
+	if err != nil {
+		return err
+	}
+	e.emitOp(bytecode.OP_CLOSURE)
+	e.emitShort(functionConstant)
+	for _, uv := range compiled.upvalues {
+		if uv.isLocal {
+			e.emitByte(1)
+		} else {
+			e.emitByte(0)
+		}
+		e.emitByte(uv.index)
+	}
 	return nil
 }
 
-func (ae *AstEmitter) VisitBinaryExpr(ex *ast.BinaryExpr) interface{} {
+// Compile the params and body of a function in a new functionScope.
+func (e *AstEmitter) functionBody(s *ast.FunctionStmt, kind functionType) (*functionScope, error) {
+	fs := newFunctionScope(e.current, s.Name.Lexeme, kind)
+	e.current = fs
+	defer func() {
+		e.current = fs.enclosing
+	}()
+
+	e.beginScope()
+	if len(s.Params) >= maxByteOperand {
+		return nil, loxerr.AtToken(s.Name, loxerr.LimitExceeded, fmt.Sprintf("Can't have more than %d parameters.", maxByteOperand-1))
+	}
+	fs.function.Arity = len(s.Params)
+	for _, param := range s.Params {
+		if err := e.declareVariable(param); err != nil {
+			return nil, err
+		}
+		e.markInitialized()
+	}
+
+	for _, stmt := range s.Body {
+		if _, err := stmt.Accept(e); err != nil {
+			return nil, err
+		}
+	}
+	e.emitReturn()
+	return fs, nil
+}
+
+// Compile a method and attach it to the class on top of the stack.
+func (e *AstEmitter) method(s *ast.FunctionStmt, kind functionType, op bytecode.OpCode) error {
+	nameConstant, err := e.identifierConstant(s.Name)
+	if err != nil {
+		return err
+	}
+	if err := e.function(s, kind); err != nil {
+		return err
+	}
+	e.emitOp(op)
+	e.emitShort(nameConstant)
 	return nil
 }
 
-func (ae *AstEmitter) VisitUnaryExpr(ex *ast.UnaryExpr) interface{} {
+// Emit a read of the named variable, or an assignment to it if value is non-nil.
+func (e *AstEmitter) namedVariable(name *token.Token, value ast.Expr) error {
+	var getOp, setOp bytecode.OpCode
+	var operand int
+
+	slot, err := e.resolveLocal(e.current, name)
+	if err != nil {
+		return err
+	}
 
+	if slot != -1 {
+		getOp, setOp, operand = bytecode.OP_GET_LOCAL, bytecode.OP_SET_LOCAL, slot
+	} else if index, err := e.resolveUpvalue(e.current, name); err != nil {
+		return err
+	} else if index != -1 {
+		getOp, setOp, operand = bytecode.OP_GET_UPVALUE, bytecode.OP_SET_UPVALUE, index
+	} else {
+		nameConstant, err := e.identifierConstant(name)
+		if err != nil {
+			return err
+		}
+		getOp, setOp, operand = bytecode.OP_GET_GLOBAL, bytecode.OP_SET_GLOBAL, int(nameConstant)
+	}
+
+	op := getOp
+	if value != nil {
+		if _, err := value.Accept(e); err != nil {
+			return err
+		}
+		op = setOp
+	}
+
+	e.token = name
+	e.emitOp(op)
+	if op == bytecode.OP_GET_GLOBAL || op == bytecode.OP_SET_GLOBAL {
+		e.emitShort(uint16(operand))
+	} else {
+		e.emitByte(byte(operand))
+	}
 	return nil
 }
 
-func (ae *AstEmitter) VisitGroupingExpr(ex *ast.GroupingExpr) interface{} {
+// Find the stack slot of the named local in fs, or -1 if it is not a local of fs.
+func (e *AstEmitter) resolveLocal(fs *functionScope, name *token.Token) (int, error) {
+	for i := len(fs.locals) - 1; i >= 0; i-- {
+		if fs.locals[i].name == name.Lexeme && !fs.locals[i].hidden {
+			if fs.locals[i].depth == -1 {
+				return -1, loxerr.AtToken(name, loxerr.LocalInOwnInitializer, "Can't read local variable in its own initializer.")
+			}
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// Find the upvalue index of the named variable in fs, adding upvalues through
+// every enclosing function as needed, or -1 if it is not found in any enclosing function.
+func (e *AstEmitter) resolveUpvalue(fs *functionScope, name *token.Token) (int, error) {
+	if fs.enclosing == nil {
+		return -1, nil
+	}
+
+	slot, err := e.resolveLocal(fs.enclosing, name)
+	if err != nil {
+		return -1, err
+	}
+	if slot != -1 {
+		fs.enclosing.locals[slot].isCaptured = true
+		return e.addUpvalue(fs, name, byte(slot), true)
+	}
+
+	index, err := e.resolveUpvalue(fs.enclosing, name)
+	if err != nil || index == -1 {
+		return index, err
+	}
+	return e.addUpvalue(fs, name, byte(index), false)
+}
+
+func (e *AstEmitter) addUpvalue(fs *functionScope, name *token.Token, index byte, isLocal bool) (int, error) {
+	for i, uv := range fs.upvalues {
+		if uv.index == index && uv.isLocal == isLocal {
+			return i, nil
+		}
+	}
+
+	if len(fs.upvalues) >= maxByteOperand {
+		return -1, loxerr.AtToken(name, loxerr.LimitExceeded, "Too many closure variables in function.")
+	}
+	fs.upvalues = append(fs.upvalues, upvalue{index: index, isLocal: isLocal})
+	fs.function.UpvalueCount = len(fs.upvalues)
+	return len(fs.upvalues) - 1, nil
+}
+
+// Record a local variable in the current scope. Globals are late-bound, so nothing is recorded for them.
+func (e *AstEmitter) declareVariable(name *token.Token) error {
+	if e.current.scopeDepth == 0 {
+		return nil
+	}
+
+	for i := len(e.current.locals) - 1; i >= 0; i-- {
+		l := e.current.locals[i]
+		if l.depth != -1 && l.depth < e.current.scopeDepth {
+			break
+		}
+		if l.name == name.Lexeme {
+			return loxerr.AtToken(name, loxerr.DuplicateLocal, "Already a variable with this name in this scope.")
+		}
+	}
+
+	if len(e.current.locals) >= maxByteOperand {
+		return loxerr.AtToken(name, loxerr.LimitExceeded, "Too many local variables in function.")
+	}
+	e.current.locals = append(e.current.locals, local{name: name.Lexeme, depth: -1})
+	return nil
+}
+
+// Make the most recently declared variable available for use. The value on top
+// of the stack becomes the local's slot, or is stored as a global.
+func (e *AstEmitter) defineVariable(nameConstant uint16) {
+	if e.current.scopeDepth > 0 {
+		e.markInitialized()
+		return
+	}
+	e.emitOp(bytecode.OP_DEFINE_GLOBAL)
+	e.emitShort(nameConstant)
+}
+
+func (e *AstEmitter) markInitialized() {
+	if e.current.scopeDepth == 0 {
+		return
+	}
+	e.current.locals[len(e.current.locals)-1].depth = e.current.scopeDepth
+}
+
+func (e *AstEmitter) beginScope() {
+	e.current.scopeDepth++
+}
+
+// Pop every local of the scope being exited, moving captured ones to the heap.
+func (e *AstEmitter) endScope() {
+	fs := e.current
+	fs.scopeDepth--
+	for len(fs.locals) > 0 && fs.locals[len(fs.locals)-1].depth > fs.scopeDepth {
+		if fs.locals[len(fs.locals)-1].isCaptured {
+			e.emitOp(bytecode.OP_CLOSE_UPVALUE)
+		} else {
+			e.emitOp(bytecode.OP_POP)
+		}
+		fs.locals = fs.locals[:len(fs.locals)-1]
+	}
+}
+
+// Compile the body of a try or catch block of a try statement that is in the scope depth.
+func (e *AstEmitter) tryBlock(body *ast.BlockStmt, scopeDepth int, hasHandler bool, finally *ast.BlockStmt) error {
+	fs := e.current
+	fs.tries = &tryBlock{
+		enclosing:  fs.tries,
+		scopeDepth: scopeDepth,
+		loop:       fs.loop,
+		hasHandler: hasHandler,
+		finally:    finally,
+	}
+	_, err := body.Accept(e)
+	fs.tries = fs.tries.enclosing
+	return err
+}
+
+// Compile the finally block, if there is one, at the end of the try statement.
+func (e *AstEmitter) finallyBlock(finally *ast.BlockStmt) error {
+	if finally == nil {
+		return nil
+	}
+	_, err := finally.Accept(e)
+	return err
+}
+
+// Compile an exception handler that runs the finally block and then rethrows the exception,
+// which is on top of the given number of values that the stack holds above the locals.
+func (e *AstEmitter) rethrowAfterFinally(values int, finally *ast.BlockStmt) error {
+	fs := e.current
+	locals := len(fs.locals)
+	e.beginScope()
+	for i := 0; i < values; i++ {
+		if err := e.addSyntheticLocal(); err != nil {
+			return err
+		}
+	}
+
+	if err := e.finallyBlock(finally); err != nil {
+		return err
+	}
+	e.emitOp(bytecode.OP_GET_LOCAL)
+	e.emitByte(byte(len(fs.locals) - 1))
+	e.emitOp(bytecode.OP_THROW)
+
+	// Nothing after the throw runs, so the values don't need to be popped.
+	fs.locals = fs.locals[:locals]
+	fs.scopeDepth--
+	return nil
+}
+
+// Remove the exception handlers and run the finally blocks of every try statement being jumped
+// out of, from the innermost try statement out to, but not including, the until try statement.
+func (e *AstEmitter) exitTries(until *tryBlock) error {
+	fs := e.current
+	for t := fs.tries; t != until; t = t.enclosing {
+		if t.hasHandler {
+			e.emitOp(bytecode.OP_END_TRY)
+		}
+		if t.finally == nil {
+			continue
+		}
+
+		// The finally block can't see the locals declared inside of the try statement.
+		hidden := make([]int, 0)
+		for i := len(fs.locals) - 1; i >= 0 && fs.locals[i].depth > t.scopeDepth; i-- {
+			if !fs.locals[i].hidden {
+				fs.locals[i].hidden = true
+				hidden = append(hidden, i)
+			}
+		}
+
+		tries, l := fs.tries, fs.loop
+		fs.tries, fs.loop = t.enclosing, t.loop
+		err := e.finallyBlock(t.finally)
+		fs.tries, fs.loop = tries, l
+
+		for _, i := range hidden {
+			fs.locals[i].hidden = false
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add a local that no variable refers to, for a value that is on top of the stack.
+func (e *AstEmitter) addSyntheticLocal() error {
+	if len(e.current.locals) >= maxByteOperand {
+		return e.errorAtCurrent("Too many local variables in function.")
+	}
+	e.current.locals = append(e.current.locals, local{name: "", depth: e.current.scopeDepth})
+	return nil
+}
+
+// Pop every local deeper than depth without ending their scopes, for jumping out of those scopes.
+func (e *AstEmitter) discardLocals(depth int) {
+	fs := e.current
+	for i := len(fs.locals) - 1; i >= 0 && fs.locals[i].depth > depth; i-- {
+		if fs.locals[i].isCaptured {
+			e.emitOp(bytecode.OP_CLOSE_UPVALUE)
+		} else {
+			e.emitOp(bytecode.OP_POP)
+		}
+	}
+}
+
+func (e *AstEmitter) identifierConstant(name *token.Token) (uint16, error) {
+	return e.makeConstant(name.Lexeme)
+}
+
+func (e *AstEmitter) makeConstant(value interface{}) (uint16, error) {
+	index := e.chunk().AddConstant(value)
+	if index >= bytecode.MaxConstants {
+		return 0, e.errorAtCurrent("Too many constants in one chunk.")
+	}
+	return uint16(index), nil
+}
+
+func (e *AstEmitter) emitConstant(value interface{}) error {
+	index, err := e.makeConstant(value)
+	if err != nil {
+		return err
+	}
+	e.emitOp(bytecode.OP_CONSTANT)
+	e.emitShort(index)
 	return nil
 }
 
-func (ae *AstEmitter) VisitLiteralExpr(ex *ast.LiteralExpr) interface{} {
+// Emit the implicit return at the end of a function body.
+// Initializers always return the instance being initialized.
+func (e *AstEmitter) emitReturn() {
+	e.emitReturnValue()
+	e.emitOp(bytecode.OP_RETURN)
+}
+
+// Emit the value returned by a return statement without a value.
+func (e *AstEmitter) emitReturnValue() {
+	if e.current.kind == functionTypeInitializer {
+		e.emitOp(bytecode.OP_GET_LOCAL)
+		e.emitByte(0)
+	} else {
+		e.emitOp(bytecode.OP_NIL)
+	}
+}
+
+// Emit a forward jump with a placeholder offset, returning the position of the offset to patch later.
+func (e *AstEmitter) emitJump(op bytecode.OpCode) int {
+	e.emitOp(op)
+	e.emitShort(0xffff)
+	return len(e.chunk().Code) - 2
+}
+
+// Point the forward jump whose offset is at position to the current end of the chunk.
+func (e *AstEmitter) patchJump(position int) error {
+	jump := len(e.chunk().Code) - position - 2
+	if jump > maxJump {
+		return e.errorAtCurrent("Too much code to jump over.")
+	}
+	e.chunk().Code[position] = byte(jump >> 8)
+	e.chunk().Code[position+1] = byte(jump)
 	return nil
 }
 
-func (ae *AstEmitter) VisitVarExpr(ex *ast.VarExpr) interface{} {
+// Emit a backward jump to loopStart.
+func (e *AstEmitter) emitLoop(loopStart int) error {
+	e.emitOp(bytecode.OP_LOOP)
+	jump := len(e.chunk().Code) - loopStart + 2
+	if jump > maxJump {
+		return e.errorAtCurrent("Loop body too large.")
+	}
+	e.emitShort(uint16(jump))
 	return nil
 }
+
+// Create a compiler limit error located at the most recently visited token.
+func (e *AstEmitter) errorAtCurrent(message string) error {
+	if e.token == nil {
+		return loxerr.AtLine(0, loxerr.LimitExceeded, message)
+	}
+	return loxerr.AtToken(e.token, loxerr.LimitExceeded, message)
+}
+
+func (e *AstEmitter) emitOp(op bytecode.OpCode) {
+	e.chunk().WriteOp(op, e.token)
+}
+
+func (e *AstEmitter) emitByte(b byte) {
+	e.chunk().Write(b, e.token)
+}
+
+func (e *AstEmitter) emitShort(value uint16) {
+	e.chunk().WriteShort(value, e.token)
+}
+
+func (e *AstEmitter) chunk() *bytecode.Chunk {
+	return e.current.function.Chunk
+}
+
+// Create an identifier token that doesn't appear in the source, located at t.
+func syntheticToken(t *token.Token, lexeme string) *token.Token {
+	return &token.Token{
+		Type:    tokentype.IDENTIFIER,
+		Lexeme:  lexeme,
+		Literal: nil,
+		Line:    t.Line,
+		Column:  t.Column,
+		Offset:  t.Offset,
+		Length:  0,
+	}
+}
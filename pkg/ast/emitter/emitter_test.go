@@ -1 +1,249 @@
 package emitter
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/kaschnit/golox/pkg/ast/astutil"
+	"github.com/kaschnit/golox/pkg/bytecode"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func compileLine(t *testing.T, line string) (*bytecode.Function, error) {
+	programAst, err := astutil.ParseLine(line)
+	assert.Nil(t, err)
+	return Compile(programAst)
+}
+
+// Decode the opcodes of a chunk, skipping over their operands.
+func opcodes(c *bytecode.Chunk) []bytecode.OpCode {
+	result := make([]bytecode.OpCode, 0)
+	for offset := 0; offset < len(c.Code); {
+		result = append(result, bytecode.OpCode(c.Code[offset]))
+		switch bytecode.OpCode(c.Code[offset]) {
+		case bytecode.OP_GET_LOCAL, bytecode.OP_SET_LOCAL, bytecode.OP_GET_UPVALUE,
+			bytecode.OP_SET_UPVALUE, bytecode.OP_CALL:
+			offset += 2
+		case bytecode.OP_NIL, bytecode.OP_TRUE, bytecode.OP_FALSE, bytecode.OP_POP,
+			bytecode.OP_EQUAL, bytecode.OP_NOT_EQUAL, bytecode.OP_GREATER, bytecode.OP_GREATER_EQUAL,
+			bytecode.OP_LESS, bytecode.OP_LESS_EQUAL, bytecode.OP_ADD, bytecode.OP_SUBTRACT,
+			bytecode.OP_MULTIPLY, bytecode.OP_DIVIDE, bytecode.OP_NOT, bytecode.OP_NEGATE,
+			bytecode.OP_PRINT, bytecode.OP_CLOSE_UPVALUE, bytecode.OP_RETURN, bytecode.OP_INHERIT,
+			bytecode.OP_INDEX_GET, bytecode.OP_INDEX_SET, bytecode.OP_END_TRY, bytecode.OP_CATCH, bytecode.OP_THROW:
+			offset += 1
+		case bytecode.OP_CLOSURE:
+			function := c.Constants[c.ReadShort(offset+1)].(*bytecode.Function)
+			offset += 3 + 2*function.UpvalueCount
+		default:
+			offset += 3
+		}
+	}
+	return result
+}
+
+func TestAstEmitter_PrintArithmetic(t *testing.T) {
+	script, err := compileLine(t, "print 1 + 2 * 3;")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_CONSTANT, bytecode.OP_CONSTANT, bytecode.OP_CONSTANT,
+		bytecode.OP_MULTIPLY, bytecode.OP_ADD, bytecode.OP_PRINT,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+	assert.Equal(t, []interface{}{1.0, 2.0, 3.0}, script.Chunk.Constants)
+}
+
+func TestAstEmitter_GlobalVariables(t *testing.T) {
+	script, err := compileLine(t, "var x = 1; x = x;")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_CONSTANT, bytecode.OP_DEFINE_GLOBAL,
+		bytecode.OP_GET_GLOBAL, bytecode.OP_SET_GLOBAL, bytecode.OP_POP,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+}
+
+func TestAstEmitter_LocalVariables(t *testing.T) {
+	script, err := compileLine(t, "{ var x = 1; print x; }")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_CONSTANT, bytecode.OP_GET_LOCAL, bytecode.OP_PRINT, bytecode.OP_POP,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+}
+
+func TestAstEmitter_CapturedLocalIsClosed(t *testing.T) {
+	script, err := compileLine(t, "{ var x = 1; fun f() { return x; } }")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_CONSTANT, bytecode.OP_CLOSURE, bytecode.OP_POP, bytecode.OP_CLOSE_UPVALUE,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+
+	function := script.Chunk.Constants[len(script.Chunk.Constants)-1].(*bytecode.Function)
+	assert.Equal(t, "f", function.Name)
+	assert.Equal(t, 1, function.UpvalueCount)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_GET_UPVALUE, bytecode.OP_RETURN, bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(function.Chunk))
+}
+
+func TestAstEmitter_WhileLoop(t *testing.T) {
+	script, err := compileLine(t, "while (true) print 1;")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_TRUE, bytecode.OP_JUMP_IF_FALSE, bytecode.OP_POP,
+		bytecode.OP_CONSTANT, bytecode.OP_PRINT, bytecode.OP_LOOP, bytecode.OP_POP,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+}
+
+func TestAstEmitter_BreakPopsLoopLocals(t *testing.T) {
+	script, err := compileLine(t, "while (true) { var x = 1; break; }")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_TRUE, bytecode.OP_JUMP_IF_FALSE, bytecode.OP_POP,
+		bytecode.OP_CONSTANT, bytecode.OP_POP, bytecode.OP_JUMP, bytecode.OP_POP,
+		bytecode.OP_LOOP, bytecode.OP_POP,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+
+	// The break jumps past the pop of the loop condition.
+	assert.Equal(t, len(script.Chunk.Code)-2, 12+int(script.Chunk.ReadShort(10)))
+}
+
+func TestAstEmitter_ContinueRunsIncrement(t *testing.T) {
+	script, err := compileLine(t, "for (var i = 0; i < 3; i = i + 1) continue;")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_CONSTANT,
+		bytecode.OP_GET_LOCAL, bytecode.OP_CONSTANT, bytecode.OP_LESS, bytecode.OP_JUMP_IF_FALSE, bytecode.OP_POP,
+		bytecode.OP_JUMP,
+		bytecode.OP_GET_LOCAL, bytecode.OP_CONSTANT, bytecode.OP_ADD, bytecode.OP_SET_LOCAL, bytecode.OP_POP,
+		bytecode.OP_LOOP, bytecode.OP_POP, bytecode.OP_POP,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+}
+
+func TestAstEmitter_BreakOutsideOfLoop(t *testing.T) {
+	_, err := compileLine(t, "break;")
+	assert.Error(t, err)
+	assert.ErrorContains(t, err, "Can't use 'break' outside of a loop.")
+}
+
+func TestAstEmitter_TryCatch(t *testing.T) {
+	script, err := compileLine(t, "try { throw 1; } catch (e) { print e; }")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_TRY, bytecode.OP_CONSTANT, bytecode.OP_THROW, bytecode.OP_END_TRY, bytecode.OP_JUMP,
+		bytecode.OP_CATCH, bytecode.OP_GET_LOCAL, bytecode.OP_PRINT, bytecode.OP_POP,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+}
+
+func TestAstEmitter_TryFinallyRunsBeforeReturn(t *testing.T) {
+	script, err := compileLine(t, "fun f() { try { return 1; } finally { print 2; } }")
+	assert.Nil(t, err)
+
+	function := script.Chunk.Constants[len(script.Chunk.Constants)-1].(*bytecode.Function)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_TRY,
+		bytecode.OP_CONSTANT, bytecode.OP_END_TRY, bytecode.OP_CONSTANT, bytecode.OP_PRINT, bytecode.OP_RETURN,
+		bytecode.OP_END_TRY, bytecode.OP_CONSTANT, bytecode.OP_PRINT, bytecode.OP_JUMP,
+		bytecode.OP_CONSTANT, bytecode.OP_PRINT, bytecode.OP_GET_LOCAL, bytecode.OP_THROW,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(function.Chunk))
+}
+
+func TestAstEmitter_Import(t *testing.T) {
+	script, err := compileLine(t, "import \"util.lox\" as util; { import \"util.lox\" as local; }")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_IMPORT, bytecode.OP_DEFINE_GLOBAL,
+		bytecode.OP_IMPORT, bytecode.OP_POP,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+	assert.Equal(t, "util.lox", script.Chunk.Constants[script.Chunk.ReadShort(1)])
+}
+
+func TestAstEmitter_ReturnFromTopLevel(t *testing.T) {
+	_, err := compileLine(t, "return 1;")
+	assert.Error(t, err)
+	assert.ErrorContains(t, err, "Can't return from top-level code.")
+	assert.Equal(t, loxerr.StageAnalyze, err.(*multierror.Error).Errors[0].(loxerr.Staged).Stage())
+	assert.Equal(t, loxerr.ReturnFromTopLevel, err.(*multierror.Error).Errors[0].(loxerr.Coded).Code())
+}
+
+func TestAstEmitter_DuplicateLocal(t *testing.T) {
+	_, err := compileLine(t, "{ var a = 1; var a = 2; }")
+	assert.Error(t, err)
+	assert.ErrorContains(t, err, "Already a variable with this name in this scope.")
+}
+
+func TestAstEmitter_ErrorReportsLine(t *testing.T) {
+	_, err := compileLine(t, "fun f() {}\nreturn;")
+	assert.Error(t, err)
+	assert.ErrorContains(t, err, "[line 2] Error at 'return'")
+}
+
+func TestAstEmitter_LogicalAndShortCircuits(t *testing.T) {
+	script, err := compileLine(t, "print false and 1;")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_FALSE, bytecode.OP_JUMP_IF_FALSE, bytecode.OP_POP, bytecode.OP_CONSTANT,
+		bytecode.OP_PRINT, bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+}
+
+func TestAstEmitter_LogicalOrShortCircuits(t *testing.T) {
+	script, err := compileLine(t, "print nil or 1;")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_NIL, bytecode.OP_JUMP_IF_FALSE, bytecode.OP_JUMP, bytecode.OP_POP, bytecode.OP_CONSTANT,
+		bytecode.OP_PRINT, bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+}
+
+func TestAstEmitter_ListIndexing(t *testing.T) {
+	script, err := compileLine(t, "var xs = [1, 2]; xs[0] = xs[1];")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_CONSTANT, bytecode.OP_CONSTANT, bytecode.OP_LIST, bytecode.OP_DEFINE_GLOBAL,
+		bytecode.OP_GET_GLOBAL, bytecode.OP_CONSTANT,
+		bytecode.OP_GET_GLOBAL, bytecode.OP_CONSTANT, bytecode.OP_INDEX_GET,
+		bytecode.OP_INDEX_SET, bytecode.OP_POP,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+}
+
+func TestAstEmitter_MapLiteral(t *testing.T) {
+	script, err := compileLine(t, "var m = {\"a\": 1}; print m[\"a\"];")
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_CONSTANT, bytecode.OP_CONSTANT, bytecode.OP_MAP, bytecode.OP_DEFINE_GLOBAL,
+		bytecode.OP_GET_GLOBAL, bytecode.OP_CONSTANT, bytecode.OP_INDEX_GET, bytecode.OP_PRINT,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+}
+
+func TestCompileWithResult_LastExpression(t *testing.T) {
+	programAst, err := astutil.ParseLine("var x = 1; x + 2;")
+	assert.Nil(t, err)
+	script, err := CompileWithResult(programAst)
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_CONSTANT, bytecode.OP_DEFINE_GLOBAL,
+		bytecode.OP_GET_GLOBAL, bytecode.OP_CONSTANT, bytecode.OP_ADD, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+}
+
+func TestCompileWithResult_LastStatementNotExpression(t *testing.T) {
+	programAst, err := astutil.ParseLine("1; print 2;")
+	assert.Nil(t, err)
+	script, err := CompileWithResult(programAst)
+	assert.Nil(t, err)
+	assert.Equal(t, []bytecode.OpCode{
+		bytecode.OP_CONSTANT, bytecode.OP_POP, bytecode.OP_CONSTANT, bytecode.OP_PRINT,
+		bytecode.OP_NIL, bytecode.OP_RETURN,
+	}, opcodes(script.Chunk))
+}
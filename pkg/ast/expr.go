@@ -41,6 +41,18 @@ func (e *BinaryExpr) Accept(v AstVisitor) (interface{}, error) {
 	return v.VisitBinaryExpr(e)
 }
 
+// Represents a logical "and" or "or" expression AST node.
+// Unlike a binary expression, the right operand is only evaluated if the left one doesn't decide the result.
+type LogicalExpr struct {
+	Left     Expr
+	Operator *token.Token
+	Right    Expr
+}
+
+func (e *LogicalExpr) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitLogicalExpr(e)
+}
+
 // Represents a unary expression AST node.
 type UnaryExpr struct {
 	Operator *token.Token
@@ -97,6 +109,63 @@ func (e *SetPropertyExpr) Accept(v AstVisitor) (interface{}, error) {
 	return v.VisitSetPropertyExpr(e)
 }
 
+// Represents a list literal AST node, e.g. "[1, 2, 3]".
+type ListExpr struct {
+	OpenBracket *token.Token
+	Elements    []Expr
+}
+
+func (e *ListExpr) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitListExpr(e)
+}
+
+// Represents a map literal AST node, e.g. "{"a": 1, "b": 2}".
+// The key and value of each entry are at the same position of Keys and Values.
+type MapExpr struct {
+	OpenBrace *token.Token
+	Keys      []Expr
+	Values    []Expr
+}
+
+func (e *MapExpr) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitMapExpr(e)
+}
+
+// Represents an anonymous function AST node, e.g. "fun (a, b) { ... }" or "(a, b) => a + b".
+// The keyword is the "fun" or "=>" token. The body of an arrow function is a single return statement.
+type FunctionExpr struct {
+	Keyword *token.Token
+	Params  []*token.Token
+	Body    []Stmt
+}
+
+func (e *FunctionExpr) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitFunctionExpr(e)
+}
+
+// Represents reading an element of a list or map AST node, e.g. "xs[i]".
+type IndexGetExpr struct {
+	Object      Expr
+	OpenBracket *token.Token
+	Index       Expr
+}
+
+func (e *IndexGetExpr) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitIndexGetExpr(e)
+}
+
+// Represents writing an element of a list or map AST node, e.g. "xs[i] = v".
+type IndexSetExpr struct {
+	Object      Expr
+	OpenBracket *token.Token
+	Index       Expr
+	Value       Expr
+}
+
+func (e *IndexSetExpr) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitIndexSetExpr(e)
+}
+
 type ThisExpr struct {
 	Keyword *token.Token
 }
@@ -104,3 +173,12 @@ type ThisExpr struct {
 func (e *ThisExpr) Accept(v AstVisitor) (interface{}, error) {
 	return v.VisitThisExpr(e)
 }
+
+type SuperExpr struct {
+	Keyword *token.Token
+	Method  *token.Token
+}
+
+func (e *SuperExpr) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitSuperExpr(e)
+}
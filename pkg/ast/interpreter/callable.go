@@ -5,6 +5,7 @@ import (
 
 	"github.com/kaschnit/golox/pkg/ast"
 	"github.com/kaschnit/golox/pkg/ast/interpreter/environment"
+	"github.com/kaschnit/golox/pkg/native"
 )
 
 type Callable interface {
@@ -16,12 +17,19 @@ type Callable interface {
 type LoxFunction struct {
 	declaration *ast.FunctionStmt
 	closure     *environment.Environment
+
+	// The interpreter that runs the function's body, which is the one of the module that declared it.
+	interpreter *AstInterpreter
+
+	// Whether the function is a class constructor, which always returns the instance it is bound to.
+	isInitializer bool
 }
 
-func NewLoxFunction(declaration *ast.FunctionStmt, closure *environment.Environment) *LoxFunction {
+func NewLoxFunction(declaration *ast.FunctionStmt, closure *environment.Environment, interpreter *AstInterpreter) *LoxFunction {
 	return &LoxFunction{
 		declaration: declaration,
 		closure:     closure,
+		interpreter: interpreter,
 	}
 }
 
@@ -29,72 +37,147 @@ func (f *LoxFunction) Arity() int {
 	return len(f.declaration.Params)
 }
 
-func (f *LoxFunction) Call(interpreter *AstInterpreter, args []interface{}) (interface{}, error) {
+func (f *LoxFunction) Call(_ *AstInterpreter, args []interface{}) (interface{}, error) {
 	paramActuals := make(map[string]interface{})
 	for i, param := range f.declaration.Params {
 		paramActuals[param.Lexeme] = args[i]
 	}
 
 	env := f.closure.WithValues(paramActuals)
-	err := interpreter.ExecuteBlock(f.declaration.Body, env)
+	err := f.interpreter.ExecuteBlock(f.declaration.Body, env)
 
 	// Return is propagated by child nodes up until this node
 	// to end execution of the function.
-	if returnWrapper, ok := err.(*Return); ok {
-		return returnWrapper.Value, nil
+	returnWrapper, isReturn := err.(*Return)
+	if err != nil && !isReturn {
+		return nil, err
 	}
 
-	return nil, err
+	if f.isInitializer {
+		this, _ := f.closure.GetAt(0, "this")
+		return this, nil
+	}
+	if isReturn {
+		return returnWrapper.Value, nil
+	}
+	return nil, nil
 }
 
 func (f *LoxFunction) Bind(instance *LoxClassInstance) *LoxFunction {
 	closure := f.closure.WithValue("this", instance)
-	return NewLoxFunction(f.declaration, closure)
+	return &LoxFunction{
+		declaration:   f.declaration,
+		closure:       closure,
+		interpreter:   f.interpreter,
+		isInitializer: f.isInitializer,
+	}
 }
 
 func (f *LoxFunction) String() string {
+	if f.declaration.Name == nil {
+		return fmt.Sprintf("<function anonymous [%p]>", f)
+	}
 	return fmt.Sprintf("<function %s [%p]>", f.declaration.Name.Lexeme, f)
 }
 
+func (f *LoxFunction) TypeName() string {
+	return "function"
+}
+
 // Runtime representation of user-defined class
 type LoxClass struct {
 	declaration       *ast.ClassStmt
 	closure           *environment.Environment
+	superclass        *LoxClass
 	methods           map[string]*LoxFunction
 	metaclassInstance *LoxClassInstance
+
+	// The class whose static methods a metaclass holds, or nil if the class isn't a metaclass.
+	ownerClass *LoxClass
+
+	// The interpreter that runs the class's methods, which is the one of the module that declared it.
+	interpreter *AstInterpreter
 }
 
-func NewLoxClass(declaration *ast.ClassStmt, closure *environment.Environment) *LoxClass {
+// Create a LoxClass. The superclass is nil if the class does not inherit from another class.
+// Methods close over an environment where "super" refers to the superclass, and static methods
+// close over one where "super" refers to the superclass's metaclass.
+func NewLoxClass(declaration *ast.ClassStmt, closure *environment.Environment, superclass *LoxClass, interpreter *AstInterpreter) *LoxClass {
+	methodsClosure := closure
+	staticMethodsClosure := closure
+	var metaSuperclass *LoxClass
+	if superclass != nil {
+		metaSuperclass = superclass.metaclassInstance.Class
+		methodsClosure = closure.WithValue("super", superclass)
+		staticMethodsClosure = closure.WithValue("super", metaSuperclass)
+	}
+
 	metaclass := &LoxClass{
 		declaration:       declaration,
-		closure:           closure,
-		methods:           getFunctionsMap(declaration.StaticMethods, closure),
+		closure:           staticMethodsClosure,
+		superclass:        metaSuperclass,
+		methods:           getFunctionsMap(declaration.StaticMethods, staticMethodsClosure, interpreter),
 		metaclassInstance: nil,
+		interpreter:       interpreter,
 	}
 	metaclassInstance := NewLoxClassInstance(metaclass)
 
-	return &LoxClass{
+	cls := &LoxClass{
 		declaration:       declaration,
-		closure:           closure,
-		methods:           getFunctionsMap(declaration.Methods, closure),
+		closure:           methodsClosure,
+		superclass:        superclass,
+		methods:           getFunctionsMap(declaration.Methods, methodsClosure, interpreter),
 		metaclassInstance: metaclassInstance,
+		interpreter:       interpreter,
+	}
+	metaclass.ownerClass = cls
+	return cls
+}
+
+// Find the method with the given name on the class or the nearest superclass that defines it.
+func (c *LoxClass) FindMethod(name string) (*LoxFunction, bool) {
+	for cls := c; cls != nil; cls = cls.superclass {
+		if method, ok := cls.methods[name]; ok {
+			return method, true
+		}
+	}
+	return nil, false
+}
+
+// Find the constructor of the class or the nearest superclass that defines one.
+// Metaclasses have no constructor, since their only instance is the class itself.
+func (c *LoxClass) FindConstructor() (*LoxFunction, bool) {
+	if c.ownerClass != nil {
+		return nil, false
+	}
+	for cls := c; cls != nil; cls = cls.superclass {
+		if cls.declaration.Constructor != nil {
+			return &LoxFunction{
+				declaration:   cls.declaration.Constructor,
+				closure:       cls.closure,
+				interpreter:   cls.interpreter,
+				isInitializer: true,
+			}, true
+		}
 	}
+	return nil, false
 }
 
 func (c *LoxClass) Arity() int {
-	if c.declaration.Constructor == nil {
-		return 0
+	if constructor, ok := c.FindConstructor(); ok {
+		return constructor.Arity()
 	}
-	return len(c.declaration.Constructor.Params)
+	return 0
 }
 
 func (c *LoxClass) Call(interpreter *AstInterpreter, args []interface{}) (interface{}, error) {
 	instance := NewLoxClassInstance(c)
 
-	// Call the constructor if it's been defined
-	if c.declaration.Constructor != nil {
-		constructor := NewLoxFunction(c.declaration.Constructor, c.closure)
-		constructor.Bind(instance).Call(interpreter, args)
+	// Call the constructor if it's been defined. A constructor that fails leaves no instance behind.
+	if constructor, ok := c.FindConstructor(); ok {
+		if _, err := constructor.Bind(instance).Call(interpreter, args); err != nil {
+			return nil, err
+		}
 	}
 
 	return instance, nil
@@ -104,11 +187,18 @@ func (c *LoxClass) String() string {
 	return fmt.Sprintf("<class %s [%p]>", c.declaration.Name.Lexeme, c)
 }
 
+func (c *LoxClass) TypeName() string {
+	return "class"
+}
+
 // Runtime representation of interpreter-defined ("native") function.
 type NativeFunction struct {
 	name  string
 	arity int
 	code  func(interpreter *AstInterpreter, args []interface{}) (interface{}, error)
+
+	// Whether the function takes arity or more args, which it checks itself.
+	variadic bool
 }
 
 func NewNativeFunction(name string, arity int, code func(interpreter *AstInterpreter, args []interface{}) (interface{}, error)) *NativeFunction {
@@ -119,6 +209,18 @@ func NewNativeFunction(name string, arity int, code func(interpreter *AstInterpr
 	}
 }
 
+// Create a NativeFunction that checks its args against the parameters of the registered native before calling it.
+func NewRegisteredNativeFunction(fn *native.Function) *NativeFunction {
+	return &NativeFunction{
+		name:  fn.Name,
+		arity: fn.Arity(),
+		code: func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			return fn.Call(args)
+		},
+		variadic: fn.Variadic,
+	}
+}
+
 func (f *NativeFunction) Arity() int {
 	return f.arity
 }
@@ -131,10 +233,27 @@ func (f *NativeFunction) String() string {
 	return fmt.Sprintf("<native function %s [%p]>", f.name, f)
 }
 
-func getFunctionsMap(declarations []*ast.FunctionStmt, closure *environment.Environment) map[string]*LoxFunction {
+func (f *NativeFunction) TypeName() string {
+	return "function"
+}
+
+func getFunctionsMap(declarations []*ast.FunctionStmt, closure *environment.Environment, interpreter *AstInterpreter) map[string]*LoxFunction {
 	functions := make(map[string]*LoxFunction)
 	for _, function := range declarations {
-		functions[function.Name.Lexeme] = NewLoxFunction(function, closure)
+		functions[function.Name.Lexeme] = NewLoxFunction(function, closure, interpreter)
 	}
 	return functions
 }
+
+// Get the values of the functions and namespaces in the registry by name, with namespaces as modules.
+func nativeGlobals(registry *native.Registry) map[string]interface{} {
+	globals := make(map[string]interface{})
+	for _, name := range registry.Names() {
+		if fn, ok := registry.Function(name); ok {
+			globals[name] = NewRegisteredNativeFunction(fn)
+		} else if namespace, ok := registry.Namespace(name); ok {
+			globals[name] = NewLoxModule(name, environment.NewEnvironment(nativeGlobals(namespace)), namespace.Names())
+		}
+	}
+	return globals
+}
@@ -13,7 +13,7 @@ import (
 func TestLoxClass_ToString(t *testing.T) {
 	clsDecl := &ast.ClassStmt{Name: &token.Token{Lexeme: ""}}
 	env := environment.NewEnvironment(make(map[string]interface{}))
-	cls := NewLoxClass(clsDecl, env)
+	cls := NewLoxClass(clsDecl, env, nil, nil)
 
 	var result string
 
@@ -31,7 +31,7 @@ func TestLoxClass_ToString(t *testing.T) {
 func TestLoxClass_Arity(t *testing.T) {
 	clsDecl := &ast.ClassStmt{Name: &token.Token{Lexeme: "MyClass"}}
 	env := environment.NewEnvironment(make(map[string]interface{}))
-	cls := NewLoxClass(clsDecl, env)
+	cls := NewLoxClass(clsDecl, env, nil, nil)
 	assert.Equal(t, 0, cls.Arity())
 
 	clsDecl.Constructor = &ast.FunctionStmt{
@@ -51,7 +51,7 @@ func TestLoxClass_Arity(t *testing.T) {
 func TestLoxFunction_Arity(t *testing.T) {
 	funcDecl := &ast.FunctionStmt{Params: []*token.Token{}}
 	env := environment.NewEnvironment(make(map[string]interface{}))
-	loxFunc := NewLoxFunction(funcDecl, env)
+	loxFunc := NewLoxFunction(funcDecl, env, nil)
 	assert.Equal(t, 0, loxFunc.Arity())
 
 	funcDecl.Params = []*token.Token{{}, {}, {}}
@@ -61,13 +61,23 @@ func TestLoxFunction_Arity(t *testing.T) {
 func TestLoxFunction_String(t *testing.T) {
 	funcDecl := &ast.FunctionStmt{Name: &token.Token{Lexeme: "myFunc1"}}
 	env := environment.NewEnvironment(make(map[string]interface{}))
-	loxFunc := NewLoxFunction(funcDecl, env)
+	loxFunc := NewLoxFunction(funcDecl, env, nil)
 
 	result := loxFunc.String()
 	assert.Contains(t, result, "<function myFunc1 [")
 	assert.Contains(t, result, "]>")
 }
 
+func TestLoxFunction_StringAnonymous(t *testing.T) {
+	funcDecl := &ast.FunctionStmt{Params: []*token.Token{}}
+	env := environment.NewEnvironment(make(map[string]interface{}))
+	loxFunc := NewLoxFunction(funcDecl, env, nil)
+
+	result := loxFunc.String()
+	assert.Contains(t, result, "<function anonymous [")
+	assert.Contains(t, result, "]>")
+}
+
 func TestNativeFunction_Arity(t *testing.T) {
 	nativeFunc := NewNativeFunction(
 		"myAwesomeFunction",
@@ -131,3 +141,13 @@ func TestNativeFunction_ToString(t *testing.T) {
 	assert.Contains(t, result, "<native function myAwesomeFunction")
 	assert.Contains(t, result, ">")
 }
+
+func TestLoxClass_CallPropagatesConstructorError(t *testing.T) {
+	w := NewInterpreterWrapper()
+	_, err := interpretLines(w, "class A { init() { this.x = 1; print y; } }", "var a = A();")
+	assert.ErrorContains(t, err, "Variable 'y' not defined")
+
+	// The failed construction must not define the variable.
+	_, err = interpretLines(w, "print a;")
+	assert.ErrorContains(t, err, "Variable 'a' not defined")
+}
@@ -0,0 +1,97 @@
+package interpreter
+
+import (
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
+)
+
+// The default maximum depth of nested calls before a stack overflow is reported.
+const DefaultMaxDepth = 1024
+
+// The greatest maximum depth of nested calls that can be set. Each Lox call nests several Go calls,
+// so deeper calls would overflow the Go stack and crash the interpreter instead of reporting an error.
+const MaxDepthLimit = 50000
+
+// A call to a Lox function that is in progress.
+type callFrame struct {
+	// The name of the called function, and the file it's declared in.
+	function string
+	file     string
+
+	// The token the function was called at, and the file of the code that called it.
+	callSite   *token.Token
+	callerFile string
+}
+
+// The calls in progress, which are shared by a program and the modules it imports.
+type callStack struct {
+	frames []callFrame
+
+	// The maximum depth of nested calls, counting the program itself.
+	maxDepth int
+}
+
+func newCallStack() *callStack {
+	return &callStack{
+		frames:   make([]callFrame, 0),
+		maxDepth: DefaultMaxDepth,
+	}
+}
+
+// Get the name of the function that calling the callable runs, as shown in stack traces.
+// Returns false if calling it doesn't run a Lox function.
+func frameName(callable Callable) (string, bool) {
+	switch c := callable.(type) {
+	case *LoxFunction:
+		if c.declaration.Name == nil {
+			return "anonymous", true
+		}
+		return c.declaration.Name.Lexeme, true
+	case *LoxClass:
+		if _, ok := c.FindConstructor(); ok {
+			return "init", true
+		}
+	}
+	return "", false
+}
+
+// Get the file that the callable was declared in.
+func frameFile(callable Callable) string {
+	switch c := callable.(type) {
+	case *LoxFunction:
+		return c.interpreter.file
+	case *LoxClass:
+		return c.interpreter.file
+	}
+	return ""
+}
+
+// Record the calls in progress as the stack trace of the runtime error or the error reported if the
+// thrown value is uncaught, unless it already has one.
+func (s *callStack) recordTrace(err error) {
+	if throw, ok := err.(*Throw); ok {
+		err = throw.Uncaught
+	}
+	runtimeErr, ok := err.(*loxerr.LoxRuntimeError)
+	if !ok || len(runtimeErr.Trace) > 0 || len(s.frames) == 0 {
+		return
+	}
+
+	// The innermost call is executing the code the error occurred at, and the others are executing calls.
+	trace := make([]loxerr.StackFrame, 0, len(s.frames)+1)
+	line := runtimeErr.Token.Line
+	for i := len(s.frames) - 1; i >= 0; i-- {
+		trace = append(trace, loxerr.StackFrame{
+			Function: s.frames[i].function,
+			File:     s.frames[i].file,
+			Line:     line,
+		})
+		line = s.frames[i].callSite.Line
+	}
+	trace = append(trace, loxerr.StackFrame{
+		Function: "<script>",
+		File:     s.frames[0].callerFile,
+		Line:     line,
+	})
+	runtimeErr.Trace = trace
+}
@@ -36,6 +36,44 @@ func (e *Environment) Replace(varName string, val interface{}) (exists bool) {
 	return true
 }
 
+// Define the variable in this environment, overwriting any existing value.
+func (e *Environment) Define(varName string, value interface{}) {
+	e.vars[varName] = value
+}
+
+// Get the value of the variable in the environment the given number of levels up.
+// A distance of 0 refers to this environment.
+func (e *Environment) GetAt(distance int, varName string) (value interface{}, exists bool) {
+	ancestor := e.Ancestor(distance)
+	if ancestor == nil {
+		return nil, false
+	}
+	return ancestor.Get(varName)
+}
+
+// Assign to the variable in the environment the given number of levels up.
+// A distance of 0 refers to this environment.
+func (e *Environment) AssignAt(distance int, varName string, val interface{}) (exists bool) {
+	ancestor := e.Ancestor(distance)
+	if ancestor == nil {
+		return false
+	}
+	if _, exists := ancestor.vars[varName]; !exists {
+		return false
+	}
+	ancestor.vars[varName] = val
+	return true
+}
+
+// Get the environment the given number of levels up, or nil if there is no such environment.
+func (e *Environment) Ancestor(distance int) *Environment {
+	currentEnv := e
+	for i := 0; i < distance && currentEnv != nil; i++ {
+		currentEnv = currentEnv.parent
+	}
+	return currentEnv
+}
+
 func (e *Environment) NewChild() *Environment {
 	child := NewEnvironment(make(map[string]interface{}))
 	child.parent = e
@@ -56,6 +94,20 @@ func (e *Environment) WithValues(values map[string]interface{}) *Environment {
 	return child
 }
 
+// Get the variables visible from this environment, where variables of inner environments
+// shadow the variables of outer ones with the same name.
+func (e *Environment) Visible() map[string]interface{} {
+	visible := make(map[string]interface{})
+	for currentEnv := e; currentEnv != nil; currentEnv = currentEnv.parent {
+		for varName, value := range currentEnv.vars {
+			if _, shadowed := visible[varName]; !shadowed {
+				visible[varName] = value
+			}
+		}
+	}
+	return visible
+}
+
 func (e *Environment) findEnvContainingName(varName string) *Environment {
 	currentEnv := e
 	for currentEnv != nil {
@@ -1 +1,68 @@
 package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironment_GetAt(t *testing.T) {
+	globals := NewEnvironment(map[string]interface{}{"a": "global"})
+	child := globals.WithValue("a", "child")
+	grandchild := child.NewChild()
+
+	value, exists := grandchild.GetAt(0, "a")
+	assert.False(t, exists)
+	assert.Nil(t, value)
+
+	value, exists = grandchild.GetAt(1, "a")
+	assert.True(t, exists)
+	assert.Equal(t, "child", value)
+
+	value, exists = grandchild.GetAt(2, "a")
+	assert.True(t, exists)
+	assert.Equal(t, "global", value)
+
+	value, exists = grandchild.GetAt(3, "a")
+	assert.False(t, exists)
+	assert.Nil(t, value)
+}
+
+func TestEnvironment_AssignAt(t *testing.T) {
+	globals := NewEnvironment(map[string]interface{}{"a": "global"})
+	child := globals.WithValue("a", "child")
+
+	assert.True(t, child.AssignAt(1, "a", "assigned"))
+	value, _ := child.Get("a")
+	assert.Equal(t, "child", value)
+	value, _ = globals.Get("a")
+	assert.Equal(t, "assigned", value)
+
+	assert.False(t, child.AssignAt(0, "b", "new"))
+	_, exists := child.Get("b")
+	assert.False(t, exists)
+
+	assert.False(t, child.AssignAt(5, "a", "missing"))
+}
+
+func TestEnvironment_Define(t *testing.T) {
+	env := NewEnvironment(make(map[string]interface{}))
+	child := env.NewChild()
+
+	child.Define("a", 1.0)
+	value, exists := child.Get("a")
+	assert.True(t, exists)
+	assert.Equal(t, 1.0, value)
+
+	_, exists = env.Get("a")
+	assert.False(t, exists)
+}
+
+func TestEnvironment_Visible(t *testing.T) {
+	env := NewEnvironment(map[string]interface{}{"a": 1.0, "b": 2.0})
+	child := env.WithValue("a", "shadowed")
+	child.Define("c", 3.0)
+
+	assert.Equal(t, map[string]interface{}{"a": "shadowed", "b": 2.0, "c": 3.0}, child.Visible())
+	assert.Equal(t, map[string]interface{}{"a": 1.0, "b": 2.0}, env.Visible())
+}
@@ -0,0 +1,63 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/kaschnit/golox/pkg/conversion"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
+)
+
+// Throw is implemented as an error in the same way as Return, so that a thrown value
+// propagates up from the throw statement until it is caught by an enclosing TryStmt.
+type Throw struct {
+	Value interface{}
+
+	// The error reported if nothing catches the thrown value.
+	Uncaught error
+}
+
+// Create a Throw of the value from the throw statement at the keyword.
+// Rethrowing a caught runtime error reports the original error if nothing else catches it.
+func NewThrow(value interface{}, keyword *token.Token) *Throw {
+	uncaught := error(loxerr.Runtime(keyword, loxerr.UncaughtException, fmt.Sprintf("Uncaught exception: %s", conversion.ToString(value))))
+	if loxError, ok := value.(*LoxError); ok {
+		uncaught = loxError.cause
+	}
+	return &Throw{
+		Value:    value,
+		Uncaught: uncaught,
+	}
+}
+
+func (t *Throw) Error() string {
+	return t.Uncaught.Error()
+}
+
+// Runtime representation of a caught runtime error, with "message" and "line" properties.
+type LoxError struct {
+	cause *loxerr.LoxRuntimeError
+}
+
+func NewLoxError(cause *loxerr.LoxRuntimeError) *LoxError {
+	return &LoxError{cause: cause}
+}
+
+func (e *LoxError) GetProperty(name *token.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "message":
+		return e.cause.Message(), nil
+	case "line":
+		return float64(e.cause.Token.Line), nil
+	default:
+		return nil, loxerr.Runtime(name, loxerr.UndefinedProperty, fmt.Sprintf("Property '%s' is not defined on %s", name.Lexeme, e))
+	}
+}
+
+func (e *LoxError) String() string {
+	return fmt.Sprintf("<error: %s>", e.cause.Message())
+}
+
+func (e *LoxError) TypeName() string {
+	return "error"
+}
@@ -0,0 +1,44 @@
+package interpreter
+
+import (
+	"testing"
+
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+	"github.com/stretchr/testify/assert"
+)
+
+var throwToken = &token.Token{Type: tokentype.THROW, Lexeme: "throw", Line: 3}
+
+func TestLoxError_Properties(t *testing.T) {
+	cause := loxerr.Runtime(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "x", Line: 7}, loxerr.UndefinedVariable, "Something failed.")
+	loxError := NewLoxError(cause)
+
+	message, err := loxError.GetProperty(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "message", Line: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "Something failed.", message)
+
+	line, err := loxError.GetProperty(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "line", Line: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 7.0, line)
+
+	_, err = loxError.GetProperty(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "other", Line: 1})
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	assert.Equal(t, "<error: Something failed.>", loxError.String())
+}
+
+func TestThrow_UncaughtValue(t *testing.T) {
+	throw := NewThrow("oops", throwToken)
+	assert.Equal(t, "oops", throw.Value)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, throw.Uncaught)
+	assert.Equal(t, throwToken, throw.Uncaught.(*loxerr.LoxRuntimeError).Token)
+	assert.Equal(t, "[line 3] Runtime error at 'throw': Uncaught exception: oops", throw.Error())
+}
+
+func TestThrow_RethrownErrorKeepsCause(t *testing.T) {
+	cause := loxerr.Runtime(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "x", Line: 7}, loxerr.UndefinedVariable, "Something failed.")
+	throw := NewThrow(NewLoxError(cause), throwToken)
+	assert.Equal(t, cause, throw.Uncaught)
+}
@@ -1,45 +1,145 @@
 package interpreter
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
-	"time"
+	"path/filepath"
 
 	"github.com/kaschnit/golox/pkg/ast"
+	"github.com/kaschnit/golox/pkg/ast/analyzer"
 	"github.com/kaschnit/golox/pkg/ast/interpreter/environment"
 	"github.com/kaschnit/golox/pkg/conversion"
 	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/module"
+	"github.com/kaschnit/golox/pkg/native"
 	"github.com/kaschnit/golox/pkg/token"
 	"github.com/kaschnit/golox/pkg/token/tokentype"
 )
 
+// Source of the scope distances of variable references, computed by static analysis.
+type Resolver interface {
+	// Get the number of scopes between the expression and the scope that declares the variable
+	// it refers to. Returns false if the variable is global.
+	ResolutionDistance(expr ast.Expr) (distance int, ok bool)
+}
+
 // Implementation of AstVisitor that interprets the visited AST directly
 type AstInterpreter struct {
-	env *environment.Environment
+	globals  *environment.Environment
+	env      *environment.Environment
+	resolver Resolver
+
+	// The number of loop iterations a program may run, or 0 if there is no limit, and the number
+	// run so far, which is shared with the interpreters of imported modules.
+	stepBudget int
+	steps      *int
+
+	// The calls in progress.
+	calls *callStack
+
+	// The loader of imported modules, and the directory that imports are relative to.
+	modules *module.Loader
+	dir     string
+
+	// The file the program was loaded from, or "" if it wasn't loaded from a file.
+	file string
+
+	// The registries of native functions installed in addition to the standard ones.
+	natives []*native.Registry
+
+	// The streams that print statements write to and the readLine native reads from.
+	stdout io.Writer
+	stdin  *bufio.Reader
+}
+
+// Create an AstInterpreter. Variable references are looked up using the
+// distances provided by the resolver, which must have visited the AST first.
+func NewAstInterpreter(resolver Resolver) *AstInterpreter {
+	globals := environment.NewEnvironment(nativeGlobals(native.Standard()))
+	a := &AstInterpreter{
+		globals:  globals,
+		env:      globals,
+		resolver: resolver,
+		steps:    new(int),
+		calls:    newCallStack(),
+		modules:  module.NewLoader(module.SearchPathFromEnv()),
+		dir:      "",
+		file:     "",
+		natives:  make([]*native.Registry, 0),
+		stdout:   os.Stdout,
+		stdin:    bufio.NewReader(os.Stdin),
+	}
+	for name, value := range nativeGlobals(native.Input(func() *bufio.Reader { return a.stdin })) {
+		globals.Define(name, value)
+	}
+	return a
 }
 
-// Create an AstInterpreter.
-func NewAstInterpreter() *AstInterpreter {
-	globals := environment.NewEnvironment(map[string]interface{}{
-		"clock": NewNativeFunction(
-			"clock",
-			0,
-			func(interpreter *AstInterpreter, args []interface{}) (interface{}, error) {
-				return time.Now().Unix(), nil
-			},
-		),
-	})
-	return &AstInterpreter{env: globals}
+// Set the writer that print statements write to.
+func (a *AstInterpreter) SetStdout(stdout io.Writer) {
+	a.stdout = stdout
 }
 
+// Set the reader that the readLine native reads from.
+func (a *AstInterpreter) SetStdin(stdin io.Reader) {
+	a.stdin = bufio.NewReader(stdin)
+}
+
+// Define the native functions of the registry as globals, in this program and the modules it imports.
+// Namespaces of natives are defined as modules.
+func (a *AstInterpreter) Install(registry *native.Registry) {
+	a.natives = append(a.natives, registry)
+	for name, value := range nativeGlobals(registry) {
+		a.globals.Define(name, value)
+	}
+}
+
+// Get the global variables and their values, including the native functions.
+func (a *AstInterpreter) Globals() map[string]interface{} {
+	return a.globals.Visible()
+}
+
+// Limit the number of loop iterations each program may run before it's stopped with a
+// runtime error. A budget of 0, the default, means there is no limit.
+func (a *AstInterpreter) SetStepBudget(budget int) {
+	a.stepBudget = budget
+}
+
+// Set the maximum depth of nested calls, counting the program itself, before a stack overflow
+// is reported as a runtime error. Depths greater than MaxDepthLimit are limited to it.
+func (a *AstInterpreter) SetMaxDepth(depth int) {
+	if depth > MaxDepthLimit {
+		depth = MaxDepthLimit
+	}
+	a.calls.maxDepth = depth
+}
+
+// Execute the program, returning the value of its last statement if it's an expression statement, or nil otherwise.
 func (a *AstInterpreter) VisitProgram(p *ast.Program) (interface{}, error) {
+	*a.steps = 0
+	return a.executeProgram(p)
+}
+
+// Execute the program without resetting the step count, so that it counts against the
+// budget of the program that imported it.
+func (a *AstInterpreter) executeProgram(p *ast.Program) (interface{}, error) {
+	var result interface{}
 	for i := 0; i < len(p.Statements); i++ {
-		_, err := p.Statements[i].Accept(a)
-		if err != nil {
+		value, err := p.Statements[i].Accept(a)
+		if throw, ok := err.(*Throw); ok {
+			return nil, throw.Uncaught
+		} else if err != nil {
 			return nil, err
 		}
+
+		result = nil
+		if _, isExpr := p.Statements[i].(*ast.ExprStmt); isExpr {
+			result = value
+		}
 	}
-	return nil, nil
+	return result, nil
 }
 
 func (a *AstInterpreter) VisitPrintStmt(s *ast.PrintStmt) (interface{}, error) {
@@ -48,7 +148,7 @@ func (a *AstInterpreter) VisitPrintStmt(s *ast.PrintStmt) (interface{}, error) {
 		return nil, err
 	}
 
-	fmt.Print(value)
+	fmt.Fprint(a.stdout, value)
 	return nil, nil
 }
 
@@ -63,6 +163,43 @@ func (a *AstInterpreter) VisitReturnStmt(s *ast.ReturnStmt) (interface{}, error)
 	return nil, NewReturn(nil)
 }
 
+func (a *AstInterpreter) VisitBreakStmt(s *ast.BreakStmt) (interface{}, error) {
+	return nil, NewBreak()
+}
+
+func (a *AstInterpreter) VisitContinueStmt(s *ast.ContinueStmt) (interface{}, error) {
+	return nil, NewContinue()
+}
+
+func (a *AstInterpreter) VisitThrowStmt(s *ast.ThrowStmt) (interface{}, error) {
+	value, err := s.Value.Accept(a)
+	if err != nil {
+		return nil, err
+	}
+	return nil, NewThrow(value, s.Keyword)
+}
+
+func (a *AstInterpreter) VisitTryStmt(s *ast.TryStmt) (interface{}, error) {
+	_, err := s.Body.Accept(a)
+
+	if s.CatchBody != nil {
+		if caught, ok := a.catchable(err); ok {
+			env := a.env.NewChild()
+			env.Define(s.CatchName.Lexeme, caught)
+			err = a.ExecuteBlock([]ast.Stmt{s.CatchBody}, env)
+		}
+	}
+
+	// The finally block runs however the try and catch blocks ended, and ending it early
+	// with an error, return, break or continue replaces how they ended.
+	if s.FinallyBody != nil {
+		if _, finallyErr := s.FinallyBody.Accept(a); finallyErr != nil {
+			return nil, finallyErr
+		}
+	}
+	return nil, err
+}
+
 func (a *AstInterpreter) VisitExprStmt(s *ast.ExprStmt) (interface{}, error) {
 	return s.Expression.Accept(a)
 }
@@ -87,22 +224,29 @@ func (a *AstInterpreter) VisitWhileStmt(s *ast.WhileStmt) (interface{}, error) {
 		return nil, err
 	}
 
-	i := 0
 	for conversion.IsTruthy(cond) {
+		if err := a.consumeStep(s.Keyword); err != nil {
+			return nil, err
+		}
+
+		// Break and Continue are propagated by child nodes up until this node.
 		_, err := s.LoopStatement.Accept(a)
-		if err != nil {
+		if _, ok := err.(*Break); ok {
+			break
+		} else if _, ok := err.(*Continue); !ok && err != nil {
 			return nil, err
 		}
 
+		if s.Increment != nil {
+			if _, err := s.Increment.Accept(a); err != nil {
+				return nil, err
+			}
+		}
+
 		cond, err = s.Condition.Accept(a)
 		if err != nil {
 			return nil, err
 		}
-		i += 1
-		if i > 100 {
-			os.Exit(1)
-		}
-
 	}
 	return nil, nil
 }
@@ -115,58 +259,132 @@ func (a *AstInterpreter) VisitBlockStmt(s *ast.BlockStmt) (interface{}, error) {
 func (a *AstInterpreter) VisitClassStmt(s *ast.ClassStmt) (interface{}, error) {
 	_, exists := a.env.Get(s.Name.Lexeme)
 	if exists {
-		return nil, loxerr.Runtime(s.Name, fmt.Sprintf("Name '%s' already defined", s.Name.Lexeme))
+		return nil, loxerr.Runtime(s.Name, loxerr.AlreadyDefined, fmt.Sprintf("Name '%s' already defined", s.Name.Lexeme))
 	}
 
-	a.env = a.env.WithValue(s.Name.Lexeme, nil)
-	cls := NewLoxClass(s, a.env)
-	a.env.Replace(s.Name.Lexeme, cls)
+	var superclass *LoxClass
+	if s.Superclass != nil {
+		value, err := s.Superclass.Accept(a)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		superclass, ok = value.(*LoxClass)
+		if !ok {
+			return nil, loxerr.Runtime(s.Superclass.Name, loxerr.SuperclassNotClass, "Superclass must be a class.")
+		}
+	}
+
+	a.env.Define(s.Name.Lexeme, nil)
+	cls := NewLoxClass(s, a.env, superclass, a)
+	a.env.Define(s.Name.Lexeme, cls)
 	return nil, nil
 }
 
 func (a *AstInterpreter) VisitFunctionStmt(s *ast.FunctionStmt) (interface{}, error) {
 	_, exists := a.env.Get(s.Name.Lexeme)
 	if exists {
-		return nil, loxerr.Runtime(s.Name, fmt.Sprintf("Name '%s' already defined", s.Name.Lexeme))
+		return nil, loxerr.Runtime(s.Name, loxerr.AlreadyDefined, fmt.Sprintf("Name '%s' already defined", s.Name.Lexeme))
 	}
 
-	a.env = a.env.WithValue(s.Name.Lexeme, nil)
-	function := NewLoxFunction(s, a.env)
-	a.env.Replace(s.Name.Lexeme, function)
+	function := NewLoxFunction(s, a.env, a)
+	a.env.Define(s.Name.Lexeme, function)
 
 	return nil, nil
 }
 
+func (a *AstInterpreter) VisitFunctionExpr(e *ast.FunctionExpr) (interface{}, error) {
+	// An anonymous function is a function declaration without a name.
+	declaration := &ast.FunctionStmt{
+		Params: e.Params,
+		Body:   e.Body,
+	}
+	return NewLoxFunction(declaration, a.env, a), nil
+}
+
 func (a *AstInterpreter) VisitVarStmt(s *ast.VarStmt) (interface{}, error) {
 	_, exists := a.env.Get(s.Left.Lexeme)
 	if exists {
-		return nil, loxerr.Runtime(s.Left, fmt.Sprintf("Name '%s' already defined", s.Left.Lexeme))
+		return nil, loxerr.Runtime(s.Left, loxerr.AlreadyDefined, fmt.Sprintf("Name '%s' already defined", s.Left.Lexeme))
 	}
 
-	if s.Right == nil {
-		a.env = a.env.WithValue(s.Left.Lexeme, nil)
-	} else {
-		value, err := s.Right.Accept(a)
+	var value interface{}
+	if s.Right != nil {
+		var err error
+		value, err = s.Right.Accept(a)
 		if err != nil {
 			return nil, err
 		}
-		a.env = a.env.WithValue(s.Left.Lexeme, value)
 	}
+	a.env.Define(s.Left.Lexeme, value)
 
 	return nil, nil
 }
 
+func (a *AstInterpreter) VisitImportStmt(s *ast.ImportStmt) (interface{}, error) {
+	_, exists := a.env.Get(s.Name.Lexeme)
+	if exists {
+		return nil, loxerr.Runtime(s.Name, loxerr.AlreadyDefined, fmt.Sprintf("Name '%s' already defined", s.Name.Lexeme))
+	}
+
+	path, err := a.modules.Resolve(s.Path.Literal.(string), a.dir)
+	if err != nil {
+		return nil, loxerr.RuntimeFrom(s.Path, err)
+	}
+
+	mod, err := a.modules.Load(path, a.executeModule)
+	if err != nil {
+		return nil, loxerr.RuntimeFrom(s.Path, err)
+	}
+
+	a.env.Define(s.Name.Lexeme, mod)
+	return nil, nil
+}
+
+// Execute an imported module with its own globals, producing the module object.
+func (a *AstInterpreter) executeModule(path string, program *ast.Program) (interface{}, error) {
+	analyzer := analyzer.NewAstAnalyzer()
+	if _, err := analyzer.VisitProgram(program); err != nil {
+		return nil, err
+	}
+
+	interpreter := NewAstInterpreter(analyzer)
+	interpreter.stepBudget = a.stepBudget
+	interpreter.steps = a.steps
+	interpreter.calls = a.calls
+	interpreter.modules = a.modules
+	interpreter.dir = filepath.Dir(path)
+	interpreter.file = path
+	interpreter.stdout = a.stdout
+	interpreter.stdin = a.stdin
+	for _, registry := range a.natives {
+		interpreter.Install(registry)
+	}
+	if _, err := interpreter.executeProgram(program); err != nil {
+		return nil, err
+	}
+
+	return NewLoxModule(module.Name(path), interpreter.globals, module.Exports(program)), nil
+}
+
 func (a *AstInterpreter) VisitAssignExpr(e *ast.AssignExpr) (interface{}, error) {
 	value, err := e.Right.Accept(a)
 	if err != nil {
 		return nil, err
 	}
 
-	if exists := a.env.Replace(e.Left.Lexeme, value); exists {
+	var exists bool
+	if distance, ok := a.resolver.ResolutionDistance(e); ok {
+		exists = a.env.AssignAt(distance, e.Left.Lexeme, value)
+	} else {
+		exists = a.globals.Replace(e.Left.Lexeme, value)
+	}
+	if exists {
 		return value, nil
 	}
 
-	return nil, loxerr.Runtime(e.Left, fmt.Sprintf("Variable '%s' not defined", e.Left.Lexeme))
+	return nil, loxerr.Runtime(e.Left, loxerr.UndefinedVariable, fmt.Sprintf("Variable '%s' not defined", e.Left.Lexeme))
 }
 
 func (a *AstInterpreter) VisitCallExpr(e *ast.CallExpr) (interface{}, error) {
@@ -177,12 +395,12 @@ func (a *AstInterpreter) VisitCallExpr(e *ast.CallExpr) (interface{}, error) {
 
 	callable, ok := callee.(Callable)
 	if !ok {
-		return nil, loxerr.Runtime(e.OpenParen,
+		return nil, loxerr.Runtime(e.OpenParen, loxerr.NotCallable,
 			fmt.Sprintf("Expression '%v' is not callable", callee))
 	}
 
-	if len(e.Args) != callable.Arity() {
-		return nil, loxerr.Runtime(e.OpenParen,
+	if fn, isNative := callable.(*NativeFunction); (!isNative || !fn.variadic) && len(e.Args) != callable.Arity() {
+		return nil, loxerr.Runtime(e.OpenParen, loxerr.WrongArgCount,
 			fmt.Sprintf("Expected %d args, got %d.", callable.Arity(), len(e.Args)))
 	}
 
@@ -195,7 +413,32 @@ func (a *AstInterpreter) VisitCallExpr(e *ast.CallExpr) (interface{}, error) {
 		argList = append(argList, argValue)
 	}
 
+	name, isFunction := frameName(callable)
+	if isFunction {
+		if len(a.calls.frames)+1 >= a.calls.maxDepth {
+			return nil, loxerr.Runtime(e.OpenParen, loxerr.StackOverflow, "Stack overflow.")
+		}
+		a.calls.frames = append(a.calls.frames, callFrame{
+			function:   name,
+			file:       frameFile(callable),
+			callSite:   e.OpenParen,
+			callerFile: a.file,
+		})
+		defer func() {
+			a.calls.frames = a.calls.frames[:len(a.calls.frames)-1]
+		}()
+	}
+
 	result, err := callable.Call(a, argList)
+	if isFunction {
+		a.calls.recordTrace(err)
+	}
+	if _, isNative := callable.(*NativeFunction); isNative && err != nil {
+		// Native functions don't know where they're called from, so their errors are located at the call.
+		if _, isLoxErr := err.(*loxerr.LoxRuntimeError); !isLoxErr {
+			return nil, loxerr.RuntimeFrom(e.OpenParen, err)
+		}
+	}
 	return result, err
 }
 
@@ -212,6 +455,10 @@ func (a *AstInterpreter) VisitBinaryExpr(e *ast.BinaryExpr) (interface{}, error)
 	}
 	rhsFloat, isRhsFloat := conversion.ToFloat(rhs)
 
+	lhsString, isLhsString := lhs.(string)
+	rhsString, isRhsString := rhs.(string)
+	isStrings := isLhsString && isRhsString
+
 	invalidOperatorMsg := fmt.Sprintf("Invalid operator '%s'", e.Operator.Lexeme)
 
 	switch e.Operator.Type {
@@ -219,25 +466,28 @@ func (a *AstInterpreter) VisitBinaryExpr(e *ast.BinaryExpr) (interface{}, error)
 		if isLhsFloat && isRhsFloat {
 			return lhsFloat - rhsFloat, nil
 		} else {
-			return nil, loxerr.Runtime(e.Operator, invalidOperatorMsg)
+			return nil, loxerr.Runtime(e.Operator, loxerr.InvalidOperand, invalidOperatorMsg)
 		}
 	case tokentype.PLUS:
 		if isLhsFloat && isRhsFloat {
 			return lhsFloat + rhsFloat, nil
+		} else if isLhsString || isRhsString {
+			// Adding a string to any value concatenates the value's string form.
+			return conversion.ToString(lhs) + conversion.ToString(rhs), nil
 		} else {
-			return nil, loxerr.Runtime(e.Operator, invalidOperatorMsg)
+			return nil, loxerr.Runtime(e.Operator, loxerr.InvalidOperand, invalidOperatorMsg)
 		}
 	case tokentype.SLASH:
 		if isLhsFloat && isRhsFloat {
 			return lhsFloat / rhsFloat, nil
 		} else {
-			return nil, loxerr.Runtime(e.Operator, invalidOperatorMsg)
+			return nil, loxerr.Runtime(e.Operator, loxerr.InvalidOperand, invalidOperatorMsg)
 		}
 	case tokentype.STAR:
 		if isLhsFloat && isRhsFloat {
 			return lhsFloat * rhsFloat, nil
 		} else {
-			return nil, loxerr.Runtime(e.Operator, invalidOperatorMsg)
+			return nil, loxerr.Runtime(e.Operator, loxerr.InvalidOperand, invalidOperatorMsg)
 		}
 	case tokentype.BANG_EQUAL:
 		return lhs != rhs, nil
@@ -246,34 +496,62 @@ func (a *AstInterpreter) VisitBinaryExpr(e *ast.BinaryExpr) (interface{}, error)
 	case tokentype.GREATER:
 		if isLhsFloat && isRhsFloat {
 			return lhsFloat > rhsFloat, nil
+		} else if isStrings {
+			return lhsString > rhsString, nil
 		} else {
-			return nil, loxerr.Runtime(e.Operator, invalidOperatorMsg)
+			return nil, loxerr.Runtime(e.Operator, loxerr.InvalidOperand, invalidOperatorMsg)
 		}
 	case tokentype.GREATER_EQUAL:
 		if isLhsFloat && isRhsFloat {
 			return lhsFloat >= rhsFloat, nil
+		} else if isStrings {
+			return lhsString >= rhsString, nil
 		} else {
-			return nil, loxerr.Runtime(e.Operator, invalidOperatorMsg)
+			return nil, loxerr.Runtime(e.Operator, loxerr.InvalidOperand, invalidOperatorMsg)
 		}
 	case tokentype.LESS:
 		if isLhsFloat && isRhsFloat {
 			return lhsFloat < rhsFloat, nil
+		} else if isStrings {
+			return lhsString < rhsString, nil
 		} else {
-			return nil, loxerr.Runtime(e.Operator, invalidOperatorMsg)
+			return nil, loxerr.Runtime(e.Operator, loxerr.InvalidOperand, invalidOperatorMsg)
 		}
 	case tokentype.LESS_EQUAL:
 		if isLhsFloat && isRhsFloat {
 			return lhsFloat <= rhsFloat, nil
+		} else if isStrings {
+			return lhsString <= rhsString, nil
 		} else {
-			return nil, loxerr.Runtime(e.Operator, invalidOperatorMsg)
+			return nil, loxerr.Runtime(e.Operator, loxerr.InvalidOperand, invalidOperatorMsg)
 		}
+	default:
+		return nil, loxerr.Internal(fmt.Sprintf("Unknown binary operator '%s' reached interpreter!", e.Operator.Lexeme))
+	}
+}
+
+func (a *AstInterpreter) VisitLogicalExpr(e *ast.LogicalExpr) (interface{}, error) {
+	lhs, err := e.Left.Accept(a)
+	if err != nil {
+		return nil, err
+	}
+
+	// The result is whichever operand decided it, so the right operand
+	// is only evaluated if the left one doesn't decide the result.
+	switch e.Operator.Type {
 	case tokentype.AND:
-		return conversion.IsTruthy(lhs) && conversion.IsTruthy(rhs), nil
+		if !conversion.IsTruthy(lhs) {
+			return lhs, nil
+		}
 	case tokentype.OR:
-		return conversion.IsTruthy(lhs) || conversion.IsTruthy(rhs), nil
+		if conversion.IsTruthy(lhs) {
+			return lhs, nil
+		}
 	default:
-		return nil, loxerr.Internal(fmt.Sprintf("Unknown binary operator '%s' reached interpreter!", e.Operator.Lexeme))
+		return nil, loxerr.Internal(fmt.Sprintf("Unknown logical operator '%s' reached interpreter!", e.Operator.Lexeme))
 	}
+
+	return e.Right.Accept(a)
 }
 
 func (a *AstInterpreter) VisitUnaryExpr(e *ast.UnaryExpr) (interface{}, error) {
@@ -289,7 +567,7 @@ func (a *AstInterpreter) VisitUnaryExpr(e *ast.UnaryExpr) (interface{}, error) {
 		if floatValue, ok := conversion.ToFloat(rhsResult); ok {
 			return -floatValue, nil
 		}
-		return nil, loxerr.Runtime(e.Operator, fmt.Sprintf("Unable to apply operator '%s' to value: %v", e.Operator.Lexeme, rhsResult))
+		return nil, loxerr.Runtime(e.Operator, loxerr.InvalidOperand, fmt.Sprintf("Unable to apply operator '%s' to value: %v", e.Operator.Lexeme, rhsResult))
 	default:
 		return nil, loxerr.Internal(fmt.Sprintf("Unknown unary operator '%s' reached interpreter!", e.Operator.Lexeme))
 	}
@@ -314,11 +592,24 @@ func (a *AstInterpreter) VisitGetPropertyExpr(e *ast.GetPropertyExpr) (interface
 		return nil, err
 	}
 
+	if list, ok := parentObj.(*LoxList); ok {
+		return list.GetMethod(e.Name)
+	}
+	if m, ok := parentObj.(*LoxMap); ok {
+		return m.GetMethod(e.Name)
+	}
+	if loxError, ok := parentObj.(*LoxError); ok {
+		return loxError.GetProperty(e.Name)
+	}
+	if mod, ok := parentObj.(*LoxModule); ok {
+		return mod.GetProperty(e.Name)
+	}
+
 	instance, ok := parentObj.(*LoxClassInstance)
 	if !ok {
 		cls, ok := parentObj.(*LoxClass)
 		if !ok {
-			return nil, loxerr.Runtime(e.Name, "Only instances have properties.")
+			return nil, loxerr.Runtime(e.Name, loxerr.NotAnInstance, "Only instances have properties.")
 		}
 		if cls == nil {
 			return nil, loxerr.Internal(fmt.Sprintf("Somehow the metaclass of metaclass %s is being accessed!", cls))
@@ -339,7 +630,7 @@ func (a *AstInterpreter) VisitSetPropertyExpr(e *ast.SetPropertyExpr) (interface
 	if !ok {
 		cls, ok := parentObj.(*LoxClass)
 		if !ok {
-			return nil, loxerr.Runtime(e.Name, "Only instances have properties.")
+			return nil, loxerr.Runtime(e.Name, loxerr.NotAnInstance, "Only instances have properties.")
 		}
 		if cls == nil {
 			return nil, loxerr.Internal(fmt.Sprintf("Somehow the metaclass of metaclass %s is being accessed!", cls))
@@ -357,11 +648,122 @@ func (a *AstInterpreter) VisitSetPropertyExpr(e *ast.SetPropertyExpr) (interface
 	return value, nil
 }
 
+func (a *AstInterpreter) VisitListExpr(e *ast.ListExpr) (interface{}, error) {
+	elements := make([]interface{}, 0, len(e.Elements))
+	for _, elementExpr := range e.Elements {
+		element, err := elementExpr.Accept(a)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	return NewLoxList(elements), nil
+}
+
+func (a *AstInterpreter) VisitMapExpr(e *ast.MapExpr) (interface{}, error) {
+	m := NewLoxMap()
+	for i := range e.Keys {
+		key, err := e.Keys[i].Accept(a)
+		if err != nil {
+			return nil, err
+		}
+		value, err := e.Values[i].Accept(a)
+		if err != nil {
+			return nil, err
+		}
+		m.Set(key, value)
+	}
+	return m, nil
+}
+
+func (a *AstInterpreter) VisitIndexGetExpr(e *ast.IndexGetExpr) (interface{}, error) {
+	object, err := e.Object.Accept(a)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := e.Index.Accept(a)
+	if err != nil {
+		return nil, err
+	}
+
+	switch object := object.(type) {
+	case *LoxList:
+		return object.Get(e.OpenBracket, index)
+	case *LoxMap:
+		return object.Get(e.OpenBracket, index)
+	default:
+		return nil, loxerr.Runtime(e.OpenBracket, loxerr.NotIndexable, "Only lists and maps can be indexed.")
+	}
+}
+
+func (a *AstInterpreter) VisitIndexSetExpr(e *ast.IndexSetExpr) (interface{}, error) {
+	object, err := e.Object.Accept(a)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := e.Index.Accept(a)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := e.Value.Accept(a)
+	if err != nil {
+		return nil, err
+	}
+
+	switch object := object.(type) {
+	case *LoxList:
+		if err := object.Set(e.OpenBracket, index, value); err != nil {
+			return nil, err
+		}
+	case *LoxMap:
+		object.Set(index, value)
+	default:
+		return nil, loxerr.Runtime(e.OpenBracket, loxerr.NotIndexable, "Only lists and maps can be indexed.")
+	}
+	return value, nil
+}
+
 func (a *AstInterpreter) VisitThisExpr(e *ast.ThisExpr) (interface{}, error) {
 	result, err := a.findVar(e.Keyword, e)
 	return result, err
 }
 
+func (a *AstInterpreter) VisitSuperExpr(e *ast.SuperExpr) (interface{}, error) {
+	distance, ok := a.resolver.ResolutionDistance(e)
+	if !ok {
+		return nil, loxerr.Internal("Somehow 'super' was not resolved to a scope!")
+	}
+
+	superValue, exists := a.env.GetAt(distance, e.Keyword.Lexeme)
+	if !exists {
+		return nil, loxerr.Internal("Somehow 'super' is being used without 'super' defined!")
+	}
+	superclass := superValue.(*LoxClass)
+
+	// The scope defining "this" is always directly inside the scope defining "super".
+	thisValue, exists := a.env.GetAt(distance-1, "this")
+	if !exists {
+		return nil, loxerr.Internal("Somehow 'super' is being used without 'this' defined!")
+	}
+	instance := thisValue.(*LoxClassInstance)
+
+	// The superclass's constructor is accessible through super, e.g. "super.init()".
+	var method *LoxFunction
+	if e.Method.Lexeme == "init" {
+		method, ok = superclass.FindConstructor()
+	} else {
+		method, ok = superclass.FindMethod(e.Method.Lexeme)
+	}
+	if !ok {
+		return nil, loxerr.Runtime(e.Method, loxerr.UndefinedProperty, fmt.Sprintf("Property '%s' is not defined on %s", e.Method.Lexeme, superclass))
+	}
+
+	return method.Bind(instance), nil
+}
+
 func (a *AstInterpreter) ExecuteBlock(stmts []ast.Stmt, env *environment.Environment) error {
 	prevEnv := a.env
 	defer func() {
@@ -379,10 +781,47 @@ func (a *AstInterpreter) ExecuteBlock(stmts []ast.Stmt, env *environment.Environ
 	return nil
 }
 
+// Get the value that a catch block receives for the error, if the error can be caught.
+// Running out of the step budget can't be caught, so that scripts can't keep running after it.
+func (a *AstInterpreter) catchable(err error) (interface{}, bool) {
+	if a.stepBudget > 0 && *a.steps > a.stepBudget {
+		return nil, false
+	}
+
+	switch err := err.(type) {
+	case *Throw:
+		return err.Value, true
+	case *loxerr.LoxRuntimeError:
+		return NewLoxError(err), true
+	default:
+		return nil, false
+	}
+}
+
+// Count a loop iteration against the step budget, failing if the budget is used up.
+func (a *AstInterpreter) consumeStep(loopKeyword *token.Token) error {
+	if a.stepBudget <= 0 {
+		return nil
+	}
+
+	*a.steps++
+	if *a.steps > a.stepBudget {
+		return loxerr.Runtime(loopKeyword, loxerr.StepBudgetExceeded, fmt.Sprintf("Step budget of %d exceeded.", a.stepBudget))
+	}
+	return nil
+}
+
 func (a *AstInterpreter) findVar(name *token.Token, expr ast.Expr) (interface{}, error) {
-	if result, exists := a.env.TraverseGet(name.Lexeme); exists {
+	var result interface{}
+	var exists bool
+	if distance, ok := a.resolver.ResolutionDistance(expr); ok {
+		result, exists = a.env.GetAt(distance, name.Lexeme)
+	} else {
+		result, exists = a.globals.Get(name.Lexeme)
+	}
+	if exists {
 		return result, nil
 	}
 
-	return nil, loxerr.Runtime(name, fmt.Sprintf("Variable '%s' not defined", name.Lexeme))
+	return nil, loxerr.Runtime(name, loxerr.UndefinedVariable, fmt.Sprintf("Variable '%s' not defined", name.Lexeme))
 }
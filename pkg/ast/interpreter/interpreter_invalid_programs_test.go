@@ -1,9 +1,13 @@
 package interpreter
 
 import (
+	"fmt"
 	"testing"
 
 	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+	"github.com/kaschnit/golox/test/programs"
+	"github.com/kaschnit/golox/test/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,6 +17,7 @@ func TestInterpreter_InvalidProgram_GetClassInstanceUndefinedProperty(t *testing
 	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
 
 	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, loxerr.UndefinedProperty, runtimeErr.Code())
 	assert.Equal(t, "hello", runtimeErr.Token.Lexeme)
 	assert.ErrorContains(t, runtimeErr, "Property")
 	assert.ErrorContains(t, runtimeErr, "not defined")
@@ -23,6 +28,7 @@ func TestInterpreter_InvalidProgram_PropertyAccessOnNonClass(t *testing.T) {
 	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
 
 	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, loxerr.NotAnInstance, runtimeErr.Code())
 	assert.Equal(t, "someProperty", runtimeErr.Token.Lexeme)
 	assert.ErrorContains(t, runtimeErr, "instance")
 }
@@ -34,6 +40,7 @@ func TestInterpreter_InvalidProgram_PropertySetOnNonClass(t *testing.T) {
 	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
 
 	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, loxerr.NotAnInstance, runtimeErr.Code())
 	assert.Equal(t, "someProperty", runtimeErr.Token.Lexeme)
 	assert.ErrorContains(t, runtimeErr, "instance")
 }
@@ -44,6 +51,7 @@ func TestInterpreter_InvalidProgram_VariableNotDefinedAssignment(t *testing.T) {
 	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
 
 	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, loxerr.UndefinedVariable, runtimeErr.Code())
 	assert.Equal(t, "y", runtimeErr.Token.Lexeme)
 	assert.ErrorContains(t, runtimeErr, "not defined")
 }
@@ -54,6 +62,7 @@ func TestInterpreter_InvalidProgram_VariableNotDefinedInitialization(t *testing.
 	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
 
 	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, loxerr.UndefinedVariable, runtimeErr.Code())
 	assert.Equal(t, "y", runtimeErr.Token.Lexeme)
 	assert.ErrorContains(t, runtimeErr, "not defined")
 }
@@ -67,3 +76,136 @@ func TestInterpreter_InvalidProgram_VariableNotDefinedPrint(t *testing.T) {
 	assert.Equal(t, "x", runtimeErr.Token.Lexeme)
 	assert.ErrorContains(t, runtimeErr, "not defined")
 }
+
+func TestInterpreter_InvalidProgram_SuperclassNotAClass(t *testing.T) {
+	err := interpretSourceFile("invalid/interpreter/SuperclassNotAClass.lox")
+	assert.Error(t, err)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, "NotAClass", runtimeErr.Token.Lexeme)
+	assert.ErrorContains(t, runtimeErr, "Superclass must be a class.")
+}
+
+func TestInterpreter_InvalidProgram_StringComparedToNumber(t *testing.T) {
+	err := interpretSourceFile("invalid/interpreter/StringComparedToNumber.lox")
+	assert.Error(t, err)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, tokentype.LESS, runtimeErr.Token.Type)
+	assert.Equal(t, 2, runtimeErr.Token.Line)
+}
+
+func TestInterpreter_InvalidProgram_ListIndexOutOfBounds(t *testing.T) {
+	err := interpretSourceFile("invalid/interpreter/ListIndexOutOfBounds.lox")
+	assert.Error(t, err)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, tokentype.LEFT_BRACKET, runtimeErr.Token.Type)
+	assert.Equal(t, 3, runtimeErr.Token.Line)
+	assert.ErrorContains(t, runtimeErr, "out of bounds")
+}
+
+func TestInterpreter_InvalidProgram_ListPopEmpty(t *testing.T) {
+	err := interpretSourceFile("invalid/interpreter/ListPopEmpty.lox")
+	assert.Error(t, err)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, tokentype.LEFT_PAREN, runtimeErr.Token.Type)
+	assert.ErrorContains(t, runtimeErr, "Can't pop from an empty list.")
+}
+
+func TestInterpreter_InvalidProgram_IndexNonList(t *testing.T) {
+	err := interpretSourceFile("invalid/interpreter/IndexNonList.lox")
+	assert.Error(t, err)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, tokentype.LEFT_BRACKET, runtimeErr.Token.Type)
+	assert.ErrorContains(t, runtimeErr, "Only lists and maps can be indexed.")
+}
+
+func TestInterpreter_InvalidProgram_MapMissingKey(t *testing.T) {
+	err := interpretSourceFile("invalid/interpreter/MapMissingKey.lox")
+	assert.Error(t, err)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, tokentype.LEFT_BRACKET, runtimeErr.Token.Type)
+	assert.Equal(t, 2, runtimeErr.Token.Line)
+	assert.ErrorContains(t, runtimeErr, "Key 'b' not found in map.")
+}
+
+func TestInterpreter_InvalidProgram_ClassConstructorRuntimeError(t *testing.T) {
+	result, err := testutil.CaptureOutput(func() error {
+		return interpretSourceFile("invalid/interpreter/ClassConstructorRuntimeError.lox")
+	})
+	assert.Equal(t, "", result)
+	assert.Error(t, err)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, "missing", runtimeErr.Token.Lexeme)
+	assert.Equal(t, 4, runtimeErr.Token.Line)
+}
+
+func TestInterpreter_InvalidProgram_UncaughtThrow(t *testing.T) {
+	err := interpretSourceFile("invalid/interpreter/UncaughtThrow.lox")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, tokentype.THROW, runtimeErr.Token.Type)
+	assert.Equal(t, 2, runtimeErr.Token.Line)
+	assert.ErrorContains(t, runtimeErr, "Uncaught exception: boom")
+}
+
+func TestInterpreter_InvalidProgram_ImportCycle(t *testing.T) {
+	err := interpretSourceFile("invalid/interpreter/ImportCycleA.lox")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, `"ImportCycleB.lox"`, runtimeErr.Token.Lexeme)
+	assert.Equal(t, 1, runtimeErr.Token.Line)
+
+	cycleA := programs.GetPath("invalid/interpreter/ImportCycleA.lox")
+	cycleB := programs.GetPath("invalid/interpreter/ImportCycleB.lox")
+	assert.ErrorContains(t, runtimeErr, fmt.Sprintf("Import cycle: %s -> %s -> %s.", cycleA, cycleB, cycleA))
+}
+
+func TestInterpreter_InvalidProgram_NativeArgumentType(t *testing.T) {
+	err := interpretSourceFile("invalid/interpreter/NativeArgumentType.lox")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, tokentype.LEFT_PAREN, runtimeErr.Token.Type)
+	assert.Equal(t, 2, runtimeErr.Token.Line)
+	assert.ErrorContains(t, runtimeErr, "Argument 3 of 'math.max' must be a number, got string.")
+}
+
+func TestInterpreter_InvalidProgram_StackTrace(t *testing.T) {
+	err := interpretSourceFile("invalid/interpreter/StackTrace.lox")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	path := programs.GetPath("invalid/interpreter/StackTrace.lox")
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, 2, runtimeErr.Token.Line)
+	assert.Equal(t, []loxerr.StackFrame{
+		{Function: "inner", File: path, Line: 2},
+		{Function: "outer", File: path, Line: 5},
+		{Function: "<script>", File: path, Line: 7},
+	}, runtimeErr.Trace)
+}
+
+func TestInterpreter_InvalidProgram_StackOverflow(t *testing.T) {
+	err := interpretSourceFile("invalid/interpreter/StackOverflow.lox")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, tokentype.LEFT_PAREN, runtimeErr.Token.Type)
+	assert.Equal(t, 2, runtimeErr.Token.Line)
+	assert.ErrorContains(t, runtimeErr, "Stack overflow.")
+	assert.Len(t, runtimeErr.Trace, DefaultMaxDepth)
+}
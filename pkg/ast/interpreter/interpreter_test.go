@@ -1 +1,234 @@
 package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/native"
+	"github.com/kaschnit/golox/test/programs"
+	"github.com/stretchr/testify/assert"
+)
+
+func interpretLines(w *InterpreterWrapper, lines ...string) (string, error) {
+	var stdout strings.Builder
+	w.SetStdout(&stdout)
+	for _, line := range lines {
+		if err := w.InterpretLine(line); err != nil {
+			return "", err
+		}
+	}
+	return stdout.String(), nil
+}
+
+func TestInterpreter_StepBudget_Exceeded(t *testing.T) {
+	w := NewInterpreterWrapper()
+	w.SetStepBudget(10)
+
+	result, err := interpretLines(w, "var i = 0;\nwhile (true) {\n i = i + 1;\n}")
+	assert.Equal(t, "", result)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, "while", runtimeErr.Token.Lexeme)
+	assert.Equal(t, 2, runtimeErr.Token.Line)
+	assert.ErrorContains(t, runtimeErr, "Step budget of 10 exceeded.")
+}
+
+func TestInterpreter_StepBudget_ForLoopExceeded(t *testing.T) {
+	w := NewInterpreterWrapper()
+	w.SetStepBudget(5)
+
+	_, err := interpretLines(w, "for (var i = 0; i < 6; i = i + 1) {}")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.Equal(t, "for", err.(*loxerr.LoxRuntimeError).Token.Lexeme)
+}
+
+func TestInterpreter_StepBudget_WithinBudget(t *testing.T) {
+	w := NewInterpreterWrapper()
+	w.SetStepBudget(5)
+
+	// The budget applies to each program separately.
+	result, err := interpretLines(w,
+		"for (var i = 0; i < 5; i = i + 1) { print i; }",
+		"for (var i = 0; i < 5; i = i + 1) { print i; }",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "0123401234", result)
+}
+
+func TestInterpreter_StepBudget_UnlimitedByDefault(t *testing.T) {
+	result, err := interpretLines(NewInterpreterWrapper(), "var i = 0; while (i < 5000) { i = i + 1; } print i;")
+	assert.Nil(t, err)
+	assert.Equal(t, "5000", result)
+}
+
+func TestInterpreter_StepBudget_NotCatchable(t *testing.T) {
+	w := NewInterpreterWrapper()
+	w.SetStepBudget(10)
+
+	_, err := interpretLines(w, "try { while (true) {} } catch (e) { print e; }")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Step budget of 10 exceeded.")
+}
+
+func TestInterpreter_StepBudget_SharedWithImports(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Loop.lox"), []byte("var i = 0; while (i < 5) { i = i + 1; }"), 0o644))
+
+	w := NewInterpreterWrapper()
+	w.SetSearchPath([]string{dir})
+	w.SetStepBudget(8)
+
+	// The module's loop iterations count against the budget of the script that imports it.
+	_, err := interpretLines(w, "import \"Loop.lox\" as loop;\nfor (var j = 0; j < 5; j = j + 1) {}")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Step budget of 8 exceeded.")
+	assert.Equal(t, 2, err.(*loxerr.LoxRuntimeError).Token.Line)
+}
+
+func TestInterpreter_CaughtRuntimeErrorProperties(t *testing.T) {
+	result, err := interpretLines(NewInterpreterWrapper(), "try {\n print missing;\n} catch (e) { print e.line + \" \" + e.message; }")
+	assert.Nil(t, err)
+	assert.Equal(t, "2 Variable 'missing' not defined", result)
+}
+
+func TestInterpreter_ImportFromSearchPath(t *testing.T) {
+	w := NewInterpreterWrapper()
+	w.SetSearchPath([]string{programs.GetPath("basic"), programs.GetPath("modules")})
+
+	result, err := interpretLines(w, "import \"Geometry.lox\" as g;", "print g.area(1);", "import \"Geometry.lox\" as h;")
+	assert.Nil(t, err)
+	assert.Equal(t, "loading geometry;3", result)
+}
+
+func TestInterpreter_ReadLine(t *testing.T) {
+	w := NewInterpreterWrapper()
+	w.SetStdin(strings.NewReader("alice\nbob\n"))
+
+	result, err := interpretLines(w,
+		"var line = readLine();",
+		"while (line != nil) { print \"hello \" + line + \";\"; line = readLine(); }",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello alice;hello bob;", result)
+}
+
+func TestInterpreter_MaxDepth(t *testing.T) {
+	w := NewInterpreterWrapper()
+	w.SetMaxDepth(4)
+
+	result, err := interpretLines(w, "fun depth(n) { if (n == 1) return n; return depth(n - 1); }", "print depth(3);")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", result)
+
+	_, err = interpretLines(w, "print depth(4);")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Stack overflow.")
+	assert.Equal(t, []loxerr.StackFrame{
+		{Function: "depth", File: "", Line: 1},
+		{Function: "depth", File: "", Line: 1},
+		{Function: "depth", File: "", Line: 1},
+		{Function: "<script>", File: "", Line: 1},
+	}, err.(*loxerr.LoxRuntimeError).Trace)
+}
+
+func TestInterpreter_MaxDepthIsLimited(t *testing.T) {
+	w := NewInterpreterWrapper()
+	w.SetMaxDepth(MaxDepthLimit * 100)
+
+	_, err := interpretLines(w,
+		"fun depth(n) { if (n == 0) return 0; return 1 + depth(n - 1); }",
+		"print depth("+strconv.Itoa(MaxDepthLimit)+");",
+	)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Stack overflow.")
+}
+
+func TestInterpreter_StackTraceOfRethrownError(t *testing.T) {
+	_, err := interpretLines(NewInterpreterWrapper(),
+		"fun fail() {\n return nil + 1;\n}",
+		"fun retry() {\n try { fail(); } catch (e) { throw e; }\n}",
+		"retry();",
+	)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	// The trace is the one of the original error, not of where it was rethrown.
+	assert.Equal(t, []loxerr.StackFrame{
+		{Function: "fail", File: "", Line: 2},
+		{Function: "retry", File: "", Line: 2},
+		{Function: "<script>", File: "", Line: 1},
+	}, err.(*loxerr.LoxRuntimeError).Trace)
+}
+
+func TestInterpreter_EvaluateLine(t *testing.T) {
+	w := NewInterpreterWrapper()
+	value, err := w.EvaluateLine("var x = 40;")
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+
+	value, err = w.EvaluateLine("x + 2")
+	assert.Nil(t, err)
+	assert.Equal(t, 42.0, value)
+
+	_, err = w.EvaluateLine("x + nil")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	value, err = w.EvaluateLine("fun f() {\n  return x;\n}\nf();")
+	assert.Nil(t, err)
+	assert.Equal(t, 40.0, value)
+
+	_, err = w.EvaluateLine("x + 1 x")
+	assert.Error(t, err)
+}
+
+func TestInterpreter_Globals(t *testing.T) {
+	w := NewInterpreterWrapper()
+	_, err := interpretLines(w, "var x = [1];", "class A {}")
+	assert.Nil(t, err)
+
+	globals := w.Globals()
+	assert.Equal(t, "list", native.TypeName(globals["x"]))
+	assert.Equal(t, "class", native.TypeName(globals["A"]))
+	assert.Equal(t, "function", native.TypeName(globals["clock"]))
+	assert.Equal(t, "module", native.TypeName(globals["math"]))
+
+	value, err := w.EvaluateLine("A()")
+	assert.Nil(t, err)
+	assert.Equal(t, "instance", native.TypeName(value))
+}
+
+func TestInterpreter_ClockIsANumber(t *testing.T) {
+	w := NewInterpreterWrapper()
+	result, err := interpretLines(w, "print clock() + 1 > clock();", "print math.sqrt(clock()) > 0;")
+	assert.Nil(t, err)
+	assert.Equal(t, "truetrue", result)
+}
+
+func TestInterpreter_ClassHasNoInitProperty(t *testing.T) {
+	_, err := interpretLines(NewInterpreterWrapper(), "class A { init() { this.x = 1; } }", "A.init();")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.Equal(t, loxerr.UndefinedProperty, err.(*loxerr.LoxRuntimeError).Code())
+	assert.ErrorContains(t, err, "Property 'init' is not defined on <class A [")
+}
+
+func TestInterpreter_PrintsListsThatContainThemselves(t *testing.T) {
+	result, err := interpretLines(NewInterpreterWrapper(),
+		"var zs = []; zs.push(zs); print zs;",
+		"var xs = []; var ys = [xs]; xs.push(ys); print xs; print ys;",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "[[...]][[[...]]][[[...]]]", result)
+}
+
+func TestInterpreter_PrintsMapsThatContainThemselves(t *testing.T) {
+	result, err := interpretLines(NewInterpreterWrapper(),
+		"var m = {}; m[\"self\"] = m; print m;",
+		"var xs = []; var n = {\"xs\": xs}; xs.push(n); print n; print xs;",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "{self: {...}}{xs: [{...}]}[{xs: [...]}]", result)
+}
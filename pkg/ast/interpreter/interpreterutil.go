@@ -1,9 +1,13 @@
 package interpreter
 
 import (
+	"io"
+	"path/filepath"
+
 	"github.com/kaschnit/golox/pkg/ast"
 	"github.com/kaschnit/golox/pkg/ast/analyzer"
 	"github.com/kaschnit/golox/pkg/ast/astutil"
+	"github.com/kaschnit/golox/pkg/native"
 )
 
 type InterpreterWrapper struct {
@@ -12,12 +16,18 @@ type InterpreterWrapper struct {
 }
 
 func NewInterpreterWrapper() *InterpreterWrapper {
+	analyzer := analyzer.NewAstAnalyzer()
 	return &InterpreterWrapper{
-		analyzer:    analyzer.NewAstAnalyzer(),
-		interpreter: NewAstInterpreter(),
+		analyzer:    analyzer,
+		interpreter: NewAstInterpreter(analyzer),
 	}
 }
 
+// Limit the number of loop iterations each program may run. A budget of 0 means there is no limit.
+func (w *InterpreterWrapper) SetStepBudget(budget int) {
+	w.interpreter.SetStepBudget(budget)
+}
+
 func (w *InterpreterWrapper) visitors() []ast.AstVisitor {
 	return []ast.AstVisitor{
 		w.analyzer,
@@ -25,10 +35,62 @@ func (w *InterpreterWrapper) visitors() []ast.AstVisitor {
 	}
 }
 
-func (w *InterpreterWrapper) InterpretSourceFile(filepath string) error {
-	return astutil.ParseSourceFileAndVisit(filepath, w.visitors()...)
+// Set the maximum depth of nested calls before a stack overflow is reported.
+func (w *InterpreterWrapper) SetMaxDepth(depth int) {
+	w.interpreter.SetMaxDepth(depth)
+}
+
+// Set the directories searched for imported modules that aren't found relative to the importing file.
+func (w *InterpreterWrapper) SetSearchPath(searchPath []string) {
+	w.interpreter.modules.SetSearchPath(searchPath)
+}
+
+// Set the writer that the output of programs is written to.
+func (w *InterpreterWrapper) SetStdout(stdout io.Writer) {
+	w.interpreter.SetStdout(stdout)
+}
+
+// Set the reader that programs read input from.
+func (w *InterpreterWrapper) SetStdin(stdin io.Reader) {
+	w.interpreter.SetStdin(stdin)
+}
+
+// Get the global variables and their values, including the native functions.
+func (w *InterpreterWrapper) Globals() map[string]interface{} {
+	return w.interpreter.Globals()
+}
+
+// Define the native functions of the registry as globals.
+func (w *InterpreterWrapper) Install(registry *native.Registry) {
+	w.interpreter.Install(registry)
+}
+
+func (w *InterpreterWrapper) InterpretSourceFile(path string) error {
+	// Imports in the file are relative to the directory containing it.
+	dir, file := w.interpreter.dir, w.interpreter.file
+	w.interpreter.dir, w.interpreter.file = filepath.Dir(path), path
+	defer func() {
+		w.interpreter.dir, w.interpreter.file = dir, file
+	}()
+
+	return w.interpreter.modules.RunMain(path, func() error {
+		return astutil.ParseSourceFileAndVisit(path, w.visitors()...)
+	})
 }
 
 func (w *InterpreterWrapper) InterpretLine(line string) error {
 	return astutil.ParseLineAndVisit(line, w.visitors()...)
 }
+
+// Run source code entered at a REPL, returning the value of its last statement if it's an expression
+// statement, or nil otherwise. The semicolon after a final expression statement may be left out.
+func (w *InterpreterWrapper) EvaluateLine(line string) (interface{}, error) {
+	programAst, err := astutil.ParseReplInput(line)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.analyzer.VisitProgram(programAst); err != nil {
+		return nil, err
+	}
+	return w.interpreter.VisitProgram(programAst)
+}
@@ -0,0 +1,138 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/kaschnit/golox/pkg/conversion"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
+)
+
+// Runtime representation of a list.
+type LoxList struct {
+	Elements []interface{}
+}
+
+func NewLoxList(elements []interface{}) *LoxList {
+	return &LoxList{Elements: elements}
+}
+
+// Get the element at the index, which is located at the bracket for error reporting.
+func (l *LoxList) Get(bracket *token.Token, indexValue interface{}) (interface{}, error) {
+	index, err := l.toIndex(indexValue, len(l.Elements)-1)
+	if err != nil {
+		return nil, loxerr.RuntimeFrom(bracket, err)
+	}
+	return l.Elements[index], nil
+}
+
+// Set the element at the index, which is located at the bracket for error reporting.
+func (l *LoxList) Set(bracket *token.Token, indexValue interface{}, value interface{}) error {
+	index, err := l.toIndex(indexValue, len(l.Elements)-1)
+	if err != nil {
+		return loxerr.RuntimeFrom(bracket, err)
+	}
+	l.Elements[index] = value
+	return nil
+}
+
+// Get the native method with the given name, bound to the list.
+func (l *LoxList) GetMethod(name *token.Token) (*NativeFunction, error) {
+	switch name.Lexeme {
+	case "push":
+		return NewNativeFunction("push", 1, func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			l.Elements = append(l.Elements, args[0])
+			return nil, nil
+		}), nil
+	case "pop":
+		return NewNativeFunction("pop", 0, func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			if len(l.Elements) == 0 {
+				return nil, loxerr.New(loxerr.EmptyList, "Can't pop from an empty list.")
+			}
+			last := l.Elements[len(l.Elements)-1]
+			l.Elements = l.Elements[:len(l.Elements)-1]
+			return last, nil
+		}), nil
+	case "len":
+		return NewNativeFunction("len", 0, func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			return float64(len(l.Elements)), nil
+		}), nil
+	case "slice":
+		return NewNativeFunction("slice", 2, func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			start, err := l.toIndex(args[0], len(l.Elements))
+			if err != nil {
+				return nil, err
+			}
+			end, err := l.toIndex(args[1], len(l.Elements))
+			if err != nil {
+				return nil, err
+			}
+			if start > end {
+				return nil, loxerr.Errorf(loxerr.InvalidIndex, "Slice start %d is after slice end %d.", start, end)
+			}
+			elements := make([]interface{}, end-start)
+			copy(elements, l.Elements[start:end])
+			return NewLoxList(elements), nil
+		}), nil
+	case "insert":
+		return NewNativeFunction("insert", 2, func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			index, err := l.toIndex(args[0], len(l.Elements))
+			if err != nil {
+				return nil, err
+			}
+			l.Elements = append(l.Elements, nil)
+			copy(l.Elements[index+1:], l.Elements[index:])
+			l.Elements[index] = args[1]
+			return nil, nil
+		}), nil
+	case "remove":
+		return NewNativeFunction("remove", 1, func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			index, err := l.toIndex(args[0], len(l.Elements)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed := l.Elements[index]
+			l.Elements = append(l.Elements[:index], l.Elements[index+1:]...)
+			return removed, nil
+		}), nil
+	default:
+		return nil, loxerr.Runtime(name, loxerr.UndefinedProperty, fmt.Sprintf("Property '%s' is not defined on %s", name.Lexeme, l))
+	}
+}
+
+func (l *LoxList) String() string {
+	return l.StringVisiting(make(map[interface{}]bool))
+}
+
+// Convert the list to a string, where a list that's already being converted is shown as "[...]".
+func (l *LoxList) StringVisiting(visiting map[interface{}]bool) string {
+	if visiting[l] {
+		return "[...]"
+	}
+	visiting[l] = true
+	defer delete(visiting, l)
+
+	elements := make([]string, len(l.Elements))
+	for i, element := range l.Elements {
+		elements[i] = conversion.ToStringVisiting(element, visiting)
+	}
+	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+}
+
+func (l *LoxList) TypeName() string {
+	return "list"
+}
+
+// Convert the value to an index into the list, which must be a whole number from 0 to max.
+func (l *LoxList) toIndex(value interface{}, max int) (int, error) {
+	floatValue, ok := value.(float64)
+	if !ok || floatValue != math.Trunc(floatValue) {
+		return 0, loxerr.Errorf(loxerr.InvalidIndex, "List index must be a whole number, got %v.", value)
+	}
+	if floatValue < 0 || floatValue > float64(max) {
+		return 0, loxerr.Errorf(loxerr.InvalidIndex, "List index %v is out of bounds for list of length %d.", value, len(l.Elements))
+	}
+	return int(floatValue), nil
+}
@@ -0,0 +1,124 @@
+package interpreter
+
+import (
+	"testing"
+
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+	"github.com/stretchr/testify/assert"
+)
+
+var bracketToken = &token.Token{Type: tokentype.LEFT_BRACKET, Lexeme: "[", Line: 1}
+
+func callListMethod(t *testing.T, list *LoxList, name string, args ...interface{}) (interface{}, error) {
+	method, err := list.GetMethod(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: name, Line: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, len(args), method.Arity())
+	return method.Call(nil, args)
+}
+
+func TestLoxList_String(t *testing.T) {
+	assert.Equal(t, "[]", NewLoxList([]interface{}{}).String())
+	assert.Equal(t, "[1, a, true]", NewLoxList([]interface{}{1.0, "a", true}).String())
+
+	nested := NewLoxList([]interface{}{NewLoxList([]interface{}{2.0})})
+	assert.Equal(t, "[[2]]", nested.String())
+}
+
+func TestLoxList_GetAndSet(t *testing.T) {
+	list := NewLoxList([]interface{}{"a", "b"})
+
+	value, err := list.Get(bracketToken, 1.0)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", value)
+
+	err = list.Set(bracketToken, 0.0, "c")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"c", "b"}, list.Elements)
+}
+
+func TestLoxList_GetOutOfBounds(t *testing.T) {
+	list := NewLoxList([]interface{}{"a", "b"})
+	for _, index := range []interface{}{-1.0, 2.0, 0.5, "0", nil, true} {
+		value, err := list.Get(bracketToken, index)
+		assert.Nil(t, value)
+		assert.IsType(t, &loxerr.LoxRuntimeError{}, err, index)
+	}
+
+	err := list.Set(bracketToken, 2.0, "c")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.Equal(t, []interface{}{"a", "b"}, list.Elements)
+}
+
+func TestLoxList_PushPopLen(t *testing.T) {
+	list := NewLoxList([]interface{}{})
+
+	_, err := callListMethod(t, list, "push", 1.0)
+	assert.Nil(t, err)
+	_, err = callListMethod(t, list, "push", 2.0)
+	assert.Nil(t, err)
+
+	length, err := callListMethod(t, list, "len")
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, length)
+
+	last, err := callListMethod(t, list, "pop")
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, last)
+	assert.Equal(t, []interface{}{1.0}, list.Elements)
+
+	_, err = callListMethod(t, list, "pop")
+	assert.Nil(t, err)
+	_, err = callListMethod(t, list, "pop")
+	assert.ErrorContains(t, err, "empty list")
+}
+
+func TestLoxList_Slice(t *testing.T) {
+	list := NewLoxList([]interface{}{1.0, 2.0, 3.0})
+
+	result, err := callListMethod(t, list, "slice", 1.0, 3.0)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{2.0, 3.0}, result.(*LoxList).Elements)
+
+	// The slice is a copy of the elements.
+	result.(*LoxList).Elements[0] = "changed"
+	assert.Equal(t, 2.0, list.Elements[1])
+
+	result, err = callListMethod(t, list, "slice", 3.0, 3.0)
+	assert.Nil(t, err)
+	assert.Empty(t, result.(*LoxList).Elements)
+
+	_, err = callListMethod(t, list, "slice", 2.0, 1.0)
+	assert.Error(t, err)
+	_, err = callListMethod(t, list, "slice", 0.0, 4.0)
+	assert.Error(t, err)
+}
+
+func TestLoxList_InsertRemove(t *testing.T) {
+	list := NewLoxList([]interface{}{1.0, 3.0})
+
+	_, err := callListMethod(t, list, "insert", 1.0, 2.0)
+	assert.Nil(t, err)
+	_, err = callListMethod(t, list, "insert", 3.0, 4.0)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1.0, 2.0, 3.0, 4.0}, list.Elements)
+
+	_, err = callListMethod(t, list, "insert", 5.0, 0.0)
+	assert.Error(t, err)
+
+	removed, err := callListMethod(t, list, "remove", 0.0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, removed)
+	assert.Equal(t, []interface{}{2.0, 3.0, 4.0}, list.Elements)
+
+	_, err = callListMethod(t, list, "remove", 3.0)
+	assert.Error(t, err)
+}
+
+func TestLoxList_UndefinedMethod(t *testing.T) {
+	list := NewLoxList([]interface{}{})
+	method, err := list.GetMethod(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "nope", Line: 1})
+	assert.Nil(t, method)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+}
@@ -0,0 +1,25 @@
+package interpreter
+
+// Break is implemented as an error in the same way as Return, so that it propagates
+// up from the break statement until it is handled by the enclosing WhileStmt.
+type Break struct{}
+
+func NewBreak() *Break {
+	return &Break{}
+}
+
+func (b *Break) Error() string {
+	return "BREAK"
+}
+
+// Continue is implemented as an error in the same way as Return, so that it propagates
+// up from the continue statement until it is handled by the enclosing WhileStmt.
+type Continue struct{}
+
+func NewContinue() *Continue {
+	return &Continue{}
+}
+
+func (c *Continue) Error() string {
+	return "CONTINUE"
+}
@@ -0,0 +1,15 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreak_ErrorString(t *testing.T) {
+	assert.Equal(t, "BREAK", NewBreak().Error())
+}
+
+func TestContinue_ErrorString(t *testing.T) {
+	assert.Equal(t, "CONTINUE", NewContinue().Error())
+}
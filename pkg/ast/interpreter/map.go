@@ -0,0 +1,126 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kaschnit/golox/pkg/conversion"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
+)
+
+// Runtime representation of a map.
+// Numbers, strings, booleans and nil are keyed by value; all other values are keyed by identity.
+// Reading a key that is not in the map is a runtime error, so use has() to check for a key first.
+// Entries are kept in insertion order.
+type LoxMap struct {
+	keys    []interface{}
+	entries map[interface{}]interface{}
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{
+		keys:    []interface{}{},
+		entries: make(map[interface{}]interface{}),
+	}
+}
+
+// Get the value for the key, which is located at the bracket for error reporting.
+func (m *LoxMap) Get(bracket *token.Token, key interface{}) (interface{}, error) {
+	value, ok := m.entries[key]
+	if !ok {
+		return nil, loxerr.Runtime(bracket, loxerr.KeyNotFound, fmt.Sprintf("Key '%s' not found in map.", conversion.ToString(key)))
+	}
+	return value, nil
+}
+
+// Set the value for the key, adding the key to the end of the map if it is new.
+func (m *LoxMap) Set(key interface{}, value interface{}) {
+	if _, ok := m.entries[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = value
+}
+
+// Check whether the key is in the map.
+func (m *LoxMap) Has(key interface{}) bool {
+	_, ok := m.entries[key]
+	return ok
+}
+
+// Delete the key from the map, returning whether the key was in the map.
+func (m *LoxMap) Delete(key interface{}) bool {
+	if _, ok := m.entries[key]; !ok {
+		return false
+	}
+	delete(m.entries, key)
+	for i, existing := range m.keys {
+		if existing == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Get the keys of the map in insertion order.
+func (m *LoxMap) Keys() []interface{} {
+	keys := make([]interface{}, len(m.keys))
+	copy(keys, m.keys)
+	return keys
+}
+
+// Get the native method with the given name, bound to the map.
+func (m *LoxMap) GetMethod(name *token.Token) (*NativeFunction, error) {
+	switch name.Lexeme {
+	case "keys":
+		return NewNativeFunction("keys", 0, func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			return NewLoxList(m.Keys()), nil
+		}), nil
+	case "values":
+		return NewNativeFunction("values", 0, func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			values := make([]interface{}, len(m.keys))
+			for i, key := range m.keys {
+				values[i] = m.entries[key]
+			}
+			return NewLoxList(values), nil
+		}), nil
+	case "has":
+		return NewNativeFunction("has", 1, func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			return m.Has(args[0]), nil
+		}), nil
+	case "delete":
+		return NewNativeFunction("delete", 1, func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			return m.Delete(args[0]), nil
+		}), nil
+	case "len":
+		return NewNativeFunction("len", 0, func(_ *AstInterpreter, args []interface{}) (interface{}, error) {
+			return float64(len(m.keys)), nil
+		}), nil
+	default:
+		return nil, loxerr.Runtime(name, loxerr.UndefinedProperty, fmt.Sprintf("Property '%s' is not defined on %s", name.Lexeme, m))
+	}
+}
+
+func (m *LoxMap) String() string {
+	return m.StringVisiting(make(map[interface{}]bool))
+}
+
+// Convert the map to a string, where a map that's already being converted is shown as "{...}".
+func (m *LoxMap) StringVisiting(visiting map[interface{}]bool) string {
+	if visiting[m] {
+		return "{...}"
+	}
+	visiting[m] = true
+	defer delete(visiting, m)
+
+	entries := make([]string, len(m.keys))
+	for i, key := range m.keys {
+		entries[i] = fmt.Sprintf("%s: %s", conversion.ToString(key), conversion.ToStringVisiting(m.entries[key], visiting))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(entries, ", "))
+}
+
+func (m *LoxMap) TypeName() string {
+	return "map"
+}
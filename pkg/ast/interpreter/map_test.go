@@ -0,0 +1,106 @@
+package interpreter
+
+import (
+	"testing"
+
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+	"github.com/stretchr/testify/assert"
+)
+
+func callMapMethod(t *testing.T, m *LoxMap, name string, args ...interface{}) (interface{}, error) {
+	method, err := m.GetMethod(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: name, Line: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, len(args), method.Arity())
+	return method.Call(nil, args)
+}
+
+func TestLoxMap_String(t *testing.T) {
+	m := NewLoxMap()
+	assert.Equal(t, "{}", m.String())
+
+	m.Set("a", 1.0)
+	m.Set(true, NewLoxList([]interface{}{}))
+	assert.Equal(t, "{a: 1, true: []}", m.String())
+}
+
+func TestLoxMap_GetAndSet(t *testing.T) {
+	m := NewLoxMap()
+	m.Set("a", 1.0)
+	m.Set("a", 2.0)
+
+	value, err := m.Get(bracketToken, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, value)
+	assert.Equal(t, []interface{}{"a"}, m.Keys())
+}
+
+func TestLoxMap_GetMissingKey(t *testing.T) {
+	m := NewLoxMap()
+
+	_, err := m.Get(bracketToken, "missing")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.Equal(t, bracketToken, err.(*loxerr.LoxRuntimeError).Token)
+	assert.ErrorContains(t, err, "Key 'missing' not found in map.")
+}
+
+func TestLoxMap_KeyHashing(t *testing.T) {
+	m := NewLoxMap()
+	first := &LoxClassInstance{}
+	second := &LoxClassInstance{}
+	m.Set(1.0, "number")
+	m.Set("1", "string")
+	m.Set(true, "bool")
+	m.Set(nil, "nil")
+	m.Set(first, "first")
+	m.Set(second, "second")
+
+	for key, expected := range map[interface{}]string{
+		1.0: "number", "1": "string", true: "bool", nil: "nil", first: "first", second: "second",
+	} {
+		value, err := m.Get(bracketToken, key)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, value)
+	}
+	assert.False(t, m.Has(false))
+	assert.False(t, m.Has(&LoxClassInstance{}))
+}
+
+func TestLoxMap_Methods(t *testing.T) {
+	m := NewLoxMap()
+	m.Set("a", 1.0)
+	m.Set("b", 2.0)
+	m.Set("c", 3.0)
+
+	result, err := callMapMethod(t, m, "has", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, true, result)
+
+	result, err = callMapMethod(t, m, "delete", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, true, result)
+
+	result, err = callMapMethod(t, m, "delete", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, false, result)
+
+	result, err = callMapMethod(t, m, "keys")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a", "c"}, result.(*LoxList).Elements)
+
+	result, err = callMapMethod(t, m, "values")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1.0, 3.0}, result.(*LoxList).Elements)
+
+	result, err = callMapMethod(t, m, "len")
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, result)
+}
+
+func TestLoxMap_UndefinedMethod(t *testing.T) {
+	m := NewLoxMap()
+	_, err := m.GetMethod(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "push", Line: 1})
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Property 'push' is not defined on {}")
+}
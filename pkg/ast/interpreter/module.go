@@ -0,0 +1,57 @@
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kaschnit/golox/pkg/ast/interpreter/environment"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
+)
+
+// Runtime representation of an imported module, whose properties are the names it defines at the top level.
+// Properties are read from the module's globals, so they reflect later assignments made by the module.
+type LoxModule struct {
+	name    string
+	globals *environment.Environment
+	exports map[string]bool
+}
+
+func NewLoxModule(name string, globals *environment.Environment, exports []string) *LoxModule {
+	exported := make(map[string]bool)
+	for _, export := range exports {
+		exported[export] = true
+	}
+	return &LoxModule{
+		name:    name,
+		globals: globals,
+		exports: exported,
+	}
+}
+
+// Get the value the module exports with the given name.
+func (m *LoxModule) GetProperty(name *token.Token) (interface{}, error) {
+	value, ok := m.globals.Get(name.Lexeme)
+	if !ok || !m.exports[name.Lexeme] {
+		return nil, loxerr.Runtime(name, loxerr.UndefinedProperty, fmt.Sprintf("Module '%s' has no export '%s'.", m.name, name.Lexeme))
+	}
+	return value, nil
+}
+
+func (m *LoxModule) String() string {
+	return fmt.Sprintf("<module %s>", m.name)
+}
+
+func (m *LoxModule) TypeName() string {
+	return "module"
+}
+
+// Get the names that the module exports, sorted.
+func (m *LoxModule) PropertyNames() []string {
+	names := make([]string, 0, len(m.exports))
+	for name := range m.exports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,35 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/kaschnit/golox/pkg/ast/interpreter/environment"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoxModule_GetProperty(t *testing.T) {
+	globals := environment.NewEnvironment(map[string]interface{}{"a": 1.0, "hidden": 2.0})
+	mod := NewLoxModule("util", globals, []string{"a"})
+
+	value, err := mod.GetProperty(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "a", Line: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, value)
+
+	// Properties reflect the module's globals as they change.
+	globals.Define("a", 3.0)
+	value, err = mod.GetProperty(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "a", Line: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, value)
+
+	_, err = mod.GetProperty(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "hidden", Line: 1})
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Module 'util' has no export 'hidden'.")
+}
+
+func TestLoxModule_String(t *testing.T) {
+	mod := NewLoxModule("util", environment.NewEnvironment(map[string]interface{}{}), []string{})
+	assert.Equal(t, "<module util>", mod.String())
+}
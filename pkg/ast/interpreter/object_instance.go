@@ -2,6 +2,7 @@ package interpreter
 
 import (
 	"fmt"
+	"sort"
 
 	loxerr "github.com/kaschnit/golox/pkg/errors"
 	"github.com/kaschnit/golox/pkg/token"
@@ -24,17 +25,52 @@ func (c *LoxClassInstance) GetProperty(propertyName *token.Token) (interface{},
 		return prop, nil
 	}
 
-	if prop, ok := c.Class.methods[propertyName.Lexeme]; ok {
+	if prop, ok := c.Class.FindMethod(propertyName.Lexeme); ok {
 		return prop.Bind(c), nil
 	}
 
-	return nil, loxerr.Runtime(propertyName, fmt.Sprintf("Property '%s' is not defined on %s", propertyName.Lexeme, c))
+	// Calling "init" directly re-runs the constructor on the instance.
+	if propertyName.Lexeme == "init" {
+		if constructor, ok := c.Class.FindConstructor(); ok {
+			return constructor.Bind(c), nil
+		}
+	}
+
+	return nil, loxerr.Runtime(propertyName, loxerr.UndefinedProperty, fmt.Sprintf("Property '%s' is not defined on %s", propertyName.Lexeme, c))
 }
 
 func (c *LoxClassInstance) SetProperty(propertyName *token.Token, value interface{}) {
 	c.properties[propertyName.Lexeme] = value
 }
 
+// Describe the instance, which is described as its class if it's the instance of a metaclass.
 func (c *LoxClassInstance) String() string {
+	if c.Class.ownerClass != nil {
+		return c.Class.ownerClass.String()
+	}
 	return fmt.Sprintf("<instance of %s [%p]>", c.Class, c)
 }
+
+func (c *LoxClassInstance) TypeName() string {
+	return "instance"
+}
+
+// Get the names of the instance's properties and of the methods of its class and superclasses, sorted.
+func (c *LoxClassInstance) PropertyNames() []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(c.properties))
+	for name := range c.properties {
+		seen[name] = true
+		names = append(names, name)
+	}
+	for cls := c.Class; cls != nil; cls = cls.superclass {
+		for name := range cls.methods {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
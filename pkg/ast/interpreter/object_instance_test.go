@@ -12,7 +12,7 @@ import (
 func TestLoxClassInstance_ToString(t *testing.T) {
 	clsDecl := &ast.ClassStmt{Name: &token.Token{Lexeme: ""}}
 	env := environment.NewEnvironment(make(map[string]interface{}))
-	cls := NewLoxClass(clsDecl, env)
+	cls := NewLoxClass(clsDecl, env, nil, nil)
 	instance := NewLoxClassInstance(cls)
 
 	var result string
@@ -2,6 +2,9 @@ package printer
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/kaschnit/golox/pkg/ast"
 )
@@ -9,11 +12,26 @@ import (
 // Implementation of AstVisitor that prints the visited AST.
 type AstPrinter struct {
 	indent int
+
+	// The writer printed to, or nil to print to stdout.
+	out io.Writer
 }
 
-// Create an AstPrinter.
+// Create an AstPrinter that prints to stdout.
 func NewAstPrinter() *AstPrinter {
-	return &AstPrinter{indent: 0}
+	return &AstPrinter{indent: 0, out: nil}
+}
+
+// Create an AstPrinter that prints to the writer.
+func NewAstPrinterWithWriter(out io.Writer) *AstPrinter {
+	return &AstPrinter{indent: 0, out: out}
+}
+
+func (p *AstPrinter) writer() io.Writer {
+	if p.out == nil {
+		return os.Stdout
+	}
+	return p.out
 }
 
 func (p *AstPrinter) VisitProgram(prg *ast.Program) (interface{}, error) {
@@ -25,33 +43,76 @@ func (p *AstPrinter) VisitProgram(prg *ast.Program) (interface{}, error) {
 
 func (p *AstPrinter) VisitPrintStmt(s *ast.PrintStmt) (interface{}, error) {
 	p.printTabbing()
-	fmt.Print("(print ")
+	fmt.Fprint(p.writer(), "(print ")
 	s.Expression.Accept(p)
-	fmt.Println(");")
+	fmt.Fprintln(p.writer(), ");")
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitReturnStmt(s *ast.ReturnStmt) (interface{}, error) {
 	p.printTabbing()
-	fmt.Print("(return ")
+	fmt.Fprint(p.writer(), "(return ")
 	s.Expression.Accept(p)
-	fmt.Println(");")
+	fmt.Fprintln(p.writer(), ");")
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitBreakStmt(s *ast.BreakStmt) (interface{}, error) {
+	p.printTabbing()
+	fmt.Fprintln(p.writer(), "(break);")
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitContinueStmt(s *ast.ContinueStmt) (interface{}, error) {
+	p.printTabbing()
+	fmt.Fprintln(p.writer(), "(continue);")
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitThrowStmt(s *ast.ThrowStmt) (interface{}, error) {
+	p.printTabbing()
+	fmt.Fprint(p.writer(), "(throw ")
+	s.Value.Accept(p)
+	fmt.Fprintln(p.writer(), ");")
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitTryStmt(s *ast.TryStmt) (interface{}, error) {
+	p.printTabbing()
+	fmt.Fprintln(p.writer(), "try:")
+	p.indent++
+	s.Body.Accept(p)
+	p.indent--
+	if s.CatchBody != nil {
+		p.printTabbing()
+		fmt.Fprintf(p.writer(), "catch (%s):\n", s.CatchName.Lexeme)
+		p.indent++
+		s.CatchBody.Accept(p)
+		p.indent--
+	}
+	if s.FinallyBody != nil {
+		p.printTabbing()
+		fmt.Fprintln(p.writer(), "finally:")
+		p.indent++
+		s.FinallyBody.Accept(p)
+		p.indent--
+	}
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitExprStmt(s *ast.ExprStmt) (interface{}, error) {
 	p.printTabbing()
-	fmt.Print("(")
+	fmt.Fprint(p.writer(), "(")
 	s.Expression.Accept(p)
-	fmt.Println(");")
+	fmt.Fprintln(p.writer(), ");")
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitIfStmt(s *ast.IfStmt) (interface{}, error) {
 	p.printTabbing()
-	fmt.Print("if (condition ")
+	fmt.Fprint(p.writer(), "if (condition ")
 	s.Condition.Accept(p)
-	fmt.Println("):")
+	fmt.Fprintln(p.writer(), "):")
 	p.indent++
 	s.ThenStatement.Accept(p)
 	p.indent--
@@ -65,9 +126,13 @@ func (p *AstPrinter) VisitIfStmt(s *ast.IfStmt) (interface{}, error) {
 
 func (p *AstPrinter) VisitWhileStmt(s *ast.WhileStmt) (interface{}, error) {
 	p.printTabbing()
-	fmt.Print("while (condition ")
+	fmt.Fprint(p.writer(), "while (condition ")
 	s.Condition.Accept(p)
-	fmt.Println("):")
+	if s.Increment != nil {
+		fmt.Fprint(p.writer(), ") (increment ")
+		s.Increment.Accept(p)
+	}
+	fmt.Fprintln(p.writer(), "):")
 	p.indent++
 	s.LoopStatement.Accept(p)
 	p.indent--
@@ -76,20 +141,24 @@ func (p *AstPrinter) VisitWhileStmt(s *ast.WhileStmt) (interface{}, error) {
 
 func (p *AstPrinter) VisitBlockStmt(s *ast.BlockStmt) (interface{}, error) {
 	p.printTabbing()
-	fmt.Println("{")
+	fmt.Fprintln(p.writer(), "{")
 	p.indent++
 	for _, stmt := range s.Statements {
 		stmt.Accept(p)
 	}
 	p.indent--
 	p.printTabbing()
-	fmt.Println("}")
+	fmt.Fprintln(p.writer(), "}")
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitClassStmt(s *ast.ClassStmt) (interface{}, error) {
-	fmt.Printf("(class %s)\n", s.Name.Lexeme)
-	fmt.Println("{")
+	if s.Superclass != nil {
+		fmt.Fprintf(p.writer(), "(class %s < %s)\n", s.Name.Lexeme, s.Superclass.Name.Lexeme)
+	} else {
+		fmt.Fprintf(p.writer(), "(class %s)\n", s.Name.Lexeme)
+	}
+	fmt.Fprintln(p.writer(), "{")
 	p.indent++
 	if s.Constructor != nil {
 		s.Constructor.Accept(p)
@@ -98,115 +167,194 @@ func (p *AstPrinter) VisitClassStmt(s *ast.ClassStmt) (interface{}, error) {
 		stmt.Accept(p)
 	}
 	p.indent--
-	fmt.Println("}")
+	fmt.Fprintln(p.writer(), "}")
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitFunctionStmt(s *ast.FunctionStmt) (interface{}, error) {
 	p.printTabbing()
-	fmt.Printf("(func %s)\n", s.Name.Lexeme)
-	fmt.Println("{")
+	fmt.Fprintf(p.writer(), "(func %s)\n", s.Name.Lexeme)
+	fmt.Fprintln(p.writer(), "{")
 	p.indent++
 	for _, stmt := range s.Body {
 		stmt.Accept(p)
 	}
 	p.indent--
-	fmt.Println("}")
+	fmt.Fprintln(p.writer(), "}")
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitVarStmt(s *ast.VarStmt) (interface{}, error) {
 	p.printTabbing()
-	fmt.Printf("(var %s = ", s.Left.Lexeme)
+	fmt.Fprintf(p.writer(), "(var %s = ", s.Left.Lexeme)
 	if s.Right != nil {
 		s.Right.Accept(p)
 	} else {
-		fmt.Print("nil")
+		fmt.Fprint(p.writer(), "nil")
 	}
-	fmt.Println(");")
+	fmt.Fprintln(p.writer(), ");")
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitImportStmt(s *ast.ImportStmt) (interface{}, error) {
+	p.printTabbing()
+	fmt.Fprintf(p.writer(), "(import %s as %s);\n", s.Path.Lexeme, s.Name.Lexeme)
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitAssignExpr(e *ast.AssignExpr) (interface{}, error) {
-	fmt.Printf("(assign %s ", e.Left.Lexeme)
+	fmt.Fprintf(p.writer(), "(assign %s ", e.Left.Lexeme)
 	e.Right.Accept(p)
-	fmt.Print(")")
+	fmt.Fprint(p.writer(), ")")
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitCallExpr(e *ast.CallExpr) (interface{}, error) {
-	fmt.Print("(call ")
+	fmt.Fprint(p.writer(), "(call ")
 	e.Callee.Accept(p)
-	fmt.Print("(")
+	fmt.Fprint(p.writer(), "(")
 	for i, v := range e.Args {
 		v.Accept(p)
 		if i != len(e.Args)-1 {
-			fmt.Print(", ")
+			fmt.Fprint(p.writer(), ", ")
 		}
 	}
-	fmt.Print("))")
+	fmt.Fprint(p.writer(), "))")
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitBinaryExpr(e *ast.BinaryExpr) (interface{}, error) {
-	fmt.Printf("(%s ", e.Operator.Lexeme)
+	fmt.Fprintf(p.writer(), "(%s ", e.Operator.Lexeme)
 	e.Left.Accept(p)
-	fmt.Print(" ")
+	fmt.Fprint(p.writer(), " ")
 	e.Right.Accept(p)
-	fmt.Print(")")
+	fmt.Fprint(p.writer(), ")")
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitLogicalExpr(e *ast.LogicalExpr) (interface{}, error) {
+	fmt.Fprintf(p.writer(), "(%s ", e.Operator.Lexeme)
+	e.Left.Accept(p)
+	fmt.Fprint(p.writer(), " ")
+	e.Right.Accept(p)
+	fmt.Fprint(p.writer(), ")")
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitUnaryExpr(e *ast.UnaryExpr) (interface{}, error) {
-	fmt.Printf("(%s ", e.Operator.Lexeme)
+	fmt.Fprintf(p.writer(), "(%s ", e.Operator.Lexeme)
 	e.Right.Accept(p)
-	fmt.Print(")")
+	fmt.Fprint(p.writer(), ")")
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitGroupingExpr(e *ast.GroupingExpr) (interface{}, error) {
-	fmt.Print("(group ")
+	fmt.Fprint(p.writer(), "(group ")
 	e.Expression.Accept(p)
-	fmt.Print(")")
+	fmt.Fprint(p.writer(), ")")
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitLiteralExpr(e *ast.LiteralExpr) (interface{}, error) {
 	if e.Value == nil {
-		fmt.Print("nil")
+		fmt.Fprint(p.writer(), "nil")
 	} else if strVal, ok := e.Value.(string); ok {
-		fmt.Printf(`"%s"`, strVal)
+		fmt.Fprintf(p.writer(), `"%s"`, strVal)
 	} else {
-		fmt.Print(e.Value)
+		fmt.Fprint(p.writer(), e.Value)
 	}
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitVarExpr(e *ast.VarExpr) (interface{}, error) {
-	fmt.Printf("(var %s)", e.Name.Lexeme)
+	fmt.Fprintf(p.writer(), "(var %s)", e.Name.Lexeme)
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitGetPropertyExpr(e *ast.GetPropertyExpr) (interface{}, error) {
 	e.ParentObject.Accept(p)
-	fmt.Printf(".%s)", e.Name.Lexeme)
+	fmt.Fprintf(p.writer(), ".%s)", e.Name.Lexeme)
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitSetPropertyExpr(e *ast.SetPropertyExpr) (interface{}, error) {
 	e.ParentObject.Accept(p)
-	fmt.Printf(".%s = ", e.Name.Lexeme)
+	fmt.Fprintf(p.writer(), ".%s = ", e.Name.Lexeme)
+	e.Value.Accept(p)
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitListExpr(e *ast.ListExpr) (interface{}, error) {
+	fmt.Fprint(p.writer(), "[")
+	for i, v := range e.Elements {
+		v.Accept(p)
+		if i != len(e.Elements)-1 {
+			fmt.Fprint(p.writer(), ", ")
+		}
+	}
+	fmt.Fprint(p.writer(), "]")
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitMapExpr(e *ast.MapExpr) (interface{}, error) {
+	fmt.Fprint(p.writer(), "{")
+	for i := range e.Keys {
+		e.Keys[i].Accept(p)
+		fmt.Fprint(p.writer(), ": ")
+		e.Values[i].Accept(p)
+		if i != len(e.Keys)-1 {
+			fmt.Fprint(p.writer(), ", ")
+		}
+	}
+	fmt.Fprint(p.writer(), "}")
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitFunctionExpr(e *ast.FunctionExpr) (interface{}, error) {
+	params := make([]string, len(e.Params))
+	for i, param := range e.Params {
+		params[i] = param.Lexeme
+	}
+	fmt.Fprintf(p.writer(), "(func (%s)\n", strings.Join(params, ", "))
+	fmt.Fprintln(p.writer(), "{")
+	p.indent++
+	for _, stmt := range e.Body {
+		stmt.Accept(p)
+	}
+	p.indent--
+	fmt.Fprint(p.writer(), "})")
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitIndexGetExpr(e *ast.IndexGetExpr) (interface{}, error) {
+	e.Object.Accept(p)
+	fmt.Fprint(p.writer(), "[")
+	e.Index.Accept(p)
+	fmt.Fprint(p.writer(), "]")
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitIndexSetExpr(e *ast.IndexSetExpr) (interface{}, error) {
+	e.Object.Accept(p)
+	fmt.Fprint(p.writer(), "[")
+	e.Index.Accept(p)
+	fmt.Fprint(p.writer(), "] = ")
 	e.Value.Accept(p)
 	return nil, nil
 }
 
 func (p *AstPrinter) VisitThisExpr(e *ast.ThisExpr) (interface{}, error) {
-	fmt.Print("this")
+	fmt.Fprint(p.writer(), "this")
+	return nil, nil
+}
+
+func (p *AstPrinter) VisitSuperExpr(e *ast.SuperExpr) (interface{}, error) {
+	fmt.Fprintf(p.writer(), "super.%s", e.Method.Lexeme)
 	return nil, nil
 }
 
 func (p *AstPrinter) printTabbing() {
 	for i := 0; i < p.indent; i++ {
-		fmt.Print("  ")
+		fmt.Fprint(p.writer(), "  ")
 	}
 }
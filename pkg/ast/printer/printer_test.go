@@ -35,6 +35,12 @@ var starToken = token.Token{
 	Literal: nil,
 	Line:    1,
 }
+var orToken = token.Token{
+	Type:    tokentype.OR,
+	Lexeme:  "or",
+	Literal: nil,
+	Line:    1,
+}
 var plusToken = token.Token{
 	Type:    tokentype.PLUS,
 	Lexeme:  "+",
@@ -200,3 +206,76 @@ func TestAstPrinter_BinaryExprWithSubExpressions(t *testing.T) {
 		binaryExpr.Accept(printer)
 	})
 }
+
+func TestAstPrinter_LogicalExpr(t *testing.T) {
+	printer := NewAstPrinter()
+	verifyPrintedToStdout(t, `(or nil "default")`, func() {
+		leftExpr := ast.LiteralExpr{Value: nil}
+		rightExpr := ast.LiteralExpr{Value: "default"}
+		logicalExpr := ast.LogicalExpr{Left: &leftExpr, Operator: &orToken, Right: &rightExpr}
+		logicalExpr.Accept(printer)
+	})
+}
+
+func TestAstPrinter_TryStmt(t *testing.T) {
+	printer := NewAstPrinter()
+	verifyPrintedToStdout(t, "try:\n  {\n    (throw 1);\n  }\ncatch (e):\n  {\n  }\nfinally:\n  {\n  }\n", func() {
+		tryStmt := ast.TryStmt{
+			Body: &ast.BlockStmt{Statements: []ast.Stmt{
+				&ast.ThrowStmt{Value: &ast.LiteralExpr{Value: 1}},
+			}},
+			CatchName:   &token.Token{Type: tokentype.IDENTIFIER, Lexeme: "e", Line: 1},
+			CatchBody:   &ast.BlockStmt{Statements: []ast.Stmt{}},
+			FinallyBody: &ast.BlockStmt{Statements: []ast.Stmt{}},
+		}
+		tryStmt.Accept(printer)
+	})
+}
+
+func TestAstPrinter_ListAndIndex(t *testing.T) {
+	printer := NewAstPrinter()
+	verifyPrintedToStdout(t, `[1, "a"]`, func() {
+		listExpr := ast.ListExpr{Elements: []ast.Expr{&ast.LiteralExpr{Value: 1}, &ast.LiteralExpr{Value: "a"}}}
+		listExpr.Accept(printer)
+	})
+	verifyPrintedToStdout(t, `[1][0] = 2`, func() {
+		listExpr := ast.ListExpr{Elements: []ast.Expr{&ast.LiteralExpr{Value: 1}}}
+		indexSetExpr := ast.IndexSetExpr{Object: &listExpr, Index: &ast.LiteralExpr{Value: 0}, Value: &ast.LiteralExpr{Value: 2}}
+		indexSetExpr.Accept(printer)
+	})
+}
+
+func TestAstPrinter_FunctionExpr(t *testing.T) {
+	printer := NewAstPrinter()
+	verifyPrintedToStdout(t, "(func (a, b)\n{\n  (return (var a));\n})", func() {
+		a := &token.Token{Type: tokentype.IDENTIFIER, Lexeme: "a", Line: 1}
+		b := &token.Token{Type: tokentype.IDENTIFIER, Lexeme: "b", Line: 1}
+		functionExpr := ast.FunctionExpr{
+			Params: []*token.Token{a, b},
+			Body:   []ast.Stmt{&ast.ReturnStmt{Expression: &ast.VarExpr{Name: a}}},
+		}
+		functionExpr.Accept(printer)
+	})
+}
+
+func TestAstPrinter_ImportStmt(t *testing.T) {
+	printer := NewAstPrinter()
+	verifyPrintedToStdout(t, "(import \"util.lox\" as util);\n", func() {
+		importStmt := ast.ImportStmt{
+			Path: &token.Token{Type: tokentype.STRING, Lexeme: `"util.lox"`, Literal: "util.lox", Line: 1},
+			Name: &token.Token{Type: tokentype.IDENTIFIER, Lexeme: "util", Line: 1},
+		}
+		importStmt.Accept(printer)
+	})
+}
+
+func TestAstPrinter_Map(t *testing.T) {
+	printer := NewAstPrinter()
+	verifyPrintedToStdout(t, `{"a": 1, true: nil}`, func() {
+		mapExpr := ast.MapExpr{
+			Keys:   []ast.Expr{&ast.LiteralExpr{Value: "a"}, &ast.LiteralExpr{Value: true}},
+			Values: []ast.Expr{&ast.LiteralExpr{Value: 1}, &ast.LiteralExpr{Value: nil}},
+		}
+		mapExpr.Accept(printer)
+	})
+}
@@ -0,0 +1,407 @@
+package serialize
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kaschnit/golox/pkg/ast"
+	"github.com/kaschnit/golox/pkg/token"
+)
+
+// Decode a program encoded as JSON by Marshal, producing the same AST that was encoded.
+// Returns an error if a node is missing a field that it needs, or if a literal isn't nil, a bool, a number or a string.
+func Unmarshal(data []byte) (*ast.Program, error) {
+	d := &decoder{}
+	fields := d.object(data)
+	if d.err != nil {
+		return nil, d.err
+	}
+	if kind := d.kind(fields); kind != "Program" {
+		return nil, fmt.Errorf("expected a node of kind 'Program', got '%s'", kind)
+	}
+
+	program := &ast.Program{Statements: d.stmts(fields["statements"])}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return program, nil
+}
+
+// Converts JSON objects back to the nodes of an AST. Decoding stops at the first error,
+// after which the nodes decoded are nil.
+type decoder struct {
+	err error
+}
+
+// Record the error, unless an error has already been found.
+func (d *decoder) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, args...)
+	}
+}
+
+// Get the fields of a JSON object, which are nil if the JSON is null or missing.
+func (d *decoder) object(data json.RawMessage) map[string]json.RawMessage {
+	if d.err != nil || isNull(data) {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		d.fail("invalid node: %w", err)
+		return nil
+	}
+	return fields
+}
+
+// Get the elements of a JSON list, which are nil if the JSON is null or missing.
+func (d *decoder) list(data json.RawMessage) []json.RawMessage {
+	if d.err != nil || isNull(data) {
+		return nil
+	}
+	elements := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(data, &elements); err != nil {
+		d.fail("invalid list of nodes: %w", err)
+		return nil
+	}
+	return elements
+}
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
+
+// Record an error for each of the fields that the node of the kind needs and that are missing or null.
+func (d *decoder) require(kind string, fields map[string]json.RawMessage, keys ...string) {
+	for _, key := range keys {
+		if isNull(fields[key]) {
+			d.fail("expected the field '%s' of a node of kind '%s'", key, kind)
+		}
+	}
+}
+
+// Record an error if an element of a list is null, since lists hold no missing nodes.
+func (d *decoder) requireElement(data json.RawMessage) {
+	if isNull(data) {
+		d.fail("expected a node in the list, got null")
+	}
+}
+
+func (d *decoder) kind(fields map[string]json.RawMessage) string {
+	var kind string
+	if err := json.Unmarshal(fields["kind"], &kind); err != nil {
+		d.fail("expected the kind of the node: %w", err)
+	}
+	return kind
+}
+
+func (d *decoder) token(data json.RawMessage) *token.Token {
+	if d.err != nil || isNull(data) {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		d.fail("invalid token: %w", err)
+		return nil
+	}
+	if isNull(fields["type"]) {
+		d.fail("expected the type of the token")
+		return nil
+	}
+	t := &token.Token{}
+	if err := json.Unmarshal(data, t); err != nil {
+		d.fail("invalid token: %w", err)
+		return nil
+	}
+	if !isLiteral(t.Literal) {
+		d.fail("invalid literal of token '%s': expected nil, a bool, a number or a string", t.Lexeme)
+		return nil
+	}
+	return t
+}
+
+func (d *decoder) tokens(data json.RawMessage) []*token.Token {
+	elements := d.list(data)
+	if elements == nil {
+		return nil
+	}
+	tokens := make([]*token.Token, len(elements))
+	for i, element := range elements {
+		d.requireElement(element)
+		tokens[i] = d.token(element)
+	}
+	return tokens
+}
+
+// Decode a literal value, which is nil, a bool, a number or a string.
+func (d *decoder) value(data json.RawMessage) interface{} {
+	if d.err != nil || isNull(data) {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		d.fail("invalid literal: %w", err)
+		return nil
+	}
+	if !isLiteral(value) {
+		d.fail("invalid literal %s: expected nil, a bool, a number or a string", string(data))
+		return nil
+	}
+	return value
+}
+
+// Check whether the value is one that a literal can have, which JSON decodes numbers of as float64.
+func isLiteral(value interface{}) bool {
+	switch value.(type) {
+	case nil, bool, float64, string:
+		return true
+	default:
+		return false
+	}
+}
+
+// Decode the path of an import statement, which is a string token.
+func (d *decoder) path(data json.RawMessage) *token.Token {
+	path := d.token(data)
+	if path == nil {
+		return nil
+	}
+	if _, ok := path.Literal.(string); !ok {
+		d.fail("expected the path of an import to be a string, got '%s'", path.Lexeme)
+	}
+	return path
+}
+
+func (d *decoder) exprs(data json.RawMessage) []ast.Expr {
+	elements := d.list(data)
+	if elements == nil {
+		return nil
+	}
+	exprs := make([]ast.Expr, len(elements))
+	for i, element := range elements {
+		d.requireElement(element)
+		exprs[i] = d.expr(element)
+	}
+	return exprs
+}
+
+func (d *decoder) stmts(data json.RawMessage) []ast.Stmt {
+	elements := d.list(data)
+	if elements == nil {
+		return nil
+	}
+	stmts := make([]ast.Stmt, len(elements))
+	for i, element := range elements {
+		d.requireElement(element)
+		stmts[i] = d.stmt(element)
+	}
+	return stmts
+}
+
+// Decode a block, which is nil if the block is missing.
+func (d *decoder) block(data json.RawMessage) *ast.BlockStmt {
+	stmt := d.stmt(data)
+	if stmt == nil {
+		return nil
+	}
+	block, ok := stmt.(*ast.BlockStmt)
+	if !ok {
+		d.fail("expected a node of kind 'BlockStmt'")
+	}
+	return block
+}
+
+// Decode a function declaration, which is nil if the function is missing.
+func (d *decoder) function(data json.RawMessage) *ast.FunctionStmt {
+	stmt := d.stmt(data)
+	if stmt == nil {
+		return nil
+	}
+	function, ok := stmt.(*ast.FunctionStmt)
+	if !ok {
+		d.fail("expected a node of kind 'FunctionStmt'")
+	}
+	return function
+}
+
+func (d *decoder) functions(data json.RawMessage) []*ast.FunctionStmt {
+	elements := d.list(data)
+	if elements == nil {
+		return nil
+	}
+	functions := make([]*ast.FunctionStmt, len(elements))
+	for i, element := range elements {
+		d.requireElement(element)
+		functions[i] = d.function(element)
+	}
+	return functions
+}
+
+// Decode a variable, which is nil if the variable is missing, like the superclass of a class without one.
+func (d *decoder) variable(data json.RawMessage) *ast.VarExpr {
+	expr := d.expr(data)
+	if expr == nil {
+		return nil
+	}
+	variable, ok := expr.(*ast.VarExpr)
+	if !ok {
+		d.fail("expected a node of kind 'VarExpr'")
+	}
+	return variable
+}
+
+func (d *decoder) stmt(data json.RawMessage) ast.Stmt {
+	f := d.object(data)
+	if f == nil {
+		return nil
+	}
+
+	switch kind := d.kind(f); kind {
+	case "PrintStmt":
+		d.require(kind, f, "expression")
+		return &ast.PrintStmt{Expression: d.expr(f["expression"])}
+	case "ReturnStmt":
+		d.require(kind, f, "keyword")
+		return &ast.ReturnStmt{Keyword: d.token(f["keyword"]), Expression: d.expr(f["expression"])}
+	case "BreakStmt":
+		d.require(kind, f, "keyword")
+		return &ast.BreakStmt{Keyword: d.token(f["keyword"])}
+	case "ContinueStmt":
+		d.require(kind, f, "keyword")
+		return &ast.ContinueStmt{Keyword: d.token(f["keyword"])}
+	case "ThrowStmt":
+		d.require(kind, f, "keyword", "value")
+		return &ast.ThrowStmt{Keyword: d.token(f["keyword"]), Value: d.expr(f["value"])}
+	case "TryStmt":
+		d.require(kind, f, "keyword", "body")
+		if isNull(f["catchName"]) != isNull(f["catchBody"]) {
+			d.fail("expected both or neither of the fields 'catchName' and 'catchBody' of a node of kind 'TryStmt'")
+		}
+		if isNull(f["catchBody"]) && isNull(f["finallyBody"]) {
+			d.fail("expected the field 'catchBody' or 'finallyBody' of a node of kind 'TryStmt'")
+		}
+		return &ast.TryStmt{
+			Keyword:     d.token(f["keyword"]),
+			Body:        d.block(f["body"]),
+			CatchName:   d.token(f["catchName"]),
+			CatchBody:   d.block(f["catchBody"]),
+			FinallyBody: d.block(f["finallyBody"]),
+		}
+	case "ExprStmt":
+		d.require(kind, f, "expression")
+		return &ast.ExprStmt{Expression: d.expr(f["expression"])}
+	case "IfStmt":
+		d.require(kind, f, "condition", "thenStatement")
+		return &ast.IfStmt{
+			Condition:     d.expr(f["condition"]),
+			ThenStatement: d.stmt(f["thenStatement"]),
+			ElseStatement: d.stmt(f["elseStatement"]),
+		}
+	case "WhileStmt":
+		d.require(kind, f, "keyword", "condition", "loopStatement")
+		return &ast.WhileStmt{
+			Keyword:       d.token(f["keyword"]),
+			Condition:     d.expr(f["condition"]),
+			LoopStatement: d.stmt(f["loopStatement"]),
+			Increment:     d.expr(f["increment"]),
+		}
+	case "BlockStmt":
+		return &ast.BlockStmt{Statements: d.stmts(f["statements"])}
+	case "ClassStmt":
+		d.require(kind, f, "name")
+		return &ast.ClassStmt{
+			Name:          d.token(f["name"]),
+			Superclass:    d.variable(f["superclass"]),
+			Constructor:   d.function(f["constructor"]),
+			Methods:       d.functions(f["methods"]),
+			StaticMethods: d.functions(f["staticMethods"]),
+		}
+	case "FunctionStmt":
+		d.require(kind, f, "name")
+		return &ast.FunctionStmt{Name: d.token(f["name"]), Params: d.tokens(f["params"]), Body: d.stmts(f["body"])}
+	case "VarStmt":
+		d.require(kind, f, "left")
+		return &ast.VarStmt{Left: d.token(f["left"]), Right: d.expr(f["right"])}
+	case "ImportStmt":
+		d.require(kind, f, "keyword", "path", "name")
+		return &ast.ImportStmt{Keyword: d.token(f["keyword"]), Path: d.path(f["path"]), Name: d.token(f["name"])}
+	default:
+		d.fail("unknown statement kind '%s'", kind)
+		return nil
+	}
+}
+
+func (d *decoder) expr(data json.RawMessage) ast.Expr {
+	f := d.object(data)
+	if f == nil {
+		return nil
+	}
+
+	switch kind := d.kind(f); kind {
+	case "AssignExpr":
+		d.require(kind, f, "left", "right")
+		return &ast.AssignExpr{Left: d.token(f["left"]), Right: d.expr(f["right"])}
+	case "CallExpr":
+		d.require(kind, f, "callee", "openParen")
+		return &ast.CallExpr{Callee: d.expr(f["callee"]), OpenParen: d.token(f["openParen"]), Args: d.exprs(f["args"])}
+	case "BinaryExpr":
+		d.require(kind, f, "left", "operator", "right")
+		return &ast.BinaryExpr{Left: d.expr(f["left"]), Operator: d.token(f["operator"]), Right: d.expr(f["right"])}
+	case "LogicalExpr":
+		d.require(kind, f, "left", "operator", "right")
+		return &ast.LogicalExpr{Left: d.expr(f["left"]), Operator: d.token(f["operator"]), Right: d.expr(f["right"])}
+	case "UnaryExpr":
+		d.require(kind, f, "operator", "right")
+		return &ast.UnaryExpr{Operator: d.token(f["operator"]), Right: d.expr(f["right"])}
+	case "GroupingExpr":
+		d.require(kind, f, "expression")
+		return &ast.GroupingExpr{Expression: d.expr(f["expression"])}
+	case "LiteralExpr":
+		return &ast.LiteralExpr{Value: d.value(f["value"])}
+	case "VarExpr":
+		d.require(kind, f, "name")
+		return &ast.VarExpr{Name: d.token(f["name"])}
+	case "GetPropertyExpr":
+		d.require(kind, f, "name", "parentObject")
+		return &ast.GetPropertyExpr{Name: d.token(f["name"]), ParentObject: d.expr(f["parentObject"])}
+	case "SetPropertyExpr":
+		d.require(kind, f, "name", "value", "parentObject")
+		return &ast.SetPropertyExpr{
+			Name:         d.token(f["name"]),
+			Value:        d.expr(f["value"]),
+			ParentObject: d.expr(f["parentObject"]),
+		}
+	case "ListExpr":
+		d.require(kind, f, "openBracket")
+		return &ast.ListExpr{OpenBracket: d.token(f["openBracket"]), Elements: d.exprs(f["elements"])}
+	case "MapExpr":
+		d.require(kind, f, "openBrace")
+		m := &ast.MapExpr{OpenBrace: d.token(f["openBrace"]), Keys: d.exprs(f["keys"]), Values: d.exprs(f["values"])}
+		if len(m.Keys) != len(m.Values) {
+			d.fail("expected as many values as keys in a node of kind 'MapExpr', got %d keys and %d values", len(m.Keys), len(m.Values))
+		}
+		return m
+	case "FunctionExpr":
+		d.require(kind, f, "keyword")
+		return &ast.FunctionExpr{Keyword: d.token(f["keyword"]), Params: d.tokens(f["params"]), Body: d.stmts(f["body"])}
+	case "IndexGetExpr":
+		d.require(kind, f, "object", "openBracket", "index")
+		return &ast.IndexGetExpr{Object: d.expr(f["object"]), OpenBracket: d.token(f["openBracket"]), Index: d.expr(f["index"])}
+	case "IndexSetExpr":
+		d.require(kind, f, "object", "openBracket", "index", "value")
+		return &ast.IndexSetExpr{
+			Object:      d.expr(f["object"]),
+			OpenBracket: d.token(f["openBracket"]),
+			Index:       d.expr(f["index"]),
+			Value:       d.expr(f["value"]),
+		}
+	case "ThisExpr":
+		d.require(kind, f, "keyword")
+		return &ast.ThisExpr{Keyword: d.token(f["keyword"])}
+	case "SuperExpr":
+		d.require(kind, f, "keyword", "method")
+		return &ast.SuperExpr{Keyword: d.token(f["keyword"]), Method: d.token(f["method"])}
+	default:
+		d.fail("unknown expression kind '%s'", kind)
+		return nil
+	}
+}
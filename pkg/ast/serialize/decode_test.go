@@ -0,0 +1,122 @@
+package serialize
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kaschnit/golox/pkg/ast"
+	"github.com/kaschnit/golox/pkg/ast/astutil"
+	"github.com/kaschnit/golox/pkg/parser"
+	"github.com/kaschnit/golox/test/programs"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertRoundTrips(t *testing.T, program *ast.Program) {
+	data, err := Marshal(program)
+	assert.Nil(t, err)
+
+	decoded, err := Unmarshal(data)
+	assert.Nil(t, err)
+	assert.Equal(t, program, decoded)
+
+	again, err := Marshal(decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), string(again))
+}
+
+func TestUnmarshal_RoundTripsTestPrograms(t *testing.T) {
+	root := programs.GetDirectoryPath()
+	count := 0
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".lox" {
+			return err
+		}
+		program, parseErr := parser.ParseSourceFile(path)
+		if parseErr != nil {
+			// Programs that are meant to fail to scan or parse have no AST.
+			return nil
+		}
+
+		count++
+		name, _ := filepath.Rel(root, path)
+		t.Run(name, func(t *testing.T) {
+			assertRoundTrips(t, program)
+		})
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Greater(t, count, 50)
+}
+
+func TestUnmarshal_RoundTripsEveryKindOfNode(t *testing.T) {
+	program, err := astutil.ParseSource(`
+		import "shapes.lox" as shapes;
+		class A < B {
+			init(x) { this.x = x; }
+			get() { return super.get() + this.x; }
+			class make() { return A(1); }
+		}
+		fun f(a, b) { return; }
+		var g = (a) => a * -2;
+		var h = fun () { print "${1} and ${"two"}"; };
+		var xs = [1, true, nil];
+		var m = {"a": 1, "b": xs[0]};
+		xs[1] = !(1 < 2) or false and m.a;
+		m.b = 3;
+		for (var i = 0; i < 3; i = i + 1) {
+			if (i == 1) continue; else if (i == 2) break;
+		}
+		while (false) {}
+		try { throw "oops"; } catch (e) { print e; } finally { print "done"; }
+	`)
+	assert.Nil(t, err)
+	assertRoundTrips(t, program)
+}
+
+func TestUnmarshal_ReportsInvalidJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"not JSON", `{`, "invalid node"},
+		{"not a program", `{"kind": "BlockStmt", "statements": []}`, "expected a node of kind 'Program', got 'BlockStmt'"},
+		{"unknown statement", `{"kind": "Program", "statements": [{"kind": "GotoStmt"}]}`, "unknown statement kind 'GotoStmt'"},
+		{"unknown expression", `{"kind": "Program", "statements": [{"kind": "PrintStmt", "expression": {"kind": "PrintStmt"}}]}`, "unknown expression kind 'PrintStmt'"},
+		{"unknown token type", `{"kind": "Program", "statements": [{"kind": "BreakStmt", "keyword": {"type": "GOTO"}}]}`, "unknown token type 'GOTO'"},
+		{"wrong kind of node", `{"kind": "Program", "statements": [{"kind": "TryStmt", "keyword": {"type": "TRY"}, ` +
+			`"body": {"kind": "BreakStmt", "keyword": {"type": "BREAK"}}, "finallyBody": {"kind": "BlockStmt"}}]}`, "expected a node of kind 'BlockStmt'"},
+		{"missing kind", `{"kind": "Program", "statements": [{}]}`, "expected the kind of the node"},
+		{"missing node", `{"kind": "Program", "statements": [{"kind": "PrintStmt"}]}`, "expected the field 'expression' of a node of kind 'PrintStmt'"},
+		{"null node", `{"kind": "Program", "statements": [{"kind": "ExprStmt", "expression": {"kind": "UnaryExpr", ` +
+			`"operator": {"type": "MINUS"}, "right": null}}]}`, "expected the field 'right' of a node of kind 'UnaryExpr'"},
+		{"null token", `{"kind": "Program", "statements": [{"kind": "ClassStmt", "name": null}]}`, "expected the field 'name' of a node of kind 'ClassStmt'"},
+		{"null in a list", `{"kind": "Program", "statements": [null]}`, "expected a node in the list, got null"},
+		{"token without a type", `{"kind": "Program", "statements": [{"kind": "BreakStmt", "keyword": {"lexeme": "break"}}]}`, "expected the type of the token"},
+		{"try without catch or finally", `{"kind": "Program", "statements": [{"kind": "TryStmt", "keyword": {"type": "TRY"}, ` +
+			`"body": {"kind": "BlockStmt"}}]}`, "expected the field 'catchBody' or 'finallyBody'"},
+		{"catch without a name", `{"kind": "Program", "statements": [{"kind": "TryStmt", "keyword": {"type": "TRY"}, ` +
+			`"body": {"kind": "BlockStmt"}, "catchBody": {"kind": "BlockStmt"}}]}`, "expected both or neither of the fields 'catchName' and 'catchBody'"},
+		{"keys without values", `{"kind": "Program", "statements": [{"kind": "ExprStmt", "expression": {"kind": "MapExpr", ` +
+			`"openBrace": {"type": "LEFT_BRACE"}, "keys": [{"kind": "LiteralExpr", "value": "a"}]}}]}`, "got 1 keys and 0 values"},
+		{"literal of the wrong type", `{"kind": "Program", "statements": [{"kind": "PrintStmt", "expression": {"kind": "LiteralExpr", "value": [1, 2]}}]}`,
+			"invalid literal [1, 2]: expected nil, a bool, a number or a string"},
+		{"token literal of the wrong type", `{"kind": "Program", "statements": [{"kind": "VarStmt", "left": {"type": "IDENTIFIER", ` +
+			`"lexeme": "a", "literal": {}}}]}`, "invalid literal of token 'a'"},
+		{"import path that isn't a string", `{"kind": "Program", "statements": [{"kind": "ImportStmt", "keyword": {"type": "IMPORT"}, ` +
+			`"path": {"type": "NUMBER", "lexeme": "1", "literal": 1}, "name": {"type": "IDENTIFIER", "lexeme": "m"}}]}`,
+			"expected the path of an import to be a string, got '1'"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			program, err := Unmarshal([]byte(tc.data))
+			assert.Nil(t, program)
+			if assert.Error(t, err) {
+				assert.True(t, strings.Contains(err.Error(), tc.expected), err.Error())
+			}
+		})
+	}
+}
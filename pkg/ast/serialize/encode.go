@@ -0,0 +1,318 @@
+// Package serialize encodes ASTs as JSON and decodes them back, so that they can be cached or read by tools.
+//
+// Each node is a JSON object whose "kind" field is the name of the node's type, like "BinaryExpr",
+// followed by the node's fields, named like the fields of the node in lower camel case. Tokens are
+// objects with their type, lexeme, literal and position in the source code. Missing nodes and lists are null.
+// Literal values have no position, since the AST only keeps their value.
+package serialize
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/kaschnit/golox/pkg/ast"
+)
+
+// Encode the program as JSON.
+func Marshal(program *ast.Program) ([]byte, error) {
+	node, err := program.Accept(&encoder{})
+	if err != nil {
+		return nil, err
+	}
+	return marshal(node)
+}
+
+// Encode the program as JSON like Marshal, indenting each field on its own line.
+func MarshalIndent(program *ast.Program, prefix string, indent string) ([]byte, error) {
+	data, err := Marshal(program)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, prefix, indent); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Encode the value as JSON without escaping the characters that are special in HTML, like '<' in "a < b".
+func marshal(value interface{}) ([]byte, error) {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n")), nil
+}
+
+// A field of a JSON object.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// A JSON object whose fields are encoded in order, so that the kind of a node comes first.
+type object []field
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		key, err := marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(key)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// Create the JSON object of a node of the kind, with the fields given as keys followed by their values.
+func node(kind string, keysAndValues ...interface{}) object {
+	o := object{{key: "kind", value: kind}}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		o = append(o, field{key: keysAndValues[i].(string), value: keysAndValues[i+1]})
+	}
+	return o
+}
+
+// Converts the nodes of an AST to JSON objects.
+type encoder struct{}
+
+func (e *encoder) expr(expr ast.Expr) interface{} {
+	if expr == nil {
+		return nil
+	}
+	o, _ := expr.Accept(e)
+	return o
+}
+
+func (e *encoder) exprs(exprs []ast.Expr) []interface{} {
+	if exprs == nil {
+		return nil
+	}
+	objects := make([]interface{}, len(exprs))
+	for i, expr := range exprs {
+		objects[i] = e.expr(expr)
+	}
+	return objects
+}
+
+func (e *encoder) stmt(stmt ast.Stmt) interface{} {
+	if stmt == nil {
+		return nil
+	}
+	o, _ := stmt.Accept(e)
+	return o
+}
+
+func (e *encoder) stmts(stmts []ast.Stmt) []interface{} {
+	if stmts == nil {
+		return nil
+	}
+	objects := make([]interface{}, len(stmts))
+	for i, stmt := range stmts {
+		objects[i] = e.stmt(stmt)
+	}
+	return objects
+}
+
+// Encode a block, which is nil if the block is missing, like the catch block of a try statement without one.
+func (e *encoder) block(block *ast.BlockStmt) interface{} {
+	if block == nil {
+		return nil
+	}
+	return e.stmt(block)
+}
+
+// Encode a function declaration, which is nil if the function is missing, like the constructor of a class without one.
+func (e *encoder) function(function *ast.FunctionStmt) interface{} {
+	if function == nil {
+		return nil
+	}
+	return e.stmt(function)
+}
+
+func (e *encoder) functions(functions []*ast.FunctionStmt) []interface{} {
+	if functions == nil {
+		return nil
+	}
+	objects := make([]interface{}, len(functions))
+	for i, function := range functions {
+		objects[i] = e.function(function)
+	}
+	return objects
+}
+
+func (e *encoder) VisitProgram(program *ast.Program) (interface{}, error) {
+	return node("Program", "statements", e.stmts(program.Statements)), nil
+}
+
+func (e *encoder) VisitPrintStmt(stmt *ast.PrintStmt) (interface{}, error) {
+	return node("PrintStmt", "expression", e.expr(stmt.Expression)), nil
+}
+
+func (e *encoder) VisitReturnStmt(stmt *ast.ReturnStmt) (interface{}, error) {
+	return node("ReturnStmt", "keyword", stmt.Keyword, "expression", e.expr(stmt.Expression)), nil
+}
+
+func (e *encoder) VisitBreakStmt(stmt *ast.BreakStmt) (interface{}, error) {
+	return node("BreakStmt", "keyword", stmt.Keyword), nil
+}
+
+func (e *encoder) VisitContinueStmt(stmt *ast.ContinueStmt) (interface{}, error) {
+	return node("ContinueStmt", "keyword", stmt.Keyword), nil
+}
+
+func (e *encoder) VisitThrowStmt(stmt *ast.ThrowStmt) (interface{}, error) {
+	return node("ThrowStmt", "keyword", stmt.Keyword, "value", e.expr(stmt.Value)), nil
+}
+
+func (e *encoder) VisitTryStmt(stmt *ast.TryStmt) (interface{}, error) {
+	return node("TryStmt",
+		"keyword", stmt.Keyword,
+		"body", e.block(stmt.Body),
+		"catchName", stmt.CatchName,
+		"catchBody", e.block(stmt.CatchBody),
+		"finallyBody", e.block(stmt.FinallyBody),
+	), nil
+}
+
+func (e *encoder) VisitExprStmt(stmt *ast.ExprStmt) (interface{}, error) {
+	return node("ExprStmt", "expression", e.expr(stmt.Expression)), nil
+}
+
+func (e *encoder) VisitIfStmt(stmt *ast.IfStmt) (interface{}, error) {
+	return node("IfStmt",
+		"condition", e.expr(stmt.Condition),
+		"thenStatement", e.stmt(stmt.ThenStatement),
+		"elseStatement", e.stmt(stmt.ElseStatement),
+	), nil
+}
+
+func (e *encoder) VisitWhileStmt(stmt *ast.WhileStmt) (interface{}, error) {
+	return node("WhileStmt",
+		"keyword", stmt.Keyword,
+		"condition", e.expr(stmt.Condition),
+		"loopStatement", e.stmt(stmt.LoopStatement),
+		"increment", e.expr(stmt.Increment),
+	), nil
+}
+
+func (e *encoder) VisitBlockStmt(stmt *ast.BlockStmt) (interface{}, error) {
+	return node("BlockStmt", "statements", e.stmts(stmt.Statements)), nil
+}
+
+func (e *encoder) VisitClassStmt(stmt *ast.ClassStmt) (interface{}, error) {
+	var superclass interface{}
+	if stmt.Superclass != nil {
+		superclass = e.expr(stmt.Superclass)
+	}
+	return node("ClassStmt",
+		"name", stmt.Name,
+		"superclass", superclass,
+		"constructor", e.function(stmt.Constructor),
+		"methods", e.functions(stmt.Methods),
+		"staticMethods", e.functions(stmt.StaticMethods),
+	), nil
+}
+
+func (e *encoder) VisitFunctionStmt(stmt *ast.FunctionStmt) (interface{}, error) {
+	return node("FunctionStmt", "name", stmt.Name, "params", stmt.Params, "body", e.stmts(stmt.Body)), nil
+}
+
+func (e *encoder) VisitVarStmt(stmt *ast.VarStmt) (interface{}, error) {
+	return node("VarStmt", "left", stmt.Left, "right", e.expr(stmt.Right)), nil
+}
+
+func (e *encoder) VisitImportStmt(stmt *ast.ImportStmt) (interface{}, error) {
+	return node("ImportStmt", "keyword", stmt.Keyword, "path", stmt.Path, "name", stmt.Name), nil
+}
+
+func (e *encoder) VisitAssignExpr(expr *ast.AssignExpr) (interface{}, error) {
+	return node("AssignExpr", "left", expr.Left, "right", e.expr(expr.Right)), nil
+}
+
+func (e *encoder) VisitCallExpr(expr *ast.CallExpr) (interface{}, error) {
+	return node("CallExpr", "callee", e.expr(expr.Callee), "openParen", expr.OpenParen, "args", e.exprs(expr.Args)), nil
+}
+
+func (e *encoder) VisitBinaryExpr(expr *ast.BinaryExpr) (interface{}, error) {
+	return node("BinaryExpr", "left", e.expr(expr.Left), "operator", expr.Operator, "right", e.expr(expr.Right)), nil
+}
+
+func (e *encoder) VisitLogicalExpr(expr *ast.LogicalExpr) (interface{}, error) {
+	return node("LogicalExpr", "left", e.expr(expr.Left), "operator", expr.Operator, "right", e.expr(expr.Right)), nil
+}
+
+func (e *encoder) VisitUnaryExpr(expr *ast.UnaryExpr) (interface{}, error) {
+	return node("UnaryExpr", "operator", expr.Operator, "right", e.expr(expr.Right)), nil
+}
+
+func (e *encoder) VisitGroupingExpr(expr *ast.GroupingExpr) (interface{}, error) {
+	return node("GroupingExpr", "expression", e.expr(expr.Expression)), nil
+}
+
+func (e *encoder) VisitLiteralExpr(expr *ast.LiteralExpr) (interface{}, error) {
+	return node("LiteralExpr", "value", expr.Value), nil
+}
+
+func (e *encoder) VisitVarExpr(expr *ast.VarExpr) (interface{}, error) {
+	return node("VarExpr", "name", expr.Name), nil
+}
+
+func (e *encoder) VisitGetPropertyExpr(expr *ast.GetPropertyExpr) (interface{}, error) {
+	return node("GetPropertyExpr", "name", expr.Name, "parentObject", e.expr(expr.ParentObject)), nil
+}
+
+func (e *encoder) VisitSetPropertyExpr(expr *ast.SetPropertyExpr) (interface{}, error) {
+	return node("SetPropertyExpr",
+		"name", expr.Name,
+		"value", e.expr(expr.Value),
+		"parentObject", e.expr(expr.ParentObject),
+	), nil
+}
+
+func (e *encoder) VisitListExpr(expr *ast.ListExpr) (interface{}, error) {
+	return node("ListExpr", "openBracket", expr.OpenBracket, "elements", e.exprs(expr.Elements)), nil
+}
+
+func (e *encoder) VisitMapExpr(expr *ast.MapExpr) (interface{}, error) {
+	return node("MapExpr", "openBrace", expr.OpenBrace, "keys", e.exprs(expr.Keys), "values", e.exprs(expr.Values)), nil
+}
+
+func (e *encoder) VisitFunctionExpr(expr *ast.FunctionExpr) (interface{}, error) {
+	return node("FunctionExpr", "keyword", expr.Keyword, "params", expr.Params, "body", e.stmts(expr.Body)), nil
+}
+
+func (e *encoder) VisitIndexGetExpr(expr *ast.IndexGetExpr) (interface{}, error) {
+	return node("IndexGetExpr", "object", e.expr(expr.Object), "openBracket", expr.OpenBracket, "index", e.expr(expr.Index)), nil
+}
+
+func (e *encoder) VisitIndexSetExpr(expr *ast.IndexSetExpr) (interface{}, error) {
+	return node("IndexSetExpr",
+		"object", e.expr(expr.Object),
+		"openBracket", expr.OpenBracket,
+		"index", e.expr(expr.Index),
+		"value", e.expr(expr.Value),
+	), nil
+}
+
+func (e *encoder) VisitThisExpr(expr *ast.ThisExpr) (interface{}, error) {
+	return node("ThisExpr", "keyword", expr.Keyword), nil
+}
+
+func (e *encoder) VisitSuperExpr(expr *ast.SuperExpr) (interface{}, error) {
+	return node("SuperExpr", "keyword", expr.Keyword, "method", expr.Method), nil
+}
@@ -0,0 +1,58 @@
+package serialize
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kaschnit/golox/pkg/ast/astutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal(t *testing.T) {
+	program, err := astutil.ParseSource("print -a < 1;")
+	assert.Nil(t, err)
+
+	data, err := Marshal(program)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"kind":"Program","statements":[{"kind":"PrintStmt","expression":{"kind":"BinaryExpr",`+
+		`"left":{"kind":"UnaryExpr","operator":{"type":"MINUS","lexeme":"-","literal":null,"line":1,"column":7,"offset":6,"length":1},`+
+		`"right":{"kind":"VarExpr","name":{"type":"IDENTIFIER","lexeme":"a","literal":null,"line":1,"column":8,"offset":7,"length":1}}},`+
+		`"operator":{"type":"LESS","lexeme":"<","literal":null,"line":1,"column":10,"offset":9,"length":1},`+
+		`"right":{"kind":"LiteralExpr","value":1}}}]}`, string(data))
+}
+
+func TestMarshal_EncodesMissingNodesAsNull(t *testing.T) {
+	program, err := astutil.ParseSource("class A { f() { return; } } try { } finally { }")
+	assert.Nil(t, err)
+
+	data, err := Marshal(program)
+	assert.Nil(t, err)
+	var decoded map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+
+	statements := decoded["statements"].([]interface{})
+	class := statements[0].(map[string]interface{})
+	assert.Equal(t, "ClassStmt", class["kind"])
+	assert.Nil(t, class["superclass"])
+	assert.Nil(t, class["constructor"])
+	assert.Equal(t, []interface{}{}, class["staticMethods"])
+	method := class["methods"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{}, method["params"])
+	assert.Nil(t, method["body"].([]interface{})[0].(map[string]interface{})["expression"])
+
+	try := statements[1].(map[string]interface{})
+	assert.Equal(t, "TryStmt", try["kind"])
+	assert.Nil(t, try["catchName"])
+	assert.Nil(t, try["catchBody"])
+	assert.Equal(t, "BlockStmt", try["finallyBody"].(map[string]interface{})["kind"])
+}
+
+func TestMarshalIndent(t *testing.T) {
+	program, err := astutil.ParseSource("nil;")
+	assert.Nil(t, err)
+
+	data, err := MarshalIndent(program, "", "  ")
+	assert.Nil(t, err)
+	assert.Equal(t, "{\n  \"kind\": \"Program\",\n  \"statements\": [\n    {\n      \"kind\": \"ExprStmt\",\n"+
+		"      \"expression\": {\n        \"kind\": \"LiteralExpr\",\n        \"value\": null\n      }\n    }\n  ]\n}", string(data))
+}
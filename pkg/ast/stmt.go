@@ -26,6 +26,48 @@ func (s *ReturnStmt) Accept(v AstVisitor) (interface{}, error) {
 	return v.VisitReturnStmt(s)
 }
 
+// Represents a break statement AST node.
+type BreakStmt struct {
+	Keyword *token.Token
+}
+
+func (s *BreakStmt) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitBreakStmt(s)
+}
+
+// Represents a continue statement AST node.
+type ContinueStmt struct {
+	Keyword *token.Token
+}
+
+func (s *ContinueStmt) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitContinueStmt(s)
+}
+
+// Represents a throw statement AST node.
+type ThrowStmt struct {
+	Keyword *token.Token
+	Value   Expr
+}
+
+func (s *ThrowStmt) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitThrowStmt(s)
+}
+
+// Represents a try statement AST node, e.g. "try { ... } catch (e) { ... } finally { ... }".
+// At least one of the catch and finally blocks is present; the other is nil.
+type TryStmt struct {
+	Keyword     *token.Token
+	Body        *BlockStmt
+	CatchName   *token.Token
+	CatchBody   *BlockStmt
+	FinallyBody *BlockStmt
+}
+
+func (s *TryStmt) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitTryStmt(s)
+}
+
 // Represents an expression statement AST node.
 type ExprStmt struct {
 	Expression Expr
@@ -48,8 +90,14 @@ func (s *IfStmt) Accept(v AstVisitor) (interface{}, error) {
 
 // Represents a while loop AST node.
 type WhileStmt struct {
+	// The 'while' or 'for' keyword that started the loop.
+	Keyword       *token.Token
 	Condition     Expr
 	LoopStatement Stmt
+
+	// The expression evaluated after each iteration of a desugared for loop, including
+	// iterations ended by 'continue'. Nil for while loops.
+	Increment Expr
 }
 
 func (s *WhileStmt) Accept(v AstVisitor) (interface{}, error) {
@@ -68,6 +116,7 @@ func (s *BlockStmt) Accept(v AstVisitor) (interface{}, error) {
 // Represents a class declaration statement AST node.
 type ClassStmt struct {
 	Name          *token.Token
+	Superclass    *VarExpr
 	Constructor   *FunctionStmt
 	Methods       []*FunctionStmt
 	StaticMethods []*FunctionStmt
@@ -97,3 +146,15 @@ type VarStmt struct {
 func (s *VarStmt) Accept(v AstVisitor) (interface{}, error) {
 	return v.VisitVarStmt(s)
 }
+
+// Represents an import statement AST node, e.g. "import "util.lox" as util;".
+// The path is the STRING token naming the imported file.
+type ImportStmt struct {
+	Keyword *token.Token
+	Path    *token.Token
+	Name    *token.Token
+}
+
+func (s *ImportStmt) Accept(v AstVisitor) (interface{}, error) {
+	return v.VisitImportStmt(s)
+}
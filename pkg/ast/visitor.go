@@ -6,6 +6,10 @@ type AstVisitor interface {
 	VisitProgram(*Program) (interface{}, error)
 	VisitPrintStmt(*PrintStmt) (interface{}, error)
 	VisitReturnStmt(*ReturnStmt) (interface{}, error)
+	VisitBreakStmt(*BreakStmt) (interface{}, error)
+	VisitContinueStmt(*ContinueStmt) (interface{}, error)
+	VisitThrowStmt(*ThrowStmt) (interface{}, error)
+	VisitTryStmt(*TryStmt) (interface{}, error)
 	VisitExprStmt(*ExprStmt) (interface{}, error)
 	VisitIfStmt(*IfStmt) (interface{}, error)
 	VisitWhileStmt(*WhileStmt) (interface{}, error)
@@ -15,12 +19,20 @@ type AstVisitor interface {
 	VisitClassStmt(*ClassStmt) (interface{}, error)
 	VisitFunctionStmt(*FunctionStmt) (interface{}, error)
 	VisitVarStmt(*VarStmt) (interface{}, error)
+	VisitImportStmt(*ImportStmt) (interface{}, error)
 	VisitBinaryExpr(*BinaryExpr) (interface{}, error)
+	VisitLogicalExpr(*LogicalExpr) (interface{}, error)
 	VisitUnaryExpr(*UnaryExpr) (interface{}, error)
 	VisitGroupingExpr(*GroupingExpr) (interface{}, error)
 	VisitLiteralExpr(*LiteralExpr) (interface{}, error)
 	VisitVarExpr(*VarExpr) (interface{}, error)
 	VisitGetPropertyExpr(*GetPropertyExpr) (interface{}, error)
 	VisitSetPropertyExpr(*SetPropertyExpr) (interface{}, error)
+	VisitListExpr(*ListExpr) (interface{}, error)
+	VisitMapExpr(*MapExpr) (interface{}, error)
+	VisitFunctionExpr(*FunctionExpr) (interface{}, error)
+	VisitIndexGetExpr(*IndexGetExpr) (interface{}, error)
+	VisitIndexSetExpr(*IndexSetExpr) (interface{}, error)
 	VisitThisExpr(*ThisExpr) (interface{}, error)
+	VisitSuperExpr(*SuperExpr) (interface{}, error)
 }
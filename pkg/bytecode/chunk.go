@@ -0,0 +1,74 @@
+package bytecode
+
+import (
+	"github.com/kaschnit/golox/pkg/token"
+)
+
+// The maximum number of constants that can be referenced by a 2-byte operand.
+const MaxConstants = 1 << 16
+
+// A sequence of bytecode instructions along with the constants they reference.
+type Chunk struct {
+	// The encoded instructions and their operands.
+	Code []byte
+
+	// The source token that produced each byte in Code, used for error reporting.
+	Tokens []*token.Token
+
+	// The constants table referenced by index from instructions.
+	Constants []interface{}
+}
+
+// Create an empty Chunk.
+func NewChunk() *Chunk {
+	return &Chunk{
+		Code:      make([]byte, 0),
+		Tokens:    make([]*token.Token, 0),
+		Constants: make([]interface{}, 0),
+	}
+}
+
+// Append a byte to the chunk, recording the token it originated from.
+func (c *Chunk) Write(b byte, t *token.Token) {
+	c.Code = append(c.Code, b)
+	c.Tokens = append(c.Tokens, t)
+}
+
+// Append an opcode to the chunk, recording the token it originated from.
+func (c *Chunk) WriteOp(op OpCode, t *token.Token) {
+	c.Write(byte(op), t)
+}
+
+// Append a big-endian 2-byte operand to the chunk.
+func (c *Chunk) WriteShort(value uint16, t *token.Token) {
+	c.Write(byte(value>>8), t)
+	c.Write(byte(value), t)
+}
+
+// Read the big-endian 2-byte operand starting at offset.
+func (c *Chunk) ReadShort(offset int) uint16 {
+	return uint16(c.Code[offset])<<8 | uint16(c.Code[offset+1])
+}
+
+// Add a value to the constants table, returning its index.
+// Identical numbers and strings share a single entry.
+func (c *Chunk) AddConstant(value interface{}) int {
+	switch value.(type) {
+	case float64, string:
+		for i, existing := range c.Constants {
+			if existing == value {
+				return i
+			}
+		}
+	}
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
+
+// Get the source line of the instruction at offset.
+func (c *Chunk) Line(offset int) int {
+	if offset < 0 || offset >= len(c.Tokens) || c.Tokens[offset] == nil {
+		return 0
+	}
+	return c.Tokens[offset].Line
+}
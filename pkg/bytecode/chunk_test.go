@@ -0,0 +1,43 @@
+package bytecode
+
+import (
+	"testing"
+
+	"github.com/kaschnit/golox/pkg/token"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunk_WriteRecordsTokens(t *testing.T) {
+	first := &token.Token{Type: tokentype.PLUS, Lexeme: "+", Line: 3}
+	second := &token.Token{Type: tokentype.MINUS, Lexeme: "-", Line: 7}
+
+	chunk := NewChunk()
+	chunk.WriteOp(OP_ADD, first)
+	chunk.WriteShort(0x1234, second)
+
+	assert.Equal(t, []byte{byte(OP_ADD), 0x12, 0x34}, chunk.Code)
+	assert.Equal(t, 3, chunk.Line(0))
+	assert.Equal(t, 7, chunk.Line(1))
+	assert.Equal(t, 7, chunk.Line(2))
+	assert.Equal(t, 0, chunk.Line(3))
+	assert.Equal(t, uint16(0x1234), chunk.ReadShort(1))
+}
+
+func TestChunk_AddConstantDeduplicatesNumbersAndStrings(t *testing.T) {
+	chunk := NewChunk()
+	assert.Equal(t, 0, chunk.AddConstant(1.5))
+	assert.Equal(t, 1, chunk.AddConstant("name"))
+	assert.Equal(t, 0, chunk.AddConstant(1.5))
+	assert.Equal(t, 1, chunk.AddConstant("name"))
+	assert.Equal(t, 2, chunk.AddConstant(2.0))
+
+	function := NewFunction("f")
+	assert.Equal(t, 3, chunk.AddConstant(function))
+	assert.Equal(t, 4, chunk.AddConstant(NewFunction("f")))
+}
+
+func TestFunction_String(t *testing.T) {
+	assert.Equal(t, "<script>", NewFunction("").String())
+	assert.Contains(t, NewFunction("myFunc").String(), "<function myFunc [")
+}
@@ -0,0 +1,67 @@
+package bytecode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Produce a human-readable listing of every instruction in the chunk.
+func Disassemble(c *Chunk, name string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "== %s ==\n", name)
+	for offset := 0; offset < len(c.Code); {
+		offset = DisassembleInstruction(&sb, c, offset)
+	}
+	return sb.String()
+}
+
+// Write a human-readable form of the instruction at offset to sb,
+// returning the offset of the next instruction.
+func DisassembleInstruction(sb *strings.Builder, c *Chunk, offset int) int {
+	fmt.Fprintf(sb, "%04d ", offset)
+	if offset > 0 && c.Line(offset) == c.Line(offset-1) {
+		sb.WriteString("   | ")
+	} else {
+		fmt.Fprintf(sb, "%4d ", c.Line(offset))
+	}
+
+	op := OpCode(c.Code[offset])
+	switch op {
+	case OP_CONSTANT, OP_GET_GLOBAL, OP_DEFINE_GLOBAL, OP_SET_GLOBAL,
+		OP_GET_PROPERTY, OP_SET_PROPERTY, OP_CLASS, OP_METHOD, OP_STATIC_METHOD, OP_GET_SUPER, OP_IMPORT:
+		index := c.ReadShort(offset + 1)
+		fmt.Fprintf(sb, "%-16s %4d '%v'\n", op, index, c.Constants[index])
+		return offset + 3
+	case OP_GET_LOCAL, OP_SET_LOCAL, OP_GET_UPVALUE, OP_SET_UPVALUE, OP_CALL:
+		fmt.Fprintf(sb, "%-16s %4d\n", op, c.Code[offset+1])
+		return offset + 2
+	case OP_LIST, OP_MAP:
+		fmt.Fprintf(sb, "%-16s %4d\n", op, c.ReadShort(offset+1))
+		return offset + 3
+	case OP_JUMP, OP_JUMP_IF_FALSE, OP_TRY:
+		jump := int(c.ReadShort(offset + 1))
+		fmt.Fprintf(sb, "%-16s %4d -> %d\n", op, offset, offset+3+jump)
+		return offset + 3
+	case OP_LOOP:
+		jump := int(c.ReadShort(offset + 1))
+		fmt.Fprintf(sb, "%-16s %4d -> %d\n", op, offset, offset+3-jump)
+		return offset + 3
+	case OP_CLOSURE:
+		index := c.ReadShort(offset + 1)
+		function := c.Constants[index].(*Function)
+		fmt.Fprintf(sb, "%-16s %4d %v\n", op, index, function)
+		offset += 3
+		for i := 0; i < function.UpvalueCount; i++ {
+			kind := "upvalue"
+			if c.Code[offset] == 1 {
+				kind = "local"
+			}
+			fmt.Fprintf(sb, "%04d    |                     %s %d\n", offset, kind, c.Code[offset+1])
+			offset += 2
+		}
+		return offset
+	default:
+		fmt.Fprintf(sb, "%s\n", op)
+		return offset + 1
+	}
+}
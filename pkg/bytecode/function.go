@@ -0,0 +1,35 @@
+package bytecode
+
+import "fmt"
+
+// Compile-time representation of a function, including the top-level script.
+type Function struct {
+	// The name of the function, or empty for the top-level script.
+	Name string
+
+	// The number of parameters the function declares.
+	Arity int
+
+	// The number of variables from enclosing functions that this function captures.
+	UpvalueCount int
+
+	// The compiled body of the function.
+	Chunk *Chunk
+}
+
+// Create a Function with an empty chunk.
+func NewFunction(name string) *Function {
+	return &Function{
+		Name:         name,
+		Arity:        0,
+		UpvalueCount: 0,
+		Chunk:        NewChunk(),
+	}
+}
+
+func (f *Function) String() string {
+	if f.Name == "" {
+		return "<script>"
+	}
+	return fmt.Sprintf("<function %s [%p]>", f.Name, f)
+}
@@ -0,0 +1,91 @@
+package bytecode
+
+type OpCode byte
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=OpCode -output opcode_string.generated.go
+
+const (
+	// Push the constant at the 2-byte constant index operand.
+	OP_CONSTANT OpCode = iota
+	OP_NIL
+	OP_TRUE
+	OP_FALSE
+	OP_POP
+
+	// Locals and upvalues take a 1-byte slot index operand.
+	OP_GET_LOCAL
+	OP_SET_LOCAL
+	OP_GET_UPVALUE
+	OP_SET_UPVALUE
+
+	// Globals and properties take a 2-byte constant index operand holding the name.
+	OP_GET_GLOBAL
+	OP_DEFINE_GLOBAL
+	OP_SET_GLOBAL
+	OP_GET_PROPERTY
+	OP_SET_PROPERTY
+
+	OP_EQUAL
+	OP_NOT_EQUAL
+	OP_GREATER
+	OP_GREATER_EQUAL
+	OP_LESS
+	OP_LESS_EQUAL
+	OP_ADD
+	OP_SUBTRACT
+	OP_MULTIPLY
+	OP_DIVIDE
+	OP_NOT
+	OP_NEGATE
+	OP_PRINT
+
+	// Jumps take a 2-byte offset operand.
+	OP_JUMP
+	OP_JUMP_IF_FALSE
+	OP_LOOP
+
+	// Call takes a 1-byte arg count operand.
+	OP_CALL
+
+	// Closure takes a 2-byte constant index operand holding the function, followed by
+	// a pair of (isLocal, index) bytes for each upvalue the function captures.
+	OP_CLOSURE
+	OP_CLOSE_UPVALUE
+	OP_RETURN
+
+	// Class declarations take a 2-byte constant index operand holding the name.
+	OP_CLASS
+	OP_METHOD
+	OP_STATIC_METHOD
+
+	// Copy the methods of the superclass below the class on top of the stack into the class.
+	OP_INHERIT
+
+	// Super method access takes a 2-byte constant index operand holding the method name.
+	OP_GET_SUPER
+
+	// Create a list from the elements on top of the stack, taking a 2-byte element count operand.
+	OP_LIST
+
+	// Create a map from the key-value pairs on top of the stack, taking a 2-byte pair count operand.
+	OP_MAP
+	OP_INDEX_GET
+	OP_INDEX_SET
+
+	// Install an exception handler, taking a 2-byte forward jump operand to the handler's code.
+	// When a value is thrown, the stack is unwound to where it was when the handler was installed,
+	// and the handler's code runs with the exception pushed.
+	OP_TRY
+
+	// Remove the most recently installed exception handler.
+	OP_END_TRY
+
+	// Replace the exception on top of the stack with the value that was thrown.
+	OP_CATCH
+
+	// Throw the value on top of the stack, or rethrow it if it is an exception.
+	OP_THROW
+
+	// Push the module imported from the path in the 2-byte constant index operand.
+	OP_IMPORT
+)
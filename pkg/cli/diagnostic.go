@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/go-multierror"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+)
+
+// The format that errors are reported in.
+type DiagnosticsFormat string
+
+const (
+	// Errors are described for people, along with the source code they occurred at.
+	DiagnosticsText DiagnosticsFormat = "text"
+
+	// Errors are described for tools, with one JSON object per line.
+	DiagnosticsJSON DiagnosticsFormat = "json"
+)
+
+// The usage of command line flags that set the diagnostics format.
+const DiagnosticsFormatUsage = "The format that errors are reported in. One of: 'text', 'json'.\n" +
+	"The command exits with code 65, 66, 67 or 70 if the source code fails to scan, parse, analyze or run."
+
+// Get the diagnostics format with the given name.
+func ParseDiagnosticsFormat(name string) (DiagnosticsFormat, error) {
+	switch format := DiagnosticsFormat(name); format {
+	case DiagnosticsText, DiagnosticsJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown diagnostics format '%s', expected one of: '%s', '%s'",
+			name, DiagnosticsText, DiagnosticsJSON)
+	}
+}
+
+// The exit codes of commands that fail with an error in each stage.
+var stageExitCodes = map[loxerr.Stage]int{
+	loxerr.StageScan:    65,
+	loxerr.StageParse:   66,
+	loxerr.StageAnalyze: 67,
+	loxerr.StageRuntime: 70,
+}
+
+// An error that has already been reported, which ends the command with the exit code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Get the exit code of a command that failed with the error, which depends on the stage the error occurred in.
+// The first error of a multierror decides the exit code.
+func ExitCode(err error) int {
+	if multi, ok := err.(*multierror.Error); ok && len(multi.Errors) > 0 {
+		err = multi.Errors[0]
+	}
+	var moduleErr *loxerr.ModuleError
+	if errors.As(err, &moduleErr) {
+		return ExitCode(moduleErr.Err)
+	}
+	if staged, ok := err.(loxerr.Staged); ok {
+		if code, ok := stageExitCodes[staged.Stage()]; ok {
+			return code
+		}
+	}
+	return 1
+}
+
+// Write the diagnostics of the error that occurred in the source code in the format.
+// The filename is "" if the source code wasn't read from a file.
+func WriteDiagnostics(w io.Writer, format DiagnosticsFormat, err error, source string, filename string) {
+	if format == DiagnosticsJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		for _, diagnostic := range loxerr.Diagnostics(err, filename) {
+			encoder.Encode(diagnostic)
+		}
+		return
+	}
+	fmt.Fprintln(w, loxerr.Render(err, source, filename))
+}
+
+// Write the diagnostics of the error that occurred in the source file in the format, returning an
+// ExitError for the error. Text diagnostics describe the error by its message alone if the file can't be read.
+func ReportFileError(w io.Writer, format DiagnosticsFormat, err error, filepath string) error {
+	source, _ := os.ReadFile(filepath)
+	return ReportSourceError(w, format, err, string(source), filepath)
+}
+
+// Write the diagnostics of the error that occurred in the source code in the format, returning an
+// ExitError for the error. The filename is "" if the source code wasn't read from a file.
+func ReportSourceError(w io.Writer, format DiagnosticsFormat, err error, source string, filename string) error {
+	WriteDiagnostics(w, format, err, source, filename)
+	return &ExitError{Code: ExitCode(err)}
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/token"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDiagnosticsFormat(t *testing.T) {
+	format, err := ParseDiagnosticsFormat("json")
+	assert.Nil(t, err)
+	assert.Equal(t, DiagnosticsJSON, format)
+
+	_, err = ParseDiagnosticsFormat("xml")
+	assert.EqualError(t, err, "unknown diagnostics format 'xml', expected one of: 'text', 'json'")
+}
+
+func TestExitCode(t *testing.T) {
+	semicolon := &token.Token{Type: tokentype.SEMICOLON, Lexeme: ";", Line: 1}
+	assert.Equal(t, 65, ExitCode(loxerr.WithStage(multierror.Append(loxerr.AtLine(1, loxerr.UnrecognizedCharacter, "Bad character.")), loxerr.StageScan)))
+	assert.Equal(t, 66, ExitCode(loxerr.WithStage(loxerr.AtToken(semicolon, loxerr.ExpectedExpression, "Expected expression."), loxerr.StageParse)))
+	assert.Equal(t, 67, ExitCode(loxerr.WithStage(loxerr.AtToken(semicolon, loxerr.ReturnFromTopLevel, "Bad return."), loxerr.StageAnalyze)))
+	assert.Equal(t, 70, ExitCode(loxerr.Runtime(semicolon, loxerr.InvalidOperand, "Bad value.")))
+	assert.Equal(t, 1, ExitCode(errors.New("no such file")))
+
+	moduleErr := &loxerr.ModuleError{File: "lib.lox", Err: multierror.Append(
+		loxerr.WithStage(loxerr.AtToken(semicolon, loxerr.ExpectedExpression, "Expected expression."), loxerr.StageParse),
+	)}
+	assert.Equal(t, 66, ExitCode(loxerr.RuntimeFrom(semicolon, moduleErr)))
+}
+
+func TestWriteDiagnostics_JSON(t *testing.T) {
+	var b strings.Builder
+	err := multierror.Append(
+		loxerr.WithStage(loxerr.AtSpan(1, loxerr.Span{Offset: 6, Length: 1, Column: 7, Text: "@"}, loxerr.UnrecognizedCharacter, "Unrecognized character @"), loxerr.StageScan),
+		loxerr.WithStage(loxerr.AtLine(2, loxerr.UnterminatedString, "Unterminated string."), loxerr.StageScan),
+	)
+
+	WriteDiagnostics(&b, DiagnosticsJSON, err, "print @;", "main.lox")
+	assert.Equal(t, `{"severity":"error","stage":"scan","code":"LOX1001","message":"Unrecognized character @","file":"main.lox","line":1,"column":7}
+{"severity":"error","stage":"scan","code":"LOX1002","message":"Unterminated string.","file":"main.lox","line":2}
+`, b.String())
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The number of lines kept in the history.
+const maxHistory = 1000
+
+// The lines entered at a REPL, oldest first, saved to a file so that they're kept between sessions.
+type History struct {
+	entries []string
+	path    string
+}
+
+// Get the path of the file that the REPL history is saved to, which is ~/.golox_history.
+// Returns an empty path if the home directory isn't known.
+func DefaultHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".golox_history")
+}
+
+// Load the history saved to the file at the path. The history is empty if the file doesn't exist,
+// and isn't saved at all if the path is empty.
+func LoadHistory(path string) *History {
+	h := &History{entries: make([]string, 0), path: path}
+	if path == "" {
+		return h
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		os.WriteFile(path, []byte(strings.Join(h.entries, "\n")+"\n"), 0600)
+	}
+	return h
+}
+
+// Get the lines in the history, oldest first.
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Add a line to the end of the history and save it, unless it's blank or the same as the last line.
+// The history is best effort, so a line that can't be saved is only kept for this session.
+func (h *History) Add(line string) {
+	if strings.TrimSpace(line) == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == line) {
+		return
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[1:]
+	}
+	if h.path == "" {
+		return
+	}
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	file.WriteString(line + "\n")
+}
@@ -0,0 +1,560 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Returned when reading a line is interrupted with Ctrl-C, so that the input read so far is discarded.
+var ErrInterrupted = errors.New("interrupted")
+
+// Gets the candidates that complete the word before the cursor, which replace the whole word.
+type Completer = func(word string) []string
+
+// Reads lines of input, writing the prompt before each line. A line is returned without its newline,
+// along with io.EOF if the input ends before the line does.
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// Reads lines of input as they are, for when the input isn't a terminal.
+type plainReader struct {
+	reader *bufio.Reader
+	out    io.Writer
+}
+
+func (p *plainReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(p.out, prompt)
+	line, err := p.reader.ReadString('\n')
+	return strings.TrimSuffix(line, "\n"), err
+}
+
+// Control keys that the line editor handles.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyBackspace = 8
+	keyTab       = 9
+	keyLineFeed  = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// Keys sent by a terminal as escape sequences, numbered after the runes so that they can't be confused with them.
+const (
+	keyUp = unicode.MaxRune + 1 + iota
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyForwardDelete
+	keyWordLeft
+	keyWordRight
+	keyUnknown
+)
+
+// Edits lines of input read from a terminal, with cursor movement, history, reverse search and completion.
+// The terminal is switched into raw mode while a line is being read, and back afterwards.
+type LineEditor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	history  *History
+	complete Completer
+
+	// The terminal that's switched into raw mode while reading a line, if any.
+	terminal *terminal
+
+	// Keys that were read while waiting for the terminal to report where its cursor is, which are read before any others.
+	pending []byte
+}
+
+// The line being edited, and where the cursor is in it.
+type editState struct {
+	prompt string
+	line   []rune
+	cursor int
+
+	// The column of the terminal that the prompt starts at, and the positions of the terminal's cursor and of the
+	// end of the text drawn. Positions count the columns from the start of the terminal's line that the prompt is
+	// on, continuing on the lines below when the text wraps, so that the cursor can be moved relative to the prompt.
+	startColumn int
+	position    int
+	end         int
+
+	// The entry of the history being shown, which is len(entries) for the line being entered, and that line.
+	historyIndex int
+	draft        []rune
+}
+
+// Create a LineEditor that reads keys from in and writes the line being edited to out. The reader
+// is used as it is, so that it can be shared with others reading the same input without losing any.
+// Accepted lines are added to the history, and Tab completes words using the completer, which may be nil.
+func NewLineEditor(in *bufio.Reader, out io.Writer, history *History, complete Completer) *LineEditor {
+	return &LineEditor{
+		in:       in,
+		out:      out,
+		history:  history,
+		complete: complete,
+	}
+}
+
+// Read a line, letting it be edited until Enter is pressed. Returns io.EOF if Ctrl-D is pressed on
+// an empty line or the input ends, and ErrInterrupted if Ctrl-C is pressed.
+func (e *LineEditor) ReadLine(prompt string) (string, error) {
+	if e.terminal != nil {
+		if restore, err := e.terminal.makeRaw(); err == nil {
+			defer restore()
+		}
+	}
+
+	// The line is drawn from where the cursor starts, rather than the start of the terminal's line,
+	// so that output that doesn't end with a newline, like from 'print', isn't drawn over.
+	s := &editState{prompt: prompt, line: make([]rune, 0), historyIndex: len(e.history.Entries())}
+	s.startColumn = e.cursorColumn()
+	s.position, s.end = s.startColumn, s.startColumn
+	e.refresh(s)
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			e.endLine(s, "")
+			return string(s.line), err
+		}
+		if key == keyCtrlR {
+			if key, err = e.search(s); err != nil {
+				e.endLine(s, "")
+				return string(s.line), err
+			}
+		}
+
+		switch key {
+		case keyEnter, keyLineFeed:
+			e.endLine(s, "")
+			e.history.Add(string(s.line))
+			return string(s.line), nil
+		case keyCtrlC:
+			e.endLine(s, "^C")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(s.line) == 0 {
+				e.endLine(s, "")
+				return "", io.EOF
+			}
+			s.deleteAt(s.cursor)
+		case keyCtrlG:
+			// Ends a search without doing anything else.
+		case keyBackspace, keyDelete:
+			if s.cursor > 0 {
+				s.cursor--
+				s.deleteAt(s.cursor)
+			}
+		case keyForwardDelete:
+			s.deleteAt(s.cursor)
+		case keyLeft, keyCtrlB:
+			if s.cursor > 0 {
+				s.cursor--
+			}
+		case keyRight, keyCtrlF:
+			if s.cursor < len(s.line) {
+				s.cursor++
+			}
+		case keyWordLeft:
+			s.cursor = s.wordStart(isWordRune)
+		case keyWordRight:
+			for s.cursor < len(s.line) && !isWordRune(s.line[s.cursor]) {
+				s.cursor++
+			}
+			for s.cursor < len(s.line) && isWordRune(s.line[s.cursor]) {
+				s.cursor++
+			}
+		case keyHome, keyCtrlA:
+			s.cursor = 0
+		case keyEnd, keyCtrlE:
+			s.cursor = len(s.line)
+		case keyUp, keyCtrlP:
+			e.showHistory(s, s.historyIndex-1)
+		case keyDown, keyCtrlN:
+			e.showHistory(s, s.historyIndex+1)
+		case keyCtrlK:
+			s.line = s.line[:s.cursor]
+		case keyCtrlU:
+			s.line = append(s.line[:0], s.line[s.cursor:]...)
+			s.cursor = 0
+		case keyCtrlW:
+			start := s.wordStart(func(r rune) bool { return !unicode.IsSpace(r) })
+			s.line = append(s.line[:start], s.line[s.cursor:]...)
+			s.cursor = start
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+			s.startColumn, s.position, s.end = 0, 0, 0
+		case keyTab:
+			e.completeWord(s)
+		default:
+			if key < unicode.MaxRune && unicode.IsPrint(key) {
+				s.insert([]rune{key})
+			}
+		}
+		e.refresh(s)
+	}
+}
+
+// Read a rune of input, starting with the keys that are pending.
+func (e *LineEditor) readRune() (rune, error) {
+	if len(e.pending) > 0 {
+		r, size := utf8.DecodeRune(e.pending)
+		e.pending = e.pending[size:]
+		return r, nil
+	}
+	r, _, err := e.in.ReadRune()
+	return r, err
+}
+
+// Check whether input can be read without waiting for it.
+func (e *LineEditor) buffered() bool {
+	return len(e.pending) > 0 || e.in.Buffered() > 0
+}
+
+// Read a key, translating the escape sequences that a terminal sends for special keys.
+func (e *LineEditor) readKey() (rune, error) {
+	r, err := e.readRune()
+	if err != nil || r != keyEscape {
+		return r, err
+	}
+
+	// A terminal sends the rest of an escape sequence along with the escape, so an escape that
+	// nothing follows is the Escape key itself, which is ignored rather than waiting for another key.
+	if !e.buffered() {
+		return keyEscape, nil
+	}
+	next, err := e.readRune()
+	if err != nil {
+		return keyEscape, nil
+	}
+	switch next {
+	case 'b':
+		return keyWordLeft, nil
+	case 'f':
+		return keyWordRight, nil
+	case '[', 'O':
+	default:
+		return keyUnknown, nil
+	}
+
+	// A control sequence is parameters followed by a final byte that says what it is.
+	var params strings.Builder
+	for {
+		c, err := e.readRune()
+		if err != nil {
+			return keyUnknown, nil
+		}
+		if c >= 0x40 && c <= 0x7e {
+			return sequenceKey(params.String(), c), nil
+		}
+		params.WriteRune(c)
+	}
+}
+
+// Get the key for a control sequence with the parameters and final byte.
+func sequenceKey(params string, final rune) rune {
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		if strings.HasSuffix(params, ";5") {
+			return keyWordRight
+		}
+		return keyRight
+	case 'D':
+		if strings.HasSuffix(params, ";5") {
+			return keyWordLeft
+		}
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	case '~':
+		switch params {
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		case "3":
+			return keyForwardDelete
+		}
+	}
+	return keyUnknown
+}
+
+// Get the column of the terminal that the cursor is at, starting at 0, which the terminal reports when asked.
+// The column is 0 when there's no terminal, when keys are waiting to be read, or when the terminal doesn't answer.
+func (e *LineEditor) cursorColumn() int {
+	if e.terminal == nil || e.buffered() {
+		return 0
+	}
+
+	column := 0
+	e.terminal.withReadTimeout(1, func() {
+		fmt.Fprint(e.out, "\x1b[6n")
+		read := make([]byte, 0)
+		for {
+			b, err := e.in.ReadByte()
+			if err != nil {
+				e.pending = append(e.pending, read...)
+				return
+			}
+			read = append(read, b)
+
+			// The report is "ESC [ row ; column R", and keys pressed before it arrived are kept to be read later.
+			if b == 'R' {
+				start := bytes.LastIndex(read, []byte("\x1b["))
+				var row int
+				if start >= 0 {
+					if _, err := fmt.Sscanf(string(read[start:]), "\x1b[%d;%dR", &row, &column); err == nil && column > 0 {
+						e.pending = append(e.pending, read[:start]...)
+						column--
+						return
+					}
+				}
+				column = 0
+			}
+		}
+	})
+	return column
+}
+
+// Get the number of columns of the terminal, or 0 if it's unknown, in which case lines are drawn as if they don't wrap.
+func (e *LineEditor) width() int {
+	if e.terminal == nil {
+		return 0
+	}
+	return e.terminal.width()
+}
+
+// Redraw the line being edited and put the cursor where it is in the line.
+func (e *LineEditor) refresh(s *editState) {
+	e.draw(s, s.prompt+string(s.line), utf8.RuneCountInString(s.prompt)+s.cursor)
+}
+
+// Redraw the line with the text, putting the cursor the number of runes after the start of the text.
+// Each rune is taken to fill one column.
+func (e *LineEditor) draw(s *editState, text string, cursor int) {
+	width := e.width()
+	fmt.Fprint(e.out, cursorMovement(s.position, s.startColumn, width), text)
+
+	// A terminal leaves the cursor on the last column after text that fills the line, so it's moved to the next line.
+	s.end = s.startColumn + utf8.RuneCountInString(text)
+	if width > 0 && s.end > s.startColumn && s.end%width == 0 {
+		fmt.Fprint(e.out, "\r\n")
+	}
+	fmt.Fprint(e.out, "\x1b[J", cursorMovement(s.end, s.startColumn+cursor, width))
+	s.position = s.startColumn + cursor
+}
+
+// Move the cursor past the end of the line drawn and onto a new line, after the text.
+func (e *LineEditor) endLine(s *editState, text string) {
+	fmt.Fprint(e.out, cursorMovement(s.position, s.end, e.width()), text, "\r\n")
+	s.startColumn, s.position, s.end = 0, 0, 0
+}
+
+// Get the escape sequences that move the cursor from one position to another, where positions count the columns
+// from the start of a line of the terminal, continuing on the lines below it when they're wider than the terminal.
+func cursorMovement(from int, to int, width int) string {
+	fromRow, fromColumn, toRow, toColumn := 0, from, 0, to
+	if width > 0 {
+		fromRow, fromColumn = from/width, from%width
+		toRow, toColumn = to/width, to%width
+	}
+
+	var b strings.Builder
+	if fromRow > toRow {
+		fmt.Fprintf(&b, "\x1b[%dA", fromRow-toRow)
+	} else if fromRow < toRow {
+		fmt.Fprintf(&b, "\x1b[%dB", toRow-fromRow)
+	}
+	if fromColumn > toColumn {
+		fmt.Fprintf(&b, "\x1b[%dD", fromColumn-toColumn)
+	} else if fromColumn < toColumn {
+		fmt.Fprintf(&b, "\x1b[%dC", toColumn-fromColumn)
+	}
+	return b.String()
+}
+
+// Show the entry of the history at the index, or the line being entered if the index is past the last entry.
+func (e *LineEditor) showHistory(s *editState, index int) {
+	entries := e.history.Entries()
+	if index < 0 || index > len(entries) || index == s.historyIndex {
+		return
+	}
+	if s.historyIndex == len(entries) {
+		s.draft = append([]rune{}, s.line...)
+	}
+	s.historyIndex = index
+	if index == len(entries) {
+		s.line = append([]rune{}, s.draft...)
+	} else {
+		s.line = []rune(entries[index])
+	}
+	s.cursor = len(s.line)
+}
+
+// Search the history backwards for a line containing what's typed, until a key other than one used
+// for searching is pressed. The line found replaces the line being edited, and the key that ended the
+// search is returned so that it takes effect as usual. Ctrl-G cancels the search, keeping the line as it was.
+func (e *LineEditor) search(s *editState) (rune, error) {
+	entries := e.history.Entries()
+	query := make([]rune, 0)
+	match := len(entries)
+	found := ""
+
+	// Find the newest entry at or before the index that contains the query.
+	find := func(from int) {
+		for i := from; i >= 0; i-- {
+			if strings.Contains(entries[i], string(query)) {
+				match = i
+				found = entries[i]
+				return
+			}
+		}
+		match = -1
+	}
+
+	for {
+		status := "reverse-i-search"
+		if match < 0 {
+			status = "failed reverse-i-search"
+		}
+		text := fmt.Sprintf("(%s)`%s': %s", status, string(query), found)
+		e.draw(s, text, utf8.RuneCountInString(text))
+
+		key, err := e.readKey()
+		if err != nil {
+			return key, err
+		}
+		switch key {
+		case keyCtrlR:
+			if len(query) > 0 && match >= 0 {
+				find(match - 1)
+			}
+		case keyBackspace, keyDelete:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				match, found = len(entries), ""
+				if len(query) > 0 {
+					find(len(entries) - 1)
+				}
+			}
+		case keyCtrlG, keyCtrlC:
+			return key, nil
+		default:
+			if key < unicode.MaxRune && unicode.IsPrint(key) {
+				query = append(query, key)
+				if match >= len(entries) {
+					match = len(entries) - 1
+				}
+				if match >= 0 {
+					find(match)
+				}
+				continue
+			}
+			if found != "" {
+				s.line = []rune(found)
+				s.cursor = len(s.line)
+			}
+			return key, nil
+		}
+	}
+}
+
+// Complete the word before the cursor. A single candidate replaces the word, and several candidates
+// replace it with the prefix they have in common, or are listed if the word is already that prefix.
+func (e *LineEditor) completeWord(s *editState) {
+	if e.complete == nil {
+		return
+	}
+
+	start := s.cursor
+	for start > 0 && isCompletionRune(s.line[start-1]) {
+		start--
+	}
+	// A ':' at the start of the line begins a command name.
+	if start > 0 && s.line[start-1] == ':' && strings.TrimSpace(string(s.line[:start-1])) == "" {
+		start--
+	}
+	word := string(s.line[start:s.cursor])
+	candidates := e.complete(word)
+	if len(candidates) == 0 {
+		fmt.Fprint(e.out, "\a")
+		return
+	}
+
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(candidates) == 1 || len(prefix) > len(word) {
+		s.line = append(s.line[:start], append([]rune(prefix), s.line[s.cursor:]...)...)
+		s.cursor = start + len([]rune(prefix))
+		return
+	}
+	e.endLine(s, "")
+	fmt.Fprintf(e.out, "%s\r\n", strings.Join(candidates, "  "))
+}
+
+// Insert runes at the cursor, moving the cursor after them.
+func (s *editState) insert(runes []rune) {
+	s.line = append(s.line[:s.cursor], append(runes, s.line[s.cursor:]...)...)
+	s.cursor += len(runes)
+}
+
+// Delete the rune at the index, if there is one.
+func (s *editState) deleteAt(index int) {
+	if index < len(s.line) {
+		s.line = append(s.line[:index], s.line[index+1:]...)
+	}
+}
+
+// Get the index that the word before the cursor starts at, skipping back over
+// runes that aren't part of a word and then over the runes of the word.
+func (s *editState) wordStart(inWord func(rune) bool) int {
+	start := s.cursor
+	for start > 0 && !inWord(s.line[start-1]) {
+		start--
+	}
+	for start > 0 && inWord(s.line[start-1]) {
+		start--
+	}
+	return start
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Check whether the rune can be part of a word that's completed, which is a name or a property of one.
+func isCompletionRune(r rune) bool {
+	return r == '.' || isWordRune(r)
+}
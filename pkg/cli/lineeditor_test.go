@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Read a line by pressing the keys, returning it and what was written to the terminal.
+func readKeys(t *testing.T, history *History, complete Completer, keys string) (string, string, error) {
+	var out strings.Builder
+	editor := NewLineEditor(bufio.NewReader(strings.NewReader(keys)), &out, history, complete)
+	line, err := editor.ReadLine("> ")
+	return line, out.String(), err
+}
+
+func TestLineEditor_EditsTheLine(t *testing.T) {
+	testCases := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"typing", "print 1;\r", "print 1;"},
+		{"backspace", "print 12\x7f;\r", "print 1;"},
+		{"left and insert", "print ;\x1b[D1\r", "print 1;"},
+		{"home and end", "rint 1\x01p\x05;\r", "print 1;"},
+		{"ctrl keys for moving", "rint 1\x02\x02\x02\x02\x02\x02p\x06\x06\x06\x06\x06\x06;\r", "print 1;"},
+		{"forward delete", "print 12;\x1b[D\x1b[D\x1b[3~\r", "print 1;"},
+		{"ctrl-d deletes under the cursor", "print 12;\x1b[D\x1b[D\x04\r", "print 1;"},
+		{"kill to end", "print 1; // comment\x1b[1~\x1b[C\x1b[C\x1b[C\x1b[C\x1b[C\x1b[C\x1b[C\x1b[C\x0b\r", "print 1;"},
+		{"kill to start", "oops print 1;\x01\x1b[C\x1b[C\x1b[C\x1b[C\x1b[C\x15\r", "print 1;"},
+		{"kill word", "print oops\x171;\r", "print 1;"},
+		{"word movement", "print 1;\x1bb\x1bb2\r", "2print 1;"},
+		{"unknown keys are ignored", "print\x1b[15~ 1;\x1bx\r", "print 1;"},
+		{"line feed", "print 1;\n", "print 1;"},
+		{"unicode", "print \"é\";\x1b[D\x1b[D\x7fe\r", "print \"e\";"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			line, _, err := readKeys(t, LoadHistory(""), nil, tc.keys)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, line)
+		})
+	}
+}
+
+func TestLineEditor_RedrawsTheLine(t *testing.T) {
+	_, out, err := readKeys(t, LoadHistory(""), nil, "ab\x1b[D\r")
+	assert.NoError(t, err)
+	assert.Equal(t, "> \x1b[J\x1b[2D> a\x1b[J\x1b[3D> ab\x1b[J\x1b[4D> ab\x1b[J\x1b[1D\x1b[1C\r\n", out)
+}
+
+func TestCursorMovement(t *testing.T) {
+	testCases := []struct {
+		name     string
+		from     int
+		to       int
+		width    int
+		expected string
+	}{
+		{"nowhere", 5, 5, 80, ""},
+		{"left", 5, 2, 80, "\x1b[3D"},
+		{"right", 2, 5, 80, "\x1b[3C"},
+		{"up a wrapped line", 85, 3, 80, "\x1b[1A\x1b[2D"},
+		{"down a wrapped line", 3, 165, 80, "\x1b[2B\x1b[2C"},
+		{"start of a wrapped line", 79, 80, 80, "\x1b[1B\x1b[79D"},
+		{"unknown width", 85, 3, 0, "\x1b[82D"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, cursorMovement(tc.from, tc.to, tc.width))
+		})
+	}
+}
+
+// A reader that returns each chunk from a separate read, like keys typed one after another.
+type chunkReader []string
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(*c) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, (*c)[0])
+	*c = (*c)[1:]
+	return n, nil
+}
+
+func TestLineEditor_IgnoresEscapeOnItsOwn(t *testing.T) {
+	keys := chunkReader{"print 1;", "\x1b", "\r"}
+	editor := NewLineEditor(bufio.NewReader(&keys), io.Discard, LoadHistory(""), nil)
+	line, err := editor.ReadLine("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, "print 1;", line)
+}
+
+func TestLineEditor_ReadsPendingKeysFirst(t *testing.T) {
+	editor := NewLineEditor(bufio.NewReader(strings.NewReader(" 1;\r")), io.Discard, LoadHistory(""), nil)
+	editor.pending = []byte("print")
+	line, err := editor.ReadLine("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, "print 1;", line)
+}
+
+func TestLineEditor_SharesTheReader(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("print 1;\rrest of the input\n"))
+	editor := NewLineEditor(reader, io.Discard, LoadHistory(""), nil)
+	line, err := editor.ReadLine("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, "print 1;", line)
+
+	rest, err := reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "rest of the input\n", rest)
+}
+
+func TestLineEditor_EndsAndInterrupts(t *testing.T) {
+	line, _, err := readKeys(t, LoadHistory(""), nil, "\x04")
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "", line)
+
+	line, _, err = readKeys(t, LoadHistory(""), nil, "partial")
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "partial", line)
+
+	line, out, err := readKeys(t, LoadHistory(""), nil, "print 1;\x03")
+	assert.Equal(t, ErrInterrupted, err)
+	assert.Equal(t, "", line)
+	assert.True(t, strings.HasSuffix(out, "^C\r\n"))
+}
+
+func TestLineEditor_MovesThroughHistory(t *testing.T) {
+	history := LoadHistory("")
+	history.Add("var a = 1;")
+	history.Add("var b = 2;")
+
+	testCases := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"previous", "\x1b[A\r", "var b = 2;"},
+		{"before previous", "\x1b[A\x10\r", "var a = 1;"},
+		{"stops at oldest", "\x1b[A\x1b[A\x1b[A\r", "var a = 1;"},
+		{"back to the draft", "print\x1b[A\x1b[A\x1b[B\x0e\r", "print"},
+		{"edits an entry", "\x1b[A\x7f\x7f3;\r", "var b = 3;"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			line, _, err := readKeys(t, history, nil, tc.keys)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, line)
+			history.entries = history.entries[:2]
+		})
+	}
+}
+
+func TestLineEditor_SearchesHistory(t *testing.T) {
+	history := LoadHistory("")
+	history.Add("var count = 1;")
+	history.Add("print count;")
+	history.Add("var name = \"lox\";")
+
+	testCases := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"newest match", "\x12count\r", "print count;"},
+		{"older match", "\x12count\x12\r", "var count = 1;"},
+		{"backspace widens the search", "\x12countx\x7f\r", "print count;"},
+		{"other keys edit the match", "\x12name\x1b[D\x7f\x7f\x7f\x7fjs\"\r", "var name = \"js\";"},
+		{"cancel", "print\x12count\x07;\r", "print;"},
+		{"no match", "print\x12zzz\x1b[C;\r", "print;"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			line, _, err := readKeys(t, history, nil, tc.keys)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, line)
+			history.entries = history.entries[:3]
+		})
+	}
+
+	_, out, _ := readKeys(t, history, nil, "\x12count\x12\x12\x07\r")
+	assert.Contains(t, out, "(reverse-i-search)`count': print count;")
+	assert.Contains(t, out, "(reverse-i-search)`count': var count = 1;")
+	assert.Contains(t, out, "(failed reverse-i-search)`count': var count = 1;")
+}
+
+func TestLineEditor_CompletesWords(t *testing.T) {
+	complete := func(word string) []string {
+		candidates := make([]string, 0)
+		for _, name := range []string{"point", "point.x", "point.y", "print", "printer", ":load"} {
+			if strings.HasPrefix(name, word) {
+				candidates = append(candidates, name)
+			}
+		}
+		return candidates
+	}
+
+	testCases := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"single candidate", "var p = printe\t;\r", "var p = printer;"},
+		{"common prefix", "po\t\r", "point"},
+		{"property", "point.x\t\r", "point.x"},
+		{"before the cursor", "x = poi;\x1b[D\t\r", "x = point;"},
+		{"command", ":lo\t\r", ":load"},
+		{"no candidates", "zzz\t\r", "zzz"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			line, _, err := readKeys(t, LoadHistory(""), complete, tc.keys)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, line)
+		})
+	}
+
+	line, out, err := readKeys(t, LoadHistory(""), complete, "point.\t\r")
+	assert.NoError(t, err)
+	assert.Equal(t, "point.", line)
+	assert.Contains(t, out, "\r\npoint.x  point.y\r\n")
+}
+
+func TestHistory_SavesAcceptedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".golox_history")
+	history := LoadHistory(path)
+	_, _, err := readKeys(t, history, nil, "var a = 1;\r")
+	assert.NoError(t, err)
+	history.Add("var a = 1;")
+	history.Add("   ")
+	history.Add("print a;")
+
+	loaded := LoadHistory(path)
+	assert.Equal(t, []string{"var a = 1;", "print a;"}, loaded.Entries())
+
+	line, _, err := readKeys(t, loaded, nil, "\x1b[A\x1b[A\r")
+	assert.NoError(t, err)
+	assert.Equal(t, "var a = 1;", line)
+}
+
+func TestHistory_KeepsTheNewestLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".golox_history")
+	history := LoadHistory(path)
+	for i := 0; i < maxHistory+10; i++ {
+		history.Add(strings.Repeat("x", i+1))
+	}
+
+	loaded := LoadHistory(path)
+	assert.Len(t, loaded.Entries(), maxHistory)
+	assert.Equal(t, strings.Repeat("x", 11), loaded.Entries()[0])
+}
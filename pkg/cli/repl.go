@@ -5,34 +5,187 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/scanner"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
 )
 
-type lineHandler = func(line string)
+// Runs the input, which is a line or a statement spanning several lines, returning an error to report if the input fails.
+type lineHandler = func(line string) error
 
 // Wraps around a function to repeatedly run for REPL-like use.
 type Repl struct {
 	reader  *bufio.Reader
+	input   lineReader
+	stdout  io.Writer
+	stderr  io.Writer
 	handler lineHandler
+
+	// The format that errors returned by the handler are written in.
+	format DiagnosticsFormat
+
+	// The commands that can be entered instead of source code, by name, and their names in the order they were added.
+	commands     map[string]*ReplCommand
+	commandNames []string
+
+	// The source code entered that the handler ran successfully.
+	inputs []string
+
+	// Completes words with names other than commands and keywords, like the names of global variables.
+	completer Completer
 }
 
 // Create a Repl with a function handler that runs on each line.
 func NewRepl(handler lineHandler) *Repl {
-	return NewReplWithReader(handler, os.Stdin)
+	return NewReplWithIO(handler, os.Stdin, os.Stdout, os.Stderr)
 }
 
 // Create a Repl with a function handler that runs on each line and a reader.
 func NewReplWithReader(handler lineHandler, reader io.Reader) *Repl {
-	return &Repl{
-		reader:  bufio.NewReader(reader),
-		handler: handler,
+	return NewReplWithIO(handler, reader, os.Stdout, os.Stderr)
+}
+
+// Create a Repl with a function handler that runs on each line read from stdin.
+// Prompts are written to stdout, and errors returned by the handler are written to stderr.
+func NewReplWithIO(handler lineHandler, stdin io.Reader, stdout io.Writer, stderr io.Writer) *Repl {
+	reader := bufio.NewReader(stdin)
+	r := &Repl{
+		reader:       reader,
+		input:        &plainReader{reader: reader, out: stdout},
+		stdout:       stdout,
+		stderr:       stderr,
+		handler:      handler,
+		format:       DiagnosticsText,
+		commands:     make(map[string]*ReplCommand),
+		commandNames: make([]string, 0),
+		inputs:       make([]string, 0),
+	}
+	r.AddCommand(helpCommand(r))
+	r.AddCommand(saveCommand(r))
+	return r
+}
+
+// Set the format that errors returned by the handler are written in.
+func (r *Repl) SetDiagnosticsFormat(format DiagnosticsFormat) {
+	r.format = format
+}
+
+// Edit lines with a terminal line editor when stdin is a terminal, with history saved to the file at historyPath,
+// reverse search with Ctrl-R, and Tab completion of commands, keywords and the names given by SetCompleter.
+// Lines are read as they are when stdin isn't a terminal, so that input can be piped to the REPL.
+func (r *Repl) EnableLineEditing(stdin *os.File, historyPath string) {
+	terminal, ok := openTerminal(stdin)
+	if !ok {
+		return
+	}
+	editor := NewLineEditor(r.reader, r.stdout, LoadHistory(historyPath), r.complete)
+	editor.terminal = terminal
+	r.input = editor
+}
+
+// Set the completer for names other than commands and keywords when lines are edited with a line editor.
+func (r *Repl) SetCompleter(completer Completer) {
+	r.completer = completer
+}
+
+// Get the commands, keywords and other names that complete the word, sorted.
+func (r *Repl) complete(word string) []string {
+	candidates := make([]string, 0)
+	if strings.HasPrefix(word, ":") {
+		for _, name := range r.commandNames {
+			if strings.HasPrefix(":"+name, word) {
+				candidates = append(candidates, ":"+name)
+			}
+		}
+		sort.Strings(candidates)
+		return candidates
+	}
+
+	names := tokentype.Keywords()
+	if r.completer != nil {
+		names = append(names, r.completer(word)...)
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if word != "" && strings.HasPrefix(name, word) && !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, name)
+		}
 	}
+	sort.Strings(candidates)
+	return candidates
 }
 
-// Start the REPL procedure.
+// Start the REPL procedure, which runs until the input ends. Lines are read until the brackets,
+// braces and parentheses of the input are balanced, so that the handler runs once for a statement
+// that spans multiple lines. Each line after the first is prompted with "... ", and a blank line runs
+// the input read so far even if it isn't balanced. A line starting with ':' runs a command instead,
+// and Ctrl-C in the line editor discards the input read so far.
 func (r *Repl) Start() {
+	var input strings.Builder
 	for {
-		fmt.Print("> ")
-		line, _ := r.reader.ReadString('\n')
-		r.handler(line)
+		prompt := "> "
+		if input.Len() > 0 {
+			prompt = "... "
+		}
+		line, readErr := r.input.ReadLine(prompt)
+		if readErr == ErrInterrupted {
+			input.Reset()
+			continue
+		}
+		continuing := input.Len() > 0
+		input.WriteString(line)
+		if readErr == nil {
+			input.WriteString("\n")
+		}
+		if !continuing && isCommand(line) {
+			r.runCommand(line)
+		} else if readErr == nil && isIncomplete(input.String()) && !(continuing && strings.TrimSpace(line) == "") {
+			continue
+		} else if source := strings.TrimSuffix(input.String(), "\n"); source != "" {
+			// The newline ending the input isn't passed on, so that an error at the end of the input is on its last line.
+			if err := r.handler(source); err != nil {
+				WriteDiagnostics(r.stderr, r.format, err, source, "")
+			} else if strings.TrimSpace(source) != "" {
+				r.inputs = append(r.inputs, source)
+			}
+		}
+		input.Reset()
+		if readErr != nil {
+			fmt.Fprintln(r.stdout)
+			return
+		}
+	}
+}
+
+// Check whether the source code ends inside of brackets, braces, parentheses, a string or a
+// string interpolation, so that more lines are needed to complete it.
+func isIncomplete(source string) bool {
+	s := scanner.NewScanner(source)
+	tokens, err := s.ScanAllTokens()
+	if multi, ok := err.(*multierror.Error); ok {
+		for _, scanErr := range multi.Errors {
+			if coded, ok := scanErr.(loxerr.Coded); ok && coded.Code() == loxerr.UnterminatedString {
+				return true
+			}
+		}
+	}
+	if s.InInterpolation() {
+		return true
+	}
+
+	depth := 0
+	for _, t := range tokens {
+		switch t.Type {
+		case tokentype.LEFT_PAREN, tokentype.LEFT_BRACE, tokentype.LEFT_BRACKET:
+			depth++
+		case tokentype.RIGHT_PAREN, tokentype.RIGHT_BRACE, tokentype.RIGHT_BRACKET:
+			depth--
+		}
 	}
+	return depth > 0
 }
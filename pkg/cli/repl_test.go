@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/scanner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepl_RunsEachLineUntilInputEnds(t *testing.T) {
+	var stdout, stderr strings.Builder
+	lines := make([]string, 0)
+	repl := NewReplWithIO(func(line string) error {
+		lines = append(lines, line)
+		if strings.HasPrefix(line, "bad") {
+			return errors.New("bad line")
+		}
+		return nil
+	}, strings.NewReader("first\nbad\nlast"), &stdout, &stderr)
+
+	repl.Start()
+	assert.Equal(t, []string{"first", "bad", "last"}, lines)
+	assert.Equal(t, "> > > \n", stdout.String())
+	assert.Equal(t, "bad line\n", stderr.String())
+}
+
+func TestRepl_RendersErrorsWithTheLine(t *testing.T) {
+	var stdout, stderr strings.Builder
+	repl := NewReplWithIO(func(line string) error {
+		return loxerr.AtSpan(1, loxerr.Span{Offset: 6, Length: 3, Text: "bad"}, loxerr.ExpectedExpression, "Bad value.")
+	}, strings.NewReader("print bad;\n"), &stdout, &stderr)
+
+	repl.Start()
+	assert.Equal(t, "[line 1] Error: Bad value. [LOX2002]\n  |\n1 | print bad;\n  |       ^~~\n", stderr.String())
+}
+
+func TestRepl_RendersErrorsAtTheEndOnTheLastLine(t *testing.T) {
+	var stdout, stderr strings.Builder
+	repl := NewReplWithIO(func(line string) error {
+		tokens, _ := scanner.NewScanner(line).ScanAllTokens()
+		return loxerr.AtToken(tokens[len(tokens)-1], loxerr.ExpectedToken, "Expected ';' after value.")
+	}, strings.NewReader("print 1\n"), &stdout, &stderr)
+
+	repl.Start()
+	assert.Equal(t, "[line 1] Error at end: Expected ';' after value. [LOX2001]\n  |\n1 | print 1\n  |        ^\n", stderr.String())
+}
+
+func TestRepl_ReadsUntilBracketsAreBalanced(t *testing.T) {
+	var stdout, stderr strings.Builder
+	inputs := make([]string, 0)
+	repl := NewReplWithIO(func(input string) error {
+		inputs = append(inputs, input)
+		return nil
+	}, strings.NewReader("fun f() {\n  print \"${\n1}\";\n}\nf();\n"), &stdout, &stderr)
+
+	repl.Start()
+	assert.Equal(t, []string{"fun f() {\n  print \"${\n1}\";\n}", "f();"}, inputs)
+	assert.Equal(t, "> ... ... ... > > \n", stdout.String())
+}
+
+func TestRepl_BlankLineRunsUnbalancedInput(t *testing.T) {
+	var stdout, stderr strings.Builder
+	inputs := make([]string, 0)
+	repl := NewReplWithIO(func(input string) error {
+		inputs = append(inputs, input)
+		return nil
+	}, strings.NewReader("\n(1\n\n[2"), &stdout, &stderr)
+
+	repl.Start()
+	assert.Equal(t, []string{"(1\n", "[2"}, inputs)
+	assert.Equal(t, "> > ... > \n", stdout.String())
+}
+
+func TestRepl_RunsCommands(t *testing.T) {
+	var stdout, stderr strings.Builder
+	inputs := make([]string, 0)
+	repl := NewReplWithIO(func(input string) error {
+		inputs = append(inputs, input)
+		return nil
+	}, strings.NewReader(":echo  a b \n:echo\n:bogus\n{\n:echo c\n}\n"), &stdout, &stderr)
+	repl.AddCommand(&ReplCommand{
+		Name: "echo",
+		Args: "<text>",
+		Help: "Print the text.",
+		Run: func(out io.Writer, args string) error {
+			if args == "" {
+				return errors.New("Expected text.")
+			}
+			fmt.Fprintln(out, args)
+			return nil
+		},
+	})
+
+	repl.Start()
+	// Commands aren't recognized in the middle of a statement.
+	assert.Equal(t, []string{"{\n:echo c\n}"}, inputs)
+	assert.Equal(t, "> a b\n> > > ... ... > \n", stdout.String())
+	assert.Equal(t, "Expected text.\nUnknown command ':bogus'. Enter ':help' to list the commands.\n", stderr.String())
+}
+
+func TestRepl_Help(t *testing.T) {
+	var stdout, stderr strings.Builder
+	repl := NewReplWithIO(func(string) error { return nil }, strings.NewReader(":help"), &stdout, &stderr)
+	repl.AddCommand(TokensCommand())
+
+	repl.Start()
+	assert.Equal(t, `> :help                List the commands.
+:save <file>         Write the source code entered successfully to the file.
+:tokens <code>       Print the tokens of the source code.
+
+`, stdout.String())
+}
+
+func TestRepl_SavesSuccessfulInputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.lox")
+	var stdout, stderr strings.Builder
+	repl := NewReplWithIO(func(input string) error {
+		if strings.Contains(input, "bad") {
+			return errors.New("bad input")
+		}
+		return nil
+	}, strings.NewReader("var a = 1;\nbad;\na + 1 // no semicolon\nfun f() {\n}\n:save "+path+"\n"), &stdout, &stderr)
+	repl.Record("var b = 2;")
+
+	repl.Start()
+	saved, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "var b = 2;\nvar a = 1;\na + 1; // no semicolon\nfun f() {\n}\n", string(saved))
+	assert.Contains(t, stdout.String(), "Saved 4 inputs to "+path+".")
+
+	repl.ClearRecord()
+	repl.runCommand(":save " + path)
+	saved, err = os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(saved))
+}
+
+func TestRepl_ReportsCommandErrorsInFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.lox")
+	assert.Nil(t, os.WriteFile(path, []byte("print bad;\n"), 0644))
+
+	var stdout, stderr strings.Builder
+	repl := NewReplWithIO(func(string) error { return nil }, strings.NewReader(":load "+path), &stdout, &stderr)
+	repl.AddCommand(&ReplCommand{
+		Name: "load",
+		Run: func(out io.Writer, path string) error {
+			return InFile(loxerr.AtSpan(1, loxerr.Span{Offset: 6, Length: 3, Column: 7, Text: "bad"}, loxerr.UndefinedVariable, "Bad value."), path)
+		},
+	})
+	assert.Nil(t, InFile(nil, path))
+
+	repl.Start()
+	assert.Equal(t, "[line 1] Error: Bad value. [LOX4001]\n --> "+path+":1:7\n  |\n1 | print bad;\n  |       ^~~\n", stderr.String())
+}
+
+func TestTokensAndAstCommands(t *testing.T) {
+	var out strings.Builder
+	assert.Nil(t, TokensCommand().Run(&out, "a + 1"))
+	assert.Equal(t, "IDENTIFIER a nil\nPLUS + nil\nNUMBER 1 1\nEOF  nil\n", out.String())
+
+	out.Reset()
+	assert.Nil(t, AstCommand().Run(&out, "a + 1"))
+	assert.Equal(t, "((+ (var a) 1));\n", out.String())
+
+	assert.Error(t, AstCommand().Run(&out, "a +"))
+}
+
+func TestRepl_CompletesCommandsKeywordsAndNames(t *testing.T) {
+	repl := NewReplWithIO(func(string) error { return nil }, strings.NewReader(""), io.Discard, io.Discard)
+	assert.Equal(t, []string{":help"}, repl.complete(":h"))
+	assert.Equal(t, []string{":help", ":save"}, repl.complete(":"))
+	assert.Equal(t, []string{"false", "finally", "for", "fun"}, repl.complete("f"))
+	assert.Empty(t, repl.complete(""))
+
+	repl.SetCompleter(func(word string) []string { return []string{"format", "fun", "point.x"} })
+	assert.Equal(t, []string{"false", "finally", "for", "format", "fun"}, repl.complete("f"))
+	assert.Equal(t, []string{"point.x"}, repl.complete("point."))
+}
+
+// Reads the lines one at a time, and then ends the input.
+type linesReader struct {
+	lines []string
+	errs  []error
+}
+
+func (l *linesReader) ReadLine(prompt string) (string, error) {
+	if len(l.lines) == 0 {
+		return "", io.EOF
+	}
+	line, err := l.lines[0], l.errs[0]
+	l.lines, l.errs = l.lines[1:], l.errs[1:]
+	return line, err
+}
+
+func TestRepl_InterruptDiscardsInput(t *testing.T) {
+	inputs := make([]string, 0)
+	repl := NewReplWithIO(func(input string) error {
+		inputs = append(inputs, input)
+		return nil
+	}, strings.NewReader(""), io.Discard, io.Discard)
+	repl.input = &linesReader{
+		lines: []string{"fun f() {", "", "print 1;"},
+		errs:  []error{nil, ErrInterrupted, nil},
+	}
+
+	repl.Start()
+	assert.Equal(t, []string{"print 1;"}, inputs)
+}
+
+func TestRepl_LineEditingFallsBackWhenNotATerminal(t *testing.T) {
+	file, err := os.Open(t.TempDir())
+	assert.Nil(t, err)
+	defer file.Close()
+
+	repl := NewReplWithIO(func(string) error { return nil }, file, io.Discard, io.Discard)
+	repl.EnableLineEditing(file, "")
+	assert.IsType(t, &plainReader{}, repl.input)
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kaschnit/golox/pkg/ast/astutil"
+	"github.com/kaschnit/golox/pkg/ast/printer"
+	"github.com/kaschnit/golox/pkg/scanner"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+)
+
+// A command run by entering ":name args" at a REPL instead of source code, like ":load file.lox".
+type ReplCommand struct {
+	Name string
+
+	// The arguments that the command takes, like "<file>", and a description of the command, shown by ":help".
+	Args string
+	Help string
+
+	// Run the command with the text entered after its name, writing its output to out.
+	// Errors are reported with the text after the name as their source code.
+	Run func(out io.Writer, args string) error
+}
+
+// An error in the source code of a file run by a REPL command.
+type fileError struct {
+	err  error
+	path string
+}
+
+func (e *fileError) Error() string {
+	return e.err.Error()
+}
+
+// Wrap an error returned by a REPL command that occurred in the source code of the file at the path,
+// so that it's reported with the file's source code. Returns nil if err is nil.
+func InFile(err error, path string) error {
+	if err == nil {
+		return nil
+	}
+	return &fileError{err: err, path: path}
+}
+
+// Add a command to the REPL, replacing any command with the same name.
+func (r *Repl) AddCommand(command *ReplCommand) {
+	if _, exists := r.commands[command.Name]; !exists {
+		r.commandNames = append(r.commandNames, command.Name)
+	}
+	r.commands[command.Name] = command
+}
+
+// Record source code as if it had been entered successfully, so that ":save" writes it.
+func (r *Repl) Record(source string) {
+	r.inputs = append(r.inputs, source)
+}
+
+// Forget the source code entered so far, so that ":save" only writes what's entered from now on.
+func (r *Repl) ClearRecord() {
+	r.inputs = r.inputs[:0]
+}
+
+// Check whether the input is a command rather than source code.
+func isCommand(input string) bool {
+	return strings.HasPrefix(strings.TrimSpace(input), ":")
+}
+
+// Run the command entered as the input, reporting its error if it fails.
+func (r *Repl) runCommand(input string) {
+	name, args, _ := strings.Cut(strings.TrimSpace(input)[1:], " ")
+	args = strings.TrimSpace(args)
+	command, ok := r.commands[name]
+	if !ok {
+		fmt.Fprintf(r.stderr, "Unknown command ':%s'. Enter ':help' to list the commands.\n", name)
+		return
+	}
+
+	err := command.Run(r.stdout, args)
+	if fileErr, ok := err.(*fileError); ok {
+		source, _ := os.ReadFile(fileErr.path)
+		WriteDiagnostics(r.stderr, r.format, fileErr.err, string(source), fileErr.path)
+	} else if err != nil {
+		WriteDiagnostics(r.stderr, r.format, err, args, "")
+	}
+}
+
+// Create the ":help" command, which lists the commands of the REPL.
+func helpCommand(r *Repl) *ReplCommand {
+	return &ReplCommand{
+		Name: "help",
+		Help: "List the commands.",
+		Run: func(out io.Writer, _ string) error {
+			for _, name := range r.commandNames {
+				command := r.commands[name]
+				usage := strings.TrimSpace(":" + command.Name + " " + command.Args)
+				fmt.Fprintf(out, "%-20s %s\n", usage, command.Help)
+			}
+			return nil
+		},
+	}
+}
+
+// Create the ":save" command, which writes the source code entered successfully to a file.
+func saveCommand(r *Repl) *ReplCommand {
+	return &ReplCommand{
+		Name: "save",
+		Args: "<file>",
+		Help: "Write the source code entered successfully to the file.",
+		Run: func(out io.Writer, path string) error {
+			if path == "" {
+				return errors.New("Expected a file to save to.")
+			}
+
+			var source strings.Builder
+			for _, input := range r.inputs {
+				source.WriteString(asStatements(input))
+			}
+			if err := os.WriteFile(path, []byte(source.String()), 0644); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Saved %d inputs to %s.\n", len(r.inputs), path)
+			return nil
+		},
+	}
+}
+
+// Get the input as statements that can be run from a file, adding the semicolon that may be left out
+// after an expression statement at the end of the input, and ending the input with a newline.
+func asStatements(input string) string {
+	trimmed := strings.TrimRight(input, " \t\r\n")
+	tokens, err := scanner.NewScanner(trimmed).ScanAllTokens()
+	if err == nil && len(tokens) > 1 {
+		// The semicolon goes right after the last token, before any comment that follows it.
+		last := tokens[len(tokens)-2]
+		if last.Type != tokentype.SEMICOLON && last.Type != tokentype.RIGHT_BRACE {
+			end := last.Offset + last.Length
+			trimmed = trimmed[:end] + ";" + trimmed[end:]
+		}
+	}
+	return trimmed + "\n"
+}
+
+// Create the ":tokens" command, which prints the tokens that the source code is scanned to.
+func TokensCommand() *ReplCommand {
+	return &ReplCommand{
+		Name: "tokens",
+		Args: "<code>",
+		Help: "Print the tokens of the source code.",
+		Run: func(out io.Writer, source string) error {
+			tokens, err := scanner.NewScanner(source).ScanAllTokens()
+			if err != nil {
+				return err
+			}
+			for _, t := range tokens {
+				fmt.Fprintln(out, t)
+			}
+			return nil
+		},
+	}
+}
+
+// Create the ":ast" command, which prints the AST that the source code is parsed to.
+// The semicolon after a final expression statement may be left out.
+func AstCommand() *ReplCommand {
+	return &ReplCommand{
+		Name: "ast",
+		Args: "<code>",
+		Help: "Print the AST of the source code.",
+		Run: func(out io.Writer, source string) error {
+			programAst, err := astutil.ParseReplInput(source)
+			if err != nil {
+				return err
+			}
+			programAst.Accept(printer.NewAstPrinterWithWriter(out))
+			return nil
+		},
+	}
+}
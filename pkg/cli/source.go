@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// The path that names stdin rather than a file, so that source code can be piped to a command.
+const StdinPath = "-"
+
+// The format that commands write their results in, like the tokens of the scanner.
+type OutputFormat string
+
+const (
+	// Results are written for people.
+	OutputText OutputFormat = "text"
+
+	// Results are written as JSON for tools.
+	OutputJSON OutputFormat = "json"
+)
+
+// The usage of command line flags that set the output format.
+const OutputFormatUsage = "The format that results are written in. One of: 'text', 'json'."
+
+// Get the output format with the given name.
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch format := OutputFormat(name); format {
+	case OutputText, OutputJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown output format '%s', expected one of: '%s', '%s'",
+			name, OutputText, OutputJSON)
+	}
+}
+
+// Read the source code in the file at the path, or from stdin if the path is "-".
+func ReadSource(path string, stdin io.Reader) (string, error) {
+	var source []byte
+	var err error
+	if path == StdinPath {
+		source, err = io.ReadAll(stdin)
+	} else {
+		source, err = os.ReadFile(path)
+	}
+	return string(source), err
+}
+
+// Get the name of the file at the path to report errors in it with, which is "<stdin>" for stdin.
+func SourceName(path string) string {
+	if path == StdinPath {
+		return "<stdin>"
+	}
+	return path
+}
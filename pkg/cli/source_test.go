@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	format, err := ParseOutputFormat("json")
+	assert.Nil(t, err)
+	assert.Equal(t, OutputJSON, format)
+
+	_, err = ParseOutputFormat("xml")
+	assert.EqualError(t, err, "unknown output format 'xml', expected one of: 'text', 'json'")
+}
+
+func TestReadSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.lox")
+	assert.Nil(t, os.WriteFile(path, []byte("print 1;\n"), 0644))
+
+	source, err := ReadSource(path, strings.NewReader("print 2;\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, "print 1;\n", source)
+	assert.Equal(t, path, SourceName(path))
+
+	source, err = ReadSource("-", strings.NewReader("print 2;\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, "print 2;\n", source)
+	assert.Equal(t, "<stdin>", SourceName("-"))
+
+	_, err = ReadSource(filepath.Join(t.TempDir(), "missing.lox"), strings.NewReader(""))
+	assert.Error(t, err)
+}
@@ -0,0 +1,25 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package cli
+
+import "os"
+
+// A terminal that can be switched into raw mode. Raw mode isn't supported on this platform,
+// so no file is a terminal and the REPL always reads plain lines.
+type terminal struct{}
+
+func openTerminal(f *os.File) (*terminal, bool) {
+	return nil, false
+}
+
+func (t *terminal) makeRaw() (func(), error) {
+	return func() {}, nil
+}
+
+func (t *terminal) width() int {
+	return 0
+}
+
+func (t *terminal) withReadTimeout(tenths uint8, f func()) {
+	f()
+}
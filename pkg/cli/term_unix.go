@@ -0,0 +1,87 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package cli
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// A terminal that can be switched into raw mode, where input is read a key at a time without being echoed.
+type terminal struct {
+	fd int
+}
+
+// Get the terminal that the file is connected to, or false if the file isn't a terminal.
+func openTerminal(f *os.File) (*terminal, bool) {
+	fd := int(f.Fd())
+	if _, err := getTermios(fd); err != nil {
+		return nil, false
+	}
+	return &terminal{fd: fd}, true
+}
+
+// Switch the terminal into raw mode, returning a function that restores the mode it was in before.
+func (t *terminal) makeRaw() (func(), error) {
+	old, err := getTermios(t.fd)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := *old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := setTermios(t.fd, &raw); err != nil {
+		return nil, err
+	}
+	return func() { setTermios(t.fd, old) }, nil
+}
+
+// Get the number of columns of the terminal, or 0 if it's unknown.
+func (t *terminal) width() int {
+	var size struct{ rows, columns, xPixels, yPixels uint16 }
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(t.fd), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size))); errno != 0 {
+		return 0
+	}
+	return int(size.columns)
+}
+
+// Run the function with reads from the terminal in raw mode ending without input once none has
+// arrived for the timeout, in tenths of a second, rather than waiting for a key to be pressed.
+func (t *terminal) withReadTimeout(tenths uint8, f func()) {
+	old, err := getTermios(t.fd)
+	if err != nil {
+		f()
+		return
+	}
+	timed := *old
+	timed.Cc[syscall.VMIN] = 0
+	timed.Cc[syscall.VTIME] = tenths
+	if err := setTermios(t.fd, &timed); err != nil {
+		f()
+		return
+	}
+	defer setTermios(t.fd, old)
+	f()
+}
+
+func getTermios(fd int) (*syscall.Termios, error) {
+	termios := &syscall.Termios{}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), ioctlGetTermios, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return nil, errno
+	}
+	return termios, nil
+}
+
+func setTermios(fd int, termios *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), ioctlSetTermios, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -1,5 +1,7 @@
 package conversion
 
+import "fmt"
+
 // Return true if val is "truthy", otherwise return false.
 func IsTruthy(val interface{}) bool {
 	if val == nil {
@@ -45,3 +47,26 @@ func ToFloat(val interface{}) (float64, bool) {
 		return 0, false
 	}
 }
+
+// Convert val to a string the same way it's printed.
+func ToString(val interface{}) string {
+	if str, ok := val.(string); ok {
+		return str
+	}
+	return fmt.Sprint(val)
+}
+
+// A value that contains other values, like a list, which may contain itself through the values it contains.
+type Container interface {
+	// Convert the value to a string, abbreviating the containers in visiting, which are already being converted.
+	StringVisiting(visiting map[interface{}]bool) string
+}
+
+// Convert val to a string like ToString, abbreviating the containers in visiting so that a container that
+// contains itself, directly or through other containers, is converted without recursing forever.
+func ToStringVisiting(val interface{}, visiting map[interface{}]bool) string {
+	if container, ok := val.(Container); ok {
+		return container.StringVisiting(visiting)
+	}
+	return ToString(val)
+}
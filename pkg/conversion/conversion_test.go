@@ -128,3 +128,30 @@ func TestToFloat_StringInvalid(t *testing.T) {
 	assert.False(t, ok)
 	assert.Zero(t, val)
 }
+
+func TestToString(t *testing.T) {
+	assert.Equal(t, "hello", ToString("hello"))
+	assert.Equal(t, "3", ToString(3.0))
+	assert.Equal(t, "2.5", ToString(2.5))
+	assert.Equal(t, "true", ToString(true))
+}
+
+type nested struct {
+	inner interface{}
+}
+
+func (n *nested) StringVisiting(visiting map[interface{}]bool) string {
+	if visiting[n] {
+		return "..."
+	}
+	visiting[n] = true
+	defer delete(visiting, n)
+	return "(" + ToStringVisiting(n.inner, visiting) + ")"
+}
+
+func TestToStringVisiting_AbbreviatesContainersBeingConverted(t *testing.T) {
+	outer := &nested{}
+	outer.inner = &nested{inner: outer}
+	assert.Equal(t, "((...))", ToStringVisiting(outer, make(map[interface{}]bool)))
+	assert.Equal(t, "abc", ToStringVisiting("abc", make(map[interface{}]bool)))
+}
@@ -0,0 +1,460 @@
+package loxerr
+
+import (
+	"sort"
+	"strings"
+)
+
+// A stable identifier of a kind of error, like "LOX2001". The first digit is the stage the error
+// occurs in: 1 for scanning, 2 for parsing, 3 for static analysis and 4 for running.
+type Code string
+
+const (
+	UnrecognizedCharacter Code = "LOX1001"
+	UnterminatedString    Code = "LOX1002"
+	InvalidEscape         Code = "LOX1003"
+
+	ExpectedToken           Code = "LOX2001"
+	ExpectedExpression      Code = "LOX2002"
+	InvalidAssignmentTarget Code = "LOX2003"
+
+	ReturnFromTopLevel     Code = "LOX3001"
+	ReturnFromInitializer  Code = "LOX3002"
+	LoopControlOutsideLoop Code = "LOX3003"
+	InheritFromSelf        Code = "LOX3004"
+	LocalInOwnInitializer  Code = "LOX3005"
+	ThisOutsideClass       Code = "LOX3006"
+	SuperOutsideClass      Code = "LOX3007"
+	SuperWithoutSuperclass Code = "LOX3008"
+	DuplicateLocal         Code = "LOX3009"
+	LimitExceeded          Code = "LOX3010"
+
+	NativeFailure      Code = "LOX4000"
+	UndefinedVariable  Code = "LOX4001"
+	AlreadyDefined     Code = "LOX4002"
+	InvalidOperand     Code = "LOX4003"
+	NotCallable        Code = "LOX4004"
+	WrongArgCount      Code = "LOX4005"
+	ArgumentType       Code = "LOX4006"
+	UndefinedProperty  Code = "LOX4007"
+	NotAnInstance      Code = "LOX4008"
+	NotIndexable       Code = "LOX4009"
+	InvalidIndex       Code = "LOX4010"
+	EmptyList          Code = "LOX4011"
+	KeyNotFound        Code = "LOX4012"
+	SuperclassNotClass Code = "LOX4013"
+	UncaughtException  Code = "LOX4014"
+	StackOverflow      Code = "LOX4015"
+	StepBudgetExceeded Code = "LOX4016"
+	ModuleNotFound     Code = "LOX4017"
+	ImportCycle        Code = "LOX4018"
+	ModuleFailed       Code = "LOX4019"
+)
+
+// The description of a kind of error in the catalog.
+type CatalogEntry struct {
+	Code  Code
+	Stage Stage
+
+	// A one-line summary of the error, and a long-form explanation of its causes and how to fix them.
+	Summary     string
+	Explanation string
+}
+
+// An error that knows the kind of error it is.
+type Coded interface {
+	error
+	Code() Code
+}
+
+// Get the catalog entry of the code, ignoring the case of the code.
+func Explain(code Code) (*CatalogEntry, bool) {
+	entry, ok := catalog[Code(strings.ToUpper(string(code)))]
+	return entry, ok
+}
+
+// Get the codes in the catalog in order.
+func Codes() []Code {
+	codes := make([]Code, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i] < codes[j]
+	})
+	return codes
+}
+
+// Get the stage that errors with the code occur in, or "" if the code isn't in the catalog.
+func (c Code) Stage() Stage {
+	if entry, ok := catalog[c]; ok {
+		return entry.Stage
+	}
+	return ""
+}
+
+var catalog = makeCatalog(
+	&CatalogEntry{
+		Code:    UnrecognizedCharacter,
+		Stage:   StageScan,
+		Summary: "Unrecognized character",
+		Explanation: `The source code contains a character that isn't part of any Lox token.
+
+Identifiers may only contain ASCII letters, digits and underscores, and other characters may only
+appear inside of strings and comments.
+
+    var café = 1; // error: 'é' can't be part of an identifier
+    var cafe = 1; // ok`,
+	},
+	&CatalogEntry{
+		Code:    UnterminatedString,
+		Stage:   StageScan,
+		Summary: "Unterminated string",
+		Explanation: `A string literal is missing its closing double quote, so it runs to the end of the source code.
+
+Strings may span multiple lines, so the error is reported where the string starts.
+
+    print "hello;  // error
+    print "hello"; // ok`,
+	},
+	&CatalogEntry{
+		Code:    InvalidEscape,
+		Stage:   StageScan,
+		Summary: "Invalid escape sequence",
+		Explanation: `A backslash in a string literal isn't followed by a valid escape sequence.
+
+The valid escape sequences are \n, \t, \r, \0, \\, \", \$, and \uXXXX or \u{X...}, which hold the
+hexadecimal code point of a Unicode character.
+
+    print "C:\path";   // error: '\p' isn't an escape sequence
+    print "C:\\path";  // ok
+    print "\u{1F600}"; // ok`,
+	},
+	&CatalogEntry{
+		Code:    ExpectedToken,
+		Stage:   StageParse,
+		Summary: "Expected a different token",
+		Explanation: `The parser expected a particular token, like a ';' at the end of a statement or a ')' closing
+a call, but found a different one. The message names the expected token.
+
+The mistake is often just before the token the error is reported at.
+
+    print 1 + 2   // error: expected ';' after the expression
+    print 1 + 2;  // ok`,
+	},
+	&CatalogEntry{
+		Code:    ExpectedExpression,
+		Stage:   StageParse,
+		Summary: "Expected an expression",
+		Explanation: `The parser expected an expression, like a literal, a variable or a call, but found a token
+that can't start one.
+
+    var a = ;  // error
+    var a = 1; // ok`,
+	},
+	&CatalogEntry{
+		Code:    InvalidAssignmentTarget,
+		Stage:   StageParse,
+		Summary: "Invalid assignment target",
+		Explanation: `The left-hand side of an assignment isn't something that can be assigned to. Only variables,
+properties and list or map elements can be assigned to.
+
+    1 + a = 2;    // error
+    a = 2;        // ok
+    point.x = 2;  // ok
+    list[0] = 2;  // ok`,
+	},
+	&CatalogEntry{
+		Code:    ReturnFromTopLevel,
+		Stage:   StageAnalyze,
+		Summary: "Return outside of a function",
+		Explanation: `A return statement appears outside of any function. Only functions and methods can return.
+
+    return 1;                   // error
+    fun one() { return 1; }     // ok`,
+	},
+	&CatalogEntry{
+		Code:    ReturnFromInitializer,
+		Stage:   StageAnalyze,
+		Summary: "Return value from a constructor",
+		Explanation: `A constructor returns a value. A class's init method always returns the new instance, so it may
+only use return without a value to end early.
+
+    class Point { init(x) { this.x = x; return x; } } // error
+    class Point { init(x) { this.x = x; return; } }   // ok`,
+	},
+	&CatalogEntry{
+		Code:    LoopControlOutsideLoop,
+		Stage:   StageAnalyze,
+		Summary: "Break or continue outside of a loop",
+		Explanation: `A break or continue statement appears outside of any while or for loop in the same function.
+Functions declared inside of a loop can't break out of it.
+
+    break;                              // error
+    while (true) { break; }             // ok
+    while (true) { fun f() { break; } } // error`,
+	},
+	&CatalogEntry{
+		Code:    InheritFromSelf,
+		Stage:   StageAnalyze,
+		Summary: "Class inherits from itself",
+		Explanation: `A class names itself as its superclass.
+
+    class A < A {}  // error
+    class B < A {}  // ok`,
+	},
+	&CatalogEntry{
+		Code:    LocalInOwnInitializer,
+		Stage:   StageAnalyze,
+		Summary: "Local variable read in its own initializer",
+		Explanation: `The initializer of a local variable refers to the variable being declared, which has no value yet.
+To refer to an outer variable with the same name, declare the new variable with a different name.
+
+    var a = 1;
+    { var a = a + 1; }  // error
+    { var b = a + 1; }  // ok`,
+	},
+	&CatalogEntry{
+		Code:    ThisOutsideClass,
+		Stage:   StageAnalyze,
+		Summary: "'this' outside of a class",
+		Explanation: `The 'this' keyword appears outside of any method, where there is no instance for it to refer to.
+
+    print this;                          // error
+    class A { name() { return this; } }  // ok`,
+	},
+	&CatalogEntry{
+		Code:    SuperOutsideClass,
+		Stage:   StageAnalyze,
+		Summary: "'super' outside of a class",
+		Explanation: `The 'super' keyword appears outside of any method, where there is no superclass for it to refer to.
+
+    super.init();  // error`,
+	},
+	&CatalogEntry{
+		Code:    SuperWithoutSuperclass,
+		Stage:   StageAnalyze,
+		Summary: "'super' in a class with no superclass",
+		Explanation: `The 'super' keyword appears in a method of a class that doesn't inherit from another class.
+
+    class A { f() { super.f(); } }      // error
+    class B < A { f() { super.f(); } }  // ok`,
+	},
+	&CatalogEntry{
+		Code:    DuplicateLocal,
+		Stage:   StageAnalyze,
+		Summary: "Local variable declared twice",
+		Explanation: `Two local variables with the same name are declared in the same scope. A local variable may
+only be shadowed by one declared in a nested scope.
+
+    { var a = 1; var a = 2; }    // error
+    { var a = 1; { var a = 2; } } // ok`,
+	},
+	&CatalogEntry{
+		Code:    LimitExceeded,
+		Stage:   StageAnalyze,
+		Summary: "Compiler limit exceeded",
+		Explanation: `The program exceeds a limit of the bytecode compiler, such as the number of arguments of a call,
+parameters of a function, local variables of a function, elements of a list literal or constants
+of a chunk of code. The message names the limit.
+
+Split large functions and literals into smaller ones.`,
+	},
+	&CatalogEntry{
+		Code:    NativeFailure,
+		Stage:   StageRuntime,
+		Summary: "Native function failed",
+		Explanation: `A function implemented outside of Lox, such as a function registered by a program embedding
+golox, failed with an error of its own. The message is the one given by the function.`,
+	},
+	&CatalogEntry{
+		Code:    UndefinedVariable,
+		Stage:   StageRuntime,
+		Summary: "Undefined variable",
+		Explanation: `A variable is read or assigned before it's declared. Assigning to a variable doesn't declare it.
+
+    count = 1;      // error
+    var count = 1;  // ok`,
+	},
+	&CatalogEntry{
+		Code:    AlreadyDefined,
+		Stage:   StageRuntime,
+		Summary: "Name already defined",
+		Explanation: `A variable, function, class or import is declared with a name that is already declared in the same
+scope by the tree-walking interpreter.
+
+    var a = 1;
+    fun a() {}  // error`,
+	},
+	&CatalogEntry{
+		Code:    InvalidOperand,
+		Stage:   StageRuntime,
+		Summary: "Invalid operand",
+		Explanation: `An operator is applied to values of types it doesn't support. Arithmetic and comparison need
+numbers, '+' also concatenates a string with any value, and unary '-' needs a number.
+
+    print 1 - "one";  // error
+    print 1 + "one";  // ok: prints "1one"
+    print -nil;       // error`,
+	},
+	&CatalogEntry{
+		Code:    NotCallable,
+		Stage:   StageRuntime,
+		Summary: "Value is not callable",
+		Explanation: `A value that isn't a function, class or method is called.
+
+    var a = 1;
+    a();  // error`,
+	},
+	&CatalogEntry{
+		Code:    WrongArgCount,
+		Stage:   StageRuntime,
+		Summary: "Wrong number of arguments",
+		Explanation: `A function is called with a different number of arguments than it has parameters. Calling a class
+passes the arguments to its init method.
+
+    fun add(a, b) { return a + b; }
+    add(1);     // error
+    add(1, 2);  // ok`,
+	},
+	&CatalogEntry{
+		Code:    ArgumentType,
+		Stage:   StageRuntime,
+		Summary: "Argument of the wrong type",
+		Explanation: `A native function is called with an argument of a type it doesn't accept. The message names the
+argument and the type it must have.
+
+    math.sqrt("4");  // error
+    math.sqrt(4);    // ok`,
+	},
+	&CatalogEntry{
+		Code:    UndefinedProperty,
+		Stage:   StageRuntime,
+		Summary: "Undefined property",
+		Explanation: `A property that isn't defined is read from an instance, class, list, map, error or module. Instances
+have the fields assigned to them and the methods of their class, and modules have the globals they
+declare.
+
+    class A {}
+    print A().name;  // error`,
+	},
+	&CatalogEntry{
+		Code:    NotAnInstance,
+		Stage:   StageRuntime,
+		Summary: "Property of a value without properties",
+		Explanation: `A property is read from or assigned to a value that has no properties, like a number or a string.
+Only instances and classes can have fields assigned to them.
+
+    var a = 1;
+    a.b = 2;  // error`,
+	},
+	&CatalogEntry{
+		Code:    NotIndexable,
+		Stage:   StageRuntime,
+		Summary: "Value is not indexable",
+		Explanation: `A value other than a list or a map is indexed with square brackets.
+
+    var a = 1;
+    print a[0];  // error`,
+	},
+	&CatalogEntry{
+		Code:    InvalidIndex,
+		Stage:   StageRuntime,
+		Summary: "Invalid list index",
+		Explanation: `A list is indexed or sliced with an index that isn't a whole number, or that is out of the list's
+bounds. Indexes start at 0.
+
+    var list = [1, 2];
+    print list[1];    // ok
+    print list[2];    // error
+    print list[0.5];  // error`,
+	},
+	&CatalogEntry{
+		Code:    EmptyList,
+		Stage:   StageRuntime,
+		Summary: "Pop from an empty list",
+		Explanation: `The last element of a list with no elements is popped. Check the length of the list first.
+
+    var list = [];
+    list.pop();                          // error
+    if (list.len() > 0) { list.pop(); }  // ok`,
+	},
+	&CatalogEntry{
+		Code:    KeyNotFound,
+		Stage:   StageRuntime,
+		Summary: "Map key not found",
+		Explanation: `A map is indexed with a key that it doesn't contain. Check for the key with the map's has method first.
+
+    var ages = {"a": 1};
+    print ages["b"];                           // error
+    if (ages.has("b")) { print ages["b"]; }    // ok`,
+	},
+	&CatalogEntry{
+		Code:    SuperclassNotClass,
+		Stage:   StageRuntime,
+		Summary: "Superclass is not a class",
+		Explanation: `A class inherits from a value that isn't a class.
+
+    var A = 1;
+    class B < A {}  // error`,
+	},
+	&CatalogEntry{
+		Code:    UncaughtException,
+		Stage:   StageRuntime,
+		Summary: "Uncaught exception",
+		Explanation: `A value is thrown and no try statement catches it. Runtime errors that aren't caught are reported
+with their own codes instead.
+
+    throw "oops";                                 // error
+    try { throw "oops"; } catch (e) { print e; }  // ok`,
+	},
+	&CatalogEntry{
+		Code:    StackOverflow,
+		Stage:   StageRuntime,
+		Summary: "Stack overflow",
+		Explanation: `Calls are nested deeper than the maximum call depth, usually because of recursion that never stops.
+The maximum depth is set with the interpreter's --max-depth flag.
+
+    fun forever(n) { return forever(n + 1); }
+    forever(0);  // error`,
+	},
+	&CatalogEntry{
+		Code:    StepBudgetExceeded,
+		Stage:   StageRuntime,
+		Summary: "Step budget exceeded",
+		Explanation: `The program ran more loop iterations than its step budget allows, which is set with the
+interpreter's --step-budget flag. This error can't be caught, so that programs can't keep running
+after it.`,
+	},
+	&CatalogEntry{
+		Code:    ModuleNotFound,
+		Stage:   StageRuntime,
+		Summary: "Module not found",
+		Explanation: `An imported module isn't found relative to the importing file, or in any of the directories listed
+in the GOLOX_PATH environment variable.
+
+    import "missing.lox" as missing;  // error`,
+	},
+	&CatalogEntry{
+		Code:    ImportCycle,
+		Stage:   StageRuntime,
+		Summary: "Import cycle",
+		Explanation: `A module imports itself, directly or through the modules it imports. The message lists the modules
+of the cycle. Move the code they share to a module of its own.`,
+	},
+	&CatalogEntry{
+		Code:    ModuleFailed,
+		Stage:   StageRuntime,
+		Summary: "Error in an imported module",
+		Explanation: `An imported module failed to scan, parse, analyze or run. The message includes the error of
+the module.`,
+	},
+)
+
+func makeCatalog(entries ...*CatalogEntry) map[Code]*CatalogEntry {
+	catalog := make(map[Code]*CatalogEntry)
+	for _, entry := range entries {
+		catalog[entry.Code] = entry
+	}
+	return catalog
+}
@@ -0,0 +1,50 @@
+package loxerr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kaschnit/golox/pkg/token"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog_EntriesMatchTheirCodes(t *testing.T) {
+	stageDigits := map[Stage]string{
+		StageScan:    "1",
+		StageParse:   "2",
+		StageAnalyze: "3",
+		StageRuntime: "4",
+	}
+	for _, code := range Codes() {
+		entry, ok := Explain(code)
+		assert.True(t, ok, code)
+		assert.Equal(t, code, entry.Code)
+		assert.Regexp(t, `^LOX\d{4}$`, string(code))
+		assert.Equal(t, stageDigits[entry.Stage], string(code)[3:4], code)
+		assert.NotEmpty(t, entry.Summary, code)
+		assert.NotEmpty(t, strings.TrimSpace(entry.Explanation), code)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	entry, ok := Explain("lox2001")
+	assert.True(t, ok)
+	assert.Equal(t, ExpectedToken, entry.Code)
+	assert.Equal(t, StageParse, ExpectedToken.Stage())
+
+	_, ok = Explain("LOX9999")
+	assert.False(t, ok)
+	assert.Equal(t, Stage(""), Code("LOX9999").Stage())
+}
+
+func TestRuntimeFrom(t *testing.T) {
+	bracket := &token.Token{Type: tokentype.LEFT_BRACKET, Lexeme: "[", Line: 1}
+	err := RuntimeFrom(bracket, Errorf(KeyNotFound, "Key '%s' not found in map.", "a"))
+	assert.Equal(t, KeyNotFound, err.Code())
+	assert.Equal(t, "Key 'a' not found in map.", err.Message())
+
+	err = RuntimeFrom(bracket, errors.New("failed"))
+	assert.Equal(t, NativeFailure, err.Code())
+}
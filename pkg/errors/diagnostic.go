@@ -0,0 +1,164 @@
+package loxerr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// A description of an error for tools to read, which encodes as a JSON object.
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Stage    Stage  `json:"stage,omitempty"`
+	Code     Code   `json:"code,omitempty"`
+	Message  string `json:"message"`
+
+	// The location of the error, where the line and column start at 1 and are 0 if they're unknown.
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+
+	// The calls that were in progress when a runtime error occurred, innermost first.
+	Trace []StackFrame `json:"trace,omitempty"`
+}
+
+// Describe the error for tools to read, with one diagnostic per error of a multierror.
+// Errors are located in the file, unless they're runtime errors that occurred in a function of another file.
+// An error importing a module is described by the errors of the module, located in the module's file.
+func Diagnostics(err error, filename string) []Diagnostic {
+	if multi, ok := err.(*multierror.Error); ok {
+		diagnostics := make([]Diagnostic, 0, len(multi.Errors))
+		for _, inner := range multi.Errors {
+			diagnostics = append(diagnostics, Diagnostics(inner, filename)...)
+		}
+		return diagnostics
+	}
+	var moduleErr *ModuleError
+	if errors.As(err, &moduleErr) {
+		return Diagnostics(moduleErr.Err, moduleErr.File)
+	}
+
+	d := Diagnostic{
+		Severity: "error",
+		Message:  err.Error(),
+		File:     filename,
+	}
+	if staged, ok := err.(Staged); ok {
+		d.Stage = staged.Stage()
+	}
+	if coded, ok := err.(Coded); ok {
+		d.Code = coded.Code()
+	}
+
+	switch e := err.(type) {
+	case *LoxErrorAtToken:
+		d.Message = e.Message()
+	case *LoxErrorAtLine:
+		d.Message, d.Line = e.Message(), e.Line()
+	case *LoxRuntimeError:
+		d.Message, d.Trace = e.Message(), e.Trace
+		if len(e.Trace) > 0 && e.Trace[0].File != "" {
+			d.File = e.Trace[0].File
+		}
+	}
+
+	// The line is the one that the span starts at, which the column is counted on.
+	if spanned, ok := err.(Spanned); ok {
+		if span, ok := spanned.Span(); ok {
+			if span.Line > 0 {
+				d.Line = span.Line
+			}
+			d.Column = span.Column
+		}
+	}
+	return []Diagnostic{d}
+}
+
+// Describe the error along with the line of source code it occurred at, underlining the span
+// of the error with "^~~~". The file name is shown when it isn't empty. Errors that don't know
+// their span, or whose span isn't in the source code, are described by their message alone.
+// The message is followed by the error's code when it has one, which "golox explain" describes.
+// Each error of a multierror is described in turn. Runtime errors are followed by their stack trace.
+// An error importing a module is described by the errors of the module, shown with the module's source code.
+func Render(err error, source string, filename string) string {
+	if multi, ok := err.(*multierror.Error); ok {
+		rendered := make([]string, len(multi.Errors))
+		for i, inner := range multi.Errors {
+			rendered[i] = Render(inner, source, filename)
+		}
+		return strings.Join(rendered, "\n")
+	}
+	var moduleErr *ModuleError
+	if errors.As(err, &moduleErr) {
+		return Render(moduleErr.Err, moduleErr.Source, moduleErr.File)
+	}
+
+	rendered := err.Error()
+	if coded, ok := err.(Coded); ok && coded.Code() != "" {
+		rendered += fmt.Sprintf(" [%s]", coded.Code())
+	}
+	if spanned, ok := err.(Spanned); ok {
+		if span, ok := spanned.Span(); ok && isSpanOf(span, source) {
+			rendered += "\n" + renderSnippet(span, source, filename)
+		}
+	}
+	if runtimeErr, ok := err.(*LoxRuntimeError); ok && len(runtimeErr.Trace) > 0 {
+		rendered += "\n" + runtimeErr.StackTrace()
+	}
+	return rendered
+}
+
+// Check whether the span is within the source code and covers the text it expects.
+func isSpanOf(span Span, source string) bool {
+	if span.Offset < 0 || span.Length < 0 || span.Offset+span.Length > len(source) {
+		return false
+	}
+	return span.Length == 0 || source[span.Offset:span.Offset+span.Length] == span.Text
+}
+
+// Render the line that the span starts on with the span underlined, preceded by the span's location.
+func renderSnippet(span Span, source string, filename string) string {
+	lineStart := strings.LastIndex(source[:span.Offset], "\n") + 1
+	lineEnd := len(source)
+	if i := strings.Index(source[span.Offset:], "\n"); i >= 0 {
+		lineEnd = span.Offset + i
+	}
+	line := strings.Count(source[:lineStart], "\n") + 1
+	column := utf8.RuneCountInString(source[lineStart:span.Offset]) + 1
+
+	// Spans that continue past the end of the line are underlined up to the end of the line.
+	spanEnd := span.Offset + span.Length
+	if spanEnd > lineEnd {
+		spanEnd = lineEnd
+	}
+	width := utf8.RuneCountInString(source[span.Offset:spanEnd])
+	if width < 1 {
+		width = 1
+	}
+
+	// Tabs are kept in the indentation of the underline so that it lines up with the source code.
+	var indent strings.Builder
+	for _, char := range source[lineStart:span.Offset] {
+		if char == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+	}
+
+	lineNumber := strconv.Itoa(line)
+	gutter := strings.Repeat(" ", len(lineNumber))
+
+	var b strings.Builder
+	if filename != "" {
+		fmt.Fprintf(&b, "%s--> %s:%d:%d\n", gutter, filename, line, column)
+	}
+	fmt.Fprintf(&b, "%s |\n", gutter)
+	fmt.Fprintf(&b, "%s | %s\n", lineNumber, strings.TrimSuffix(source[lineStart:lineEnd], "\r"))
+	fmt.Fprintf(&b, "%s | %s^%s", gutter, indent.String(), strings.Repeat("~", width-1))
+	return b.String()
+}
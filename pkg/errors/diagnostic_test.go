@@ -0,0 +1,158 @@
+package loxerr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/kaschnit/golox/pkg/token"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+	"github.com/stretchr/testify/assert"
+)
+
+const source = "var a = 1;\nprint a + \"abc\";\n"
+
+func TestRender_UnderlinesToken(t *testing.T) {
+	err := Runtime(&token.Token{Type: tokentype.STRING, Lexeme: `"abc"`, Line: 2, Column: 11, Offset: 21, Length: 5}, InvalidOperand, "Bad operand.")
+	assert.Equal(t, `[line 2] Runtime error at '"abc"': Bad operand. [LOX4003]
+ --> main.lox:2:11
+  |
+2 | print a + "abc";
+  |           ^~~~~`, Render(err, source, "main.lox"))
+}
+
+func TestRender_WithoutFilename(t *testing.T) {
+	err := AtToken(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "a", Line: 1, Column: 5, Offset: 4, Length: 1}, ExpectedToken, "Oops.")
+	assert.Equal(t, `[line 1] Error at 'a': Oops. [LOX2001]
+  |
+1 | var a = 1;
+  |     ^`, Render(err, source, ""))
+}
+
+func TestRender_EmptySpan(t *testing.T) {
+	err := AtToken(&token.Token{Type: tokentype.EOF, Lexeme: "", Line: 3, Column: 1, Offset: len(source), Length: 0}, ExpectedToken, "Expected ';'.")
+	assert.Equal(t, `[line 3] Error at end: Expected ';'. [LOX2001]
+  |
+3 | 
+  | ^`, Render(err, source, ""))
+}
+
+func TestRender_KeepsTabsInIndentation(t *testing.T) {
+	err := AtSpan(1, Span{Offset: 3, Length: 1, Text: "@"}, UnrecognizedCharacter, "Unrecognized character @")
+	assert.Equal(t, "[line 1] Error: Unrecognized character @ [LOX1001]\n  |\n1 | \tx @\n  | \t  ^", Render(err, "\tx @", ""))
+}
+
+func TestRender_SpanNotInSource(t *testing.T) {
+	// Errors from other source code, such as imported modules, aren't shown with this source code.
+	err := Runtime(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "b", Line: 1, Offset: 4, Length: 1}, UndefinedVariable, "Oops.")
+	assert.Equal(t, "[line 1] Runtime error at 'b': Oops. [LOX4001]", Render(err, source, ""))
+
+	err = Runtime(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "b", Line: 9, Offset: 400, Length: 1}, UndefinedVariable, "Oops.")
+	assert.Equal(t, "[line 9] Runtime error at 'b': Oops. [LOX4001]", Render(err, source, ""))
+
+	assert.Equal(t, "[line 1] Error: Oops.", Render(AtLine(1, "", "Oops."), source, ""))
+	assert.Equal(t, "failed", Render(errors.New("failed"), source, ""))
+}
+
+func TestRender_MultipleErrors(t *testing.T) {
+	errs := multierror.Append(
+		AtSpan(1, Span{Offset: 0, Length: 3, Text: "var"}, ExpectedExpression, "First."),
+		AtSpan(2, Span{Offset: 11, Length: 5, Text: "print"}, ExpectedToken, "Second."),
+	)
+	assert.Equal(t, `[line 1] Error: First. [LOX2002]
+  |
+1 | var a = 1;
+  | ^~~
+[line 2] Error: Second. [LOX2001]
+  |
+2 | print a + "abc";
+  | ^~~~~`, Render(errs, source, ""))
+}
+
+func TestRender_StackTrace(t *testing.T) {
+	err := Runtime(&token.Token{Type: tokentype.PLUS, Lexeme: "+", Line: 2, Column: 9, Offset: 19, Length: 1}, InvalidOperand, "Bad operand.")
+	err.Trace = []StackFrame{
+		{Function: "add", File: "main.lox", Line: 2},
+		{Function: "<script>", File: "", Line: 5},
+	}
+	assert.Equal(t, `[line 2] Runtime error at '+': Bad operand. [LOX4003]
+  |
+2 | print a + "abc";
+  |         ^
+    at add (main.lox:2)
+    at <script> (line 5)`, Render(err, source, ""))
+}
+
+func TestStackTrace_ElidesDeepStacks(t *testing.T) {
+	err := Runtime(&token.Token{Type: tokentype.PLUS, Lexeme: "+", Line: 1}, InvalidOperand, "Bad operand.")
+	for i := 0; i < 25; i++ {
+		err.Trace = append(err.Trace, StackFrame{Function: "f", File: "f.lox", Line: i})
+	}
+
+	lines := strings.Split(err.StackTrace(), "\n")
+	assert.Len(t, lines, 21)
+	assert.Equal(t, "    at f (f.lox:9)", lines[9])
+	assert.Equal(t, "    ... 5 more", lines[10])
+	assert.Equal(t, "    at f (f.lox:15)", lines[11])
+	assert.Equal(t, "    at f (f.lox:24)", lines[20])
+}
+
+func TestWithStage_KeepsKnownStages(t *testing.T) {
+	scanErr := WithStage(AtLine(1, UnrecognizedCharacter, "Bad character."), StageScan)
+	parseErr := AtToken(&token.Token{Type: tokentype.SEMICOLON, Lexeme: ";", Line: 1}, ExpectedExpression, "Expected expression.")
+	err := WithStage(multierror.Append(scanErr, parseErr), StageParse)
+
+	errs := err.(*multierror.Error).Errors
+	assert.Equal(t, StageScan, errs[0].(Staged).Stage())
+	assert.Equal(t, StageParse, errs[1].(Staged).Stage())
+	assert.Nil(t, WithStage(nil, StageParse))
+}
+
+func TestDiagnostics(t *testing.T) {
+	parseErr := WithStage(AtToken(&token.Token{Type: tokentype.IDENTIFIER, Lexeme: "a", Line: 1, Column: 5, Offset: 4, Length: 1}, ExpectedToken, "Oops."), StageParse)
+	runtimeErr := Runtime(&token.Token{Type: tokentype.PLUS, Lexeme: "+", Line: 2, Column: 9, Offset: 19, Length: 1}, InvalidOperand, "Bad operand.")
+	runtimeErr.Trace = []StackFrame{
+		{Function: "add", File: "lib.lox", Line: 2},
+		{Function: "<script>", File: "main.lox", Line: 5},
+	}
+
+	assert.Equal(t, []Diagnostic{
+		{Severity: "error", Stage: StageParse, Code: ExpectedToken, Message: "Oops.", File: "main.lox", Line: 1, Column: 5},
+		{Severity: "error", Stage: StageRuntime, Code: InvalidOperand, Message: "Bad operand.", File: "lib.lox", Line: 2, Column: 9, Trace: runtimeErr.Trace},
+		{Severity: "error", Message: "not a lox error", File: "main.lox"},
+	}, Diagnostics(multierror.Append(parseErr, runtimeErr, errors.New("not a lox error")), "main.lox"))
+}
+
+func TestDiagnostics_MultilineString(t *testing.T) {
+	source := "print 1;\nvar x = \"abc\ndef\" +;"
+	err := AtToken(&token.Token{Type: tokentype.STRING, Lexeme: "\"abc\ndef\"", Line: 3, Column: 9, Offset: 17, Length: 9}, ExpectedToken, "Oops.")
+
+	assert.Equal(t, []Diagnostic{
+		{Severity: "error", Stage: StageParse, Code: ExpectedToken, Message: "Oops.", File: "main.lox", Line: 2, Column: 9},
+	}, Diagnostics(err, "main.lox"))
+	assert.Equal(t, `[line 2] Error at '"abc
+def"': Oops. [LOX2001]
+ --> main.lox:2:9
+  |
+2 | var x = "abc
+  |         ^~~~`, Render(err, source, "main.lox"))
+}
+
+func TestDiagnostics_ModuleError(t *testing.T) {
+	moduleSource := "var a = 1;\nvar x = ;\n"
+	parseErr := WithStage(AtToken(&token.Token{Type: tokentype.SEMICOLON, Lexeme: ";", Line: 2, Column: 9, Offset: 19, Length: 1}, ExpectedExpression, "Expected expression."), StageParse)
+	moduleErr := &ModuleError{File: "lib.lox", Source: moduleSource, Err: multierror.Append(parseErr)}
+	importErr := RuntimeFrom(&token.Token{Type: tokentype.STRING, Lexeme: `"lib.lox"`, Line: 1, Column: 8, Offset: 7, Length: 9}, moduleErr)
+
+	assert.Equal(t, ModuleFailed, importErr.Code())
+	assert.Equal(t, "[line 1] Runtime error at '\"lib.lox\"': Error in module 'lib.lox': Expected expression.", importErr.Error())
+	assert.Equal(t, []Diagnostic{
+		{Severity: "error", Stage: StageParse, Code: ExpectedExpression, Message: "Expected expression.", File: "lib.lox", Line: 2, Column: 9},
+	}, Diagnostics(importErr, "main.lox"))
+	assert.Equal(t, `[line 2] Error at ';': Expected expression. [LOX2002]
+ --> lib.lox:2:9
+  |
+2 | var x = ;
+  |         ^`, Render(importErr, `import "lib.lox" as lib;`, "main.lox"))
+}
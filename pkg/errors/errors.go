@@ -2,6 +2,9 @@ package loxerr
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
 
 	"github.com/kaschnit/golox/pkg/token"
 	"github.com/kaschnit/golox/pkg/token/tokentype"
@@ -14,36 +17,227 @@ func getWhere(t *token.Token) string {
 	return fmt.Sprintf("at '%s'", t.Lexeme)
 }
 
+// The stage of running a program that an error occurred in.
+type Stage string
+
+const (
+	StageScan    Stage = "scan"
+	StageParse   Stage = "parse"
+	StageAnalyze Stage = "analyze"
+	StageRuntime Stage = "runtime"
+)
+
+// An error that may know the stage it occurred in.
+type Staged interface {
+	error
+	Stage() Stage
+}
+
+// Mark the errors in err, including each error of a multierror, as having occurred in the stage.
+// Errors that already know their stage keep it. Returns err.
+func WithStage(err error, stage Stage) error {
+	switch e := err.(type) {
+	case *multierror.Error:
+		for _, inner := range e.Errors {
+			WithStage(inner, stage)
+		}
+	case *LoxErrorAtToken:
+		if e.stage == "" {
+			e.stage = stage
+		}
+	case *LoxErrorAtLine:
+		if e.stage == "" {
+			e.stage = stage
+		}
+	}
+	return err
+}
+
+// The part of the source code that an error occurred at.
+type Span struct {
+	// The position of the span in the source code in bytes, and its length in bytes.
+	Offset int
+	Length int
+
+	// The line that the span starts at and the column that it starts at in characters, both starting
+	// at 1, or 0 if they're unknown.
+	Line   int
+	Column int
+
+	// The source code expected in the span, which is used to check that the span belongs to the
+	// source code it's shown with. Empty if the span has no length.
+	Text string
+}
+
+// An error that may know the span of the source code it occurred at.
+type Spanned interface {
+	error
+	Span() (Span, bool)
+}
+
+// Get the span of the source code that the token was scanned from.
+func tokenSpan(t *token.Token) Span {
+	if t.Length == 0 {
+		return Span{Offset: t.Offset, Length: 0, Line: t.Line, Column: t.Column, Text: ""}
+	}
+	return Span{Offset: t.Offset, Length: t.Length, Line: tokenStartLine(t), Column: t.Column, Text: t.Lexeme}
+}
+
+// Get the line that the token starts at. The line of a token is the line it ends at, which is a
+// later line for a string that spans lines.
+func tokenStartLine(t *token.Token) int {
+	return t.Line - strings.Count(t.Lexeme, "\n")
+}
+
+// The number of frames of a stack trace that are described before the rest are elided.
+const maxTraceFrames = 20
+
+// A call that was in progress when a runtime error occurred.
+type StackFrame struct {
+	// The name of the called function, or "<script>" for the top level of a script.
+	Function string `json:"function"`
+
+	// The file that the function is declared in, or "" if it wasn't declared in a file.
+	File string `json:"file,omitempty"`
+
+	// The line that the function was executing.
+	Line int `json:"line"`
+}
+
+func (f StackFrame) String() string {
+	if f.File == "" {
+		return fmt.Sprintf("at %s (line %d)", f.Function, f.Line)
+	}
+	return fmt.Sprintf("at %s (%s:%d)", f.Function, f.File, f.Line)
+}
+
+// An error message of a known kind that isn't located in the source code yet, which is
+// returned by code that doesn't know where it's called from.
+type LoxError struct {
+	code    Code
+	message string
+}
+
+func New(code Code, message string) *LoxError {
+	return &LoxError{
+		code:    code,
+		message: message,
+	}
+}
+
+// Create a LoxError with a message formatted like fmt.Sprintf.
+func Errorf(code Code, format string, args ...interface{}) *LoxError {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+func (e *LoxError) Code() Code {
+	return e.code
+}
+
+func (e *LoxError) Error() string {
+	return e.message
+}
+
 type LoxErrorAtToken struct {
 	Token   *token.Token
+	code    Code
 	where   string
 	message string
+	stage   Stage
 }
 
-func AtToken(t *token.Token, message string) *LoxErrorAtToken {
+func AtToken(t *token.Token, code Code, message string) *LoxErrorAtToken {
 	return &LoxErrorAtToken{
 		Token:   t,
+		code:    code,
 		where:   getWhere(t),
 		message: message,
 	}
 }
 
+func (e *LoxErrorAtToken) Code() Code {
+	return e.code
+}
+
+// Get the message of the error without its location.
+func (e *LoxErrorAtToken) Message() string {
+	return e.message
+}
+
+// Get the stage the error occurred in, which is the stage of its code unless it was found in another stage.
+func (e *LoxErrorAtToken) Stage() Stage {
+	if e.stage == "" {
+		return e.code.Stage()
+	}
+	return e.stage
+}
+
+func (e *LoxErrorAtToken) Span() (Span, bool) {
+	return tokenSpan(e.Token), true
+}
+
 func (e *LoxErrorAtToken) Error() string {
-	return fmt.Sprintf("[line %d] Error %s: %s", e.Token.Line, e.where, e.message)
+	return fmt.Sprintf("[line %d] Error %s: %s", tokenStartLine(e.Token), e.where, e.message)
 }
 
 type LoxErrorAtLine struct {
 	line    int
+	code    Code
 	message string
+	stage   Stage
+
+	// The span of the source code the error occurred at, or nil if it's unknown.
+	span *Span
+}
+
+func AtLine(line int, code Code, message string) *LoxErrorAtLine {
+	return &LoxErrorAtLine{
+		line:    line,
+		code:    code,
+		message: message,
+		span:    nil,
+	}
 }
 
-func AtLine(line int, message string) *LoxErrorAtLine {
+// Create an error at the line that's also located at the span of the source code.
+func AtSpan(line int, span Span, code Code, message string) *LoxErrorAtLine {
 	return &LoxErrorAtLine{
 		line:    line,
+		code:    code,
 		message: message,
+		span:    &span,
 	}
 }
 
+func (e *LoxErrorAtLine) Code() Code {
+	return e.code
+}
+
+// Get the line the error occurred at.
+func (e *LoxErrorAtLine) Line() int {
+	return e.line
+}
+
+// Get the message of the error without its location.
+func (e *LoxErrorAtLine) Message() string {
+	return e.message
+}
+
+// Get the stage the error occurred in, which is the stage of its code unless it was found in another stage.
+func (e *LoxErrorAtLine) Stage() Stage {
+	if e.stage == "" {
+		return e.code.Stage()
+	}
+	return e.stage
+}
+
+func (e *LoxErrorAtLine) Span() (Span, bool) {
+	if e.span == nil {
+		return Span{}, false
+	}
+	return *e.span, true
+}
+
 func (e *LoxErrorAtLine) Error() string {
 	return fmt.Sprintf("[line %d] Error: %s", e.line, e.message)
 }
@@ -62,18 +256,110 @@ func (e *LoxInternalError) Error() string {
 
 type LoxRuntimeError struct {
 	Token   *token.Token
+	code    Code
 	where   string
 	message string
+
+	// The calls that were in progress when the error occurred, innermost first.
+	// Empty if the error didn't occur inside a function.
+	Trace []StackFrame
+
+	// The error returned by Go code that the error was created from, if any.
+	cause error
 }
 
-func Runtime(t *token.Token, message string) *LoxRuntimeError {
+func Runtime(t *token.Token, code Code, message string) *LoxRuntimeError {
 	return &LoxRuntimeError{
 		Token:   t,
+		code:    code,
 		where:   getWhere(t),
 		message: message,
 	}
 }
 
+// Create a runtime error at the token from an error returned by Go code,
+// keeping its code if it has one.
+func RuntimeFrom(t *token.Token, err error) *LoxRuntimeError {
+	code := NativeFailure
+	if coded, ok := err.(Coded); ok {
+		code = coded.Code()
+	}
+	runtimeErr := Runtime(t, code, err.Error())
+	runtimeErr.cause = err
+	return runtimeErr
+}
+
+// Get the error returned by Go code that the error was created from, or nil if there isn't one.
+func (e *LoxRuntimeError) Unwrap() error {
+	return e.cause
+}
+
+func (e *LoxRuntimeError) Code() Code {
+	return e.code
+}
+
+// Get the message of the error without its location.
+func (e *LoxRuntimeError) Message() string {
+	return e.message
+}
+
+func (e *LoxRuntimeError) Stage() Stage {
+	return StageRuntime
+}
+
+func (e *LoxRuntimeError) Span() (Span, bool) {
+	return tokenSpan(e.Token), true
+}
+
+// Describe the calls that were in progress when the error occurred, one per line, or "" if there were none.
+// Only the innermost and outermost calls of a deep stack are described.
+func (e *LoxRuntimeError) StackTrace() string {
+	frames := make([]string, 0, len(e.Trace))
+	for i, frame := range e.Trace {
+		if len(e.Trace) > maxTraceFrames && i == maxTraceFrames/2 {
+			frames = append(frames, fmt.Sprintf("    ... %d more", len(e.Trace)-maxTraceFrames))
+		}
+		if len(e.Trace) <= maxTraceFrames || i < maxTraceFrames/2 || i >= len(e.Trace)-maxTraceFrames/2 {
+			frames = append(frames, "    "+frame.String())
+		}
+	}
+	return strings.Join(frames, "\n")
+}
+
 func (e *LoxRuntimeError) Error() string {
-	return fmt.Sprintf("[line %d] Runtime error %s: %s", e.Token.Line, e.where, e.message)
+	return fmt.Sprintf("[line %d] Runtime error %s: %s", tokenStartLine(e.Token), e.where, e.message)
+}
+
+// An error in a module imported by a program. The error is located in the module's source code
+// rather than the program's, so it's described with the module's file and source code.
+type ModuleError struct {
+	// The path of the module's file as it's shown, and its source code, which is empty if it can't be read.
+	File   string
+	Source string
+
+	Err error
+}
+
+func (e *ModuleError) Code() Code {
+	return ModuleFailed
+}
+
+// Get the error of the module.
+func (e *ModuleError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ModuleError) Error() string {
+	return fmt.Sprintf("Error in module '%s': %s", e.File, messageOf(e.Err))
+}
+
+// Get the message of the error without its location, or of the first error of a multierror.
+func messageOf(err error) string {
+	if multi, ok := err.(*multierror.Error); ok && len(multi.Errors) > 0 {
+		err = multi.Errors[0]
+	}
+	if described, ok := err.(interface{ Message() string }); ok {
+		return described.Message()
+	}
+	return err.Error()
 }
@@ -0,0 +1,163 @@
+// Package golox embeds the Lox bytecode VM in Go programs.
+//
+// Lox values are exchanged as Go values: numbers are float64, strings are string, booleans are bool,
+// nil is nil, lists are []Value and maps are map[Value]Value. Functions, classes, instances and other
+// Lox objects are passed around opaquely, so that they can be called or handed back to Lox.
+package golox
+
+import (
+	"context"
+	"io"
+
+	"github.com/kaschnit/golox/pkg/ast/analyzer"
+	"github.com/kaschnit/golox/pkg/ast/astutil"
+	"github.com/kaschnit/golox/pkg/ast/emitter"
+	"github.com/kaschnit/golox/pkg/native"
+	"github.com/kaschnit/golox/pkg/vm"
+)
+
+// A Lox value, represented as described in the package documentation.
+type Value = interface{}
+
+// Configures a VM created by NewVM.
+type Option func(*VM)
+
+// Limit the number of loop iterations each call to Eval may run. A budget of 0 means there is no limit.
+func WithStepBudget(budget int) Option {
+	return func(v *VM) {
+		v.vm.SetStepBudget(budget)
+	}
+}
+
+// Set the maximum depth of nested calls before a stack overflow is reported. The default is vm.FramesMax.
+func WithMaxDepth(depth int) Option {
+	return func(v *VM) {
+		v.vm.SetMaxDepth(depth)
+	}
+}
+
+// Set the directories searched for modules imported by evaluated source code.
+// By default, the directories in the GOLOX_PATH environment variable are searched.
+func WithSearchPath(searchPath []string) Option {
+	return func(v *VM) {
+		v.vm.SetSearchPath(searchPath)
+	}
+}
+
+// Write the output of print statements to stdout instead of the process's standard output.
+func WithStdout(stdout io.Writer) Option {
+	return func(v *VM) {
+		v.vm.SetStdout(stdout)
+	}
+}
+
+// Read the input of the readLine native from stdin instead of the process's standard input.
+func WithStdin(stdin io.Reader) Option {
+	return func(v *VM) {
+		v.vm.SetStdin(stdin)
+	}
+}
+
+// Define the native functions of the registry as globals, in evaluated source code and the modules it imports.
+// Unlike functions created with NewFunction, registered natives receive and return the VM's own Lox values.
+func WithNatives(registry *native.Registry) Option {
+	return func(v *VM) {
+		v.vm.Install(registry)
+	}
+}
+
+// A Lox VM hosted by a Go program. Globals defined by evaluated source code remain available to
+// later calls. A VM must not be used by multiple goroutines at once.
+type VM struct {
+	analyzer *analyzer.AstAnalyzer
+	vm       *vm.VM
+}
+
+// Create a VM configured by the options.
+func NewVM(opts ...Option) *VM {
+	v := &VM{
+		analyzer: analyzer.NewAstAnalyzer(),
+		vm:       vm.NewVM(),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Evaluate the Lox source code, returning the value of its last statement if it's an expression
+// statement, or nil otherwise. Evaluation is stopped with the context's error once the context is done.
+func (v *VM) Eval(ctx context.Context, src string) (Value, error) {
+	programAst, err := astutil.ParseReplInput(src)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := v.analyzer.VisitProgram(programAst); err != nil {
+		return nil, err
+	}
+	script, err := emitter.CompileWithResult(programAst)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := v.vm.Run(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	return toGo(result), nil
+}
+
+// Define the global variable with the given name, converting the Go value to a Lox value.
+func (v *VM) SetGlobal(name string, value Value) error {
+	loxValue, err := toLox(value)
+	if err != nil {
+		return err
+	}
+	v.vm.SetGlobal(name, loxValue)
+	return nil
+}
+
+// Get the value of the global variable with the given name, and whether it's defined.
+func (v *VM) GetGlobal(name string) (Value, bool) {
+	value, ok := v.vm.Global(name)
+	if !ok {
+		return nil, false
+	}
+	return toGo(value), true
+}
+
+// Call the Lox function, class or bound method with the args, returning its result.
+// Functions created with NewFunction may use this to call back into Lox.
+func (v *VM) Call(fn Value, args ...Value) (Value, error) {
+	loxArgs := make([]interface{}, len(args))
+	for i, arg := range args {
+		loxArg, err := toLox(arg)
+		if err != nil {
+			return nil, err
+		}
+		loxArgs[i] = loxArg
+	}
+
+	result, err := v.vm.Call(fn, loxArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return toGo(result), nil
+}
+
+// Create a Lox function that calls the Go function with exactly arity args.
+// Values are converted between Go and Lox in both directions.
+func NewFunction(name string, arity int, fn func(args []Value) (Value, error)) Value {
+	return vm.NewNativeFunction(name, arity, func(loxArgs []interface{}) (interface{}, error) {
+		args := make([]Value, len(loxArgs))
+		for i, arg := range loxArgs {
+			args[i] = toGo(arg)
+		}
+
+		result, err := fn(args)
+		if err != nil {
+			return nil, err
+		}
+		return toLox(result)
+	})
+}
@@ -0,0 +1,226 @@
+package golox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/native"
+	"github.com/kaschnit/golox/test/programs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVM_EvalReturnsLastExpression(t *testing.T) {
+	v := NewVM()
+
+	result, err := v.Eval(context.Background(), "var a = 1; a + 2;")
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, result)
+
+	// Globals persist between evaluations.
+	result, err = v.Eval(context.Background(), `"a is " + a;`)
+	assert.Nil(t, err)
+	assert.Equal(t, "a is 1", result)
+
+	result, err = v.Eval(context.Background(), "var b = a;")
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+}
+
+func TestVM_EvalBareExpression(t *testing.T) {
+	v := NewVM()
+
+	// Like the REPL, the last expression doesn't need a semicolon.
+	result, err := v.Eval(context.Background(), "1 + 2")
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, result)
+
+	result, err = v.Eval(context.Background(), "var a = 4; a * 2")
+	assert.Nil(t, err)
+	assert.Equal(t, 8.0, result)
+}
+
+func TestVM_EvalErrors(t *testing.T) {
+	v := NewVM()
+
+	_, err := v.Eval(context.Background(), "var = 1;")
+	assert.Error(t, err)
+
+	_, err = v.Eval(context.Background(), "missing;")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Variable 'missing' not defined")
+
+	_, err = v.Eval(context.Background(), `throw "oops";`)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Uncaught exception: oops")
+
+	// The VM keeps working after an error.
+	result, err := v.Eval(context.Background(), "1 + 1;")
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, result)
+}
+
+func TestVM_EvalContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := NewVM().Eval(ctx, "try { while (true) {} } catch (e) {}")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestVM_StepBudget(t *testing.T) {
+	_, err := NewVM(WithStepBudget(10)).Eval(context.Background(), "while (true) {}")
+	assert.ErrorContains(t, err, "Step budget of 10 exceeded.")
+}
+
+func TestVM_SearchPath(t *testing.T) {
+	v := NewVM(WithSearchPath([]string{programs.GetPath("modules")}))
+
+	result, err := v.Eval(context.Background(), `import "Geometry.lox" as g; g.area(2);`)
+	assert.Nil(t, err)
+	assert.Equal(t, 12.0, result)
+}
+
+func TestVM_Globals(t *testing.T) {
+	v := NewVM()
+
+	assert.Nil(t, v.SetGlobal("count", 2))
+	assert.Nil(t, v.SetGlobal("names", []string{"a", "b"}))
+	assert.Nil(t, v.SetGlobal("ages", map[string]int{"a": 1}))
+
+	result, err := v.Eval(context.Background(), `count + names.len() + ages["a"];`)
+	assert.Nil(t, err)
+	assert.Equal(t, 5.0, result)
+
+	_, err = v.Eval(context.Background(), `names.push("c"); var doubled = count * 2;`)
+	assert.Nil(t, err)
+
+	names, ok := v.GetGlobal("names")
+	assert.True(t, ok)
+	assert.Equal(t, []Value{"a", "b", "c"}, names)
+
+	doubled, ok := v.GetGlobal("doubled")
+	assert.True(t, ok)
+	assert.Equal(t, 4.0, doubled)
+
+	_, ok = v.GetGlobal("missing")
+	assert.False(t, ok)
+
+	assert.EqualError(t, v.SetGlobal("ch", make(chan int)), "Can't convert a value of type chan int to a Lox value.")
+}
+
+func TestVM_Call(t *testing.T) {
+	v := NewVM()
+	_, err := v.Eval(context.Background(), `
+		fun greet(name) { return "hello " + name; }
+		class Point { init(x, y) { this.x = x; this.y = y; } sum() { return this.x + this.y; } }
+	`)
+	assert.Nil(t, err)
+
+	greet, _ := v.GetGlobal("greet")
+	result, err := v.Call(greet, "world")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", result)
+
+	point, _ := v.GetGlobal("Point")
+	instance, err := v.Call(point, 1, 2)
+	assert.Nil(t, err)
+	assert.Nil(t, v.SetGlobal("p", instance))
+	result, err = v.Eval(context.Background(), "p.sum();")
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, result)
+
+	_, err = v.Call(greet)
+	assert.EqualError(t, err, "Expected 1 args, got 0.")
+
+	_, err = v.Call("greet")
+	assert.ErrorContains(t, err, "not callable")
+}
+
+func TestNewFunction(t *testing.T) {
+	v := NewVM()
+	assert.Nil(t, v.SetGlobal("sum", NewFunction("sum", 1, func(args []Value) (Value, error) {
+		total := 0.0
+		for _, element := range args[0].([]Value) {
+			total += element.(float64)
+		}
+		return total, nil
+	})))
+	assert.Nil(t, v.SetGlobal("each", NewFunction("each", 2, func(args []Value) (Value, error) {
+		for _, element := range args[0].([]Value) {
+			if _, err := v.Call(args[1], element); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})))
+	assert.Nil(t, v.SetGlobal("fail", NewFunction("fail", 0, func(args []Value) (Value, error) {
+		return nil, errors.New("failed")
+	})))
+
+	result, err := v.Eval(context.Background(), "sum([1, 2, 3]);")
+	assert.Nil(t, err)
+	assert.Equal(t, 6.0, result)
+
+	result, err = v.Eval(context.Background(), `
+		var total = 0;
+		each([1, 2, 3], fun (x) { total = total + x; });
+		total;
+	`)
+	assert.Nil(t, err)
+	assert.Equal(t, 6.0, result)
+
+	// Errors from Go functions are runtime errors that scripts can catch.
+	result, err = v.Eval(context.Background(), "var message; try { fail(); } catch (e) { message = e.message; } message;")
+	assert.Nil(t, err)
+	assert.Equal(t, "failed", result)
+
+	// Values thrown by callbacks pass through Go functions to the script.
+	result, err = v.Eval(context.Background(), `
+		var caught;
+		try { each([1], fun (x) { throw x + 1; }); } catch (e) { caught = e; }
+		caught;
+	`)
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, result)
+
+	// Runtime errors in callbacks are reported where they happened, not again at the Go function's call.
+	_, err = v.Eval(context.Background(), `each([1], fun (x) { x.nope; });`)
+	var runtimeErr *loxerr.LoxRuntimeError
+	assert.True(t, errors.As(err, &runtimeErr))
+	assert.Equal(t, loxerr.NotAnInstance, runtimeErr.Code())
+	assert.Equal(t, 1, strings.Count(err.Error(), "Runtime error"))
+	assert.Contains(t, err.Error(), "at 'nope'")
+}
+
+func TestWithNatives(t *testing.T) {
+	registry := native.NewRegistry()
+	registry.MustRegister(&native.Function{
+		Name:   "text.repeat",
+		Params: []native.Param{{Name: "s", Type: native.String}, {Name: "n", Type: native.Number}},
+		Code: func(args []interface{}) (interface{}, error) {
+			return strings.Repeat(args[0].(string), int(args[1].(float64))), nil
+		},
+	})
+	v := NewVM(WithNatives(registry))
+
+	result, err := v.Eval(context.Background(), `text.repeat("ab", 2);`)
+	assert.Nil(t, err)
+	assert.Equal(t, "abab", result)
+
+	_, err = v.Eval(context.Background(), `text.repeat(2, "ab");`)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Argument 1 of 'text.repeat' must be a string, got number.")
+}
+
+func TestVM_StdoutAndStdin(t *testing.T) {
+	var stdout strings.Builder
+	v := NewVM(WithStdout(&stdout), WithStdin(strings.NewReader("world\n")))
+
+	_, err := v.Eval(context.Background(), `print "hello " + readLine();`)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", stdout.String())
+}
@@ -0,0 +1,96 @@
+package golox
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/kaschnit/golox/pkg/vm"
+)
+
+// Convert the Go value to a Lox value. Numbers of any type become float64, and slices, arrays and
+// maps are copied to Lox lists and maps. Lox objects are left as they are.
+func toLox(value Value) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, string, float64:
+		return v, nil
+	case *vm.Closure, *vm.BoundMethod, *vm.Class, *vm.Instance, *vm.NativeFunction,
+		*vm.List, *vm.Map, *vm.Error, *vm.Module:
+		return v, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]interface{}, rv.Len())
+		for i := range elements {
+			element, err := toLox(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return vm.NewList(elements), nil
+	case reflect.Map:
+		m := vm.NewMap()
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := toLox(iter.Key().Interface())
+			if err != nil {
+				return nil, err
+			}
+			element, err := toLox(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key, element)
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("Can't convert a value of type %T to a Lox value.", value)
+	}
+}
+
+// Convert the Lox value to a Go value. Lists and maps are copied to a []Value and a map[Value]Value,
+// with the same structure if they contain themselves. Map keys that are Lox objects are left as they are.
+func toGo(value interface{}) Value {
+	return toGoValue(value, make(map[interface{}]Value))
+}
+
+// Convert the Lox value to a Go value, reusing the conversions of the lists and maps in converted.
+func toGoValue(value interface{}, converted map[interface{}]Value) Value {
+	switch v := value.(type) {
+	case *vm.List:
+		if existing, ok := converted[v]; ok {
+			return existing
+		}
+		elements := make([]Value, len(v.Elements))
+		converted[v] = elements
+		for i, element := range v.Elements {
+			elements[i] = toGoValue(element, converted)
+		}
+		return elements
+	case *vm.Map:
+		if existing, ok := converted[v]; ok {
+			return existing
+		}
+		m := make(map[Value]Value)
+		converted[v] = m
+		for _, key := range v.Keys() {
+			element, _ := v.Lookup(key)
+			m[key] = toGoValue(element, converted)
+		}
+		return m
+	default:
+		return v
+	}
+}
@@ -0,0 +1,69 @@
+package golox
+
+import (
+	"testing"
+
+	"github.com/kaschnit/golox/pkg/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToLox_Primitives(t *testing.T) {
+	for _, tc := range []struct {
+		value    Value
+		expected interface{}
+	}{
+		{nil, nil},
+		{true, true},
+		{"abc", "abc"},
+		{1.5, 1.5},
+		{3, 3.0},
+		{int8(-3), -3.0},
+		{uint16(3), 3.0},
+		{float32(0.5), 0.5},
+	} {
+		result, err := toLox(tc.value)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.expected, result)
+	}
+}
+
+func TestToLox_Collections(t *testing.T) {
+	result, err := toLox([]interface{}{1, "a", []int{2}})
+	assert.Nil(t, err)
+	assert.Equal(t, "[1, a, [2]]", result.(*vm.List).String())
+
+	result, err = toLox(map[string][]int{"a": {1}})
+	assert.Nil(t, err)
+	assert.Equal(t, "{a: [1]}", result.(*vm.Map).String())
+
+	_, err = toLox([]interface{}{struct{}{}})
+	assert.EqualError(t, err, "Can't convert a value of type struct {} to a Lox value.")
+}
+
+func TestToLox_LoxObjectsUnchanged(t *testing.T) {
+	list := vm.NewList([]interface{}{1.0})
+	result, err := toLox(list)
+	assert.Nil(t, err)
+	assert.Same(t, list, result)
+}
+
+func TestToGo_Collections(t *testing.T) {
+	key := vm.NewList([]interface{}{})
+	m := vm.NewMap()
+	m.Set("a", vm.NewList([]interface{}{1.0, "b"}))
+	m.Set(key, true)
+
+	assert.Equal(t, map[Value]Value{
+		"a": []Value{1.0, "b"},
+		key: true,
+	}, toGo(m))
+}
+
+func TestToGo_SelfReferentialList(t *testing.T) {
+	list := vm.NewList([]interface{}{1.0, nil})
+	list.Elements[1] = list
+
+	result := toGo(list).([]Value)
+	assert.Equal(t, 1.0, result[0])
+	assert.Equal(t, 1.0, result[1].([]Value)[0])
+}
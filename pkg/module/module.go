@@ -0,0 +1,178 @@
+package module
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kaschnit/golox/pkg/ast"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/parser"
+)
+
+// The environment variable holding the directories searched for imported modules,
+// separated like the directories of PATH.
+const SearchPathEnv = "GOLOX_PATH"
+
+// Get the search path configured by the GOLOX_PATH environment variable.
+func SearchPathFromEnv() []string {
+	value := os.Getenv(SearchPathEnv)
+	if value == "" {
+		return []string{}
+	}
+	return filepath.SplitList(value)
+}
+
+// Executes a parsed module, producing the module object that importers see.
+type Executor func(path string, program *ast.Program) (interface{}, error)
+
+// Finds, executes and caches the modules imported by a program.
+// Each module is executed once, and importing it again produces the same module object.
+type Loader struct {
+	// The directories searched for a module that isn't found relative to its importer.
+	searchPath []string
+
+	// The module objects of the executed modules, by canonical path.
+	modules map[string]interface{}
+
+	// The canonical paths of the files being executed, from the main program to the innermost import.
+	chain []string
+}
+
+// Create a Loader that searches the directories of the search path for imported modules.
+func NewLoader(searchPath []string) *Loader {
+	return &Loader{
+		searchPath: searchPath,
+		modules:    make(map[string]interface{}),
+		chain:      make([]string, 0),
+	}
+}
+
+// Replace the directories searched for imported modules.
+func (l *Loader) SetSearchPath(searchPath []string) {
+	l.searchPath = searchPath
+}
+
+// Run the main program in the file at path, which starts the chain of imports that cycles are detected in.
+func (l *Loader) RunMain(path string, run func() error) error {
+	canonicalPath, err := canonicalize(path)
+	if err != nil {
+		return run()
+	}
+
+	l.chain = append(l.chain, canonicalPath)
+	defer func() {
+		l.chain = l.chain[:len(l.chain)-1]
+	}()
+	return run()
+}
+
+// Find the module imported as importPath by a file in the directory fromDir, returning its canonical path.
+// The module is looked for relative to fromDir and then in each directory of the search path.
+func (l *Loader) Resolve(importPath string, fromDir string) (string, error) {
+	candidates := []string{importPath}
+	if !filepath.IsAbs(importPath) {
+		candidates = []string{filepath.Join(fromDir, importPath)}
+		for _, dir := range l.searchPath {
+			candidates = append(candidates, filepath.Join(dir, importPath))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return canonicalize(candidate)
+		}
+	}
+	return "", loxerr.Errorf(loxerr.ModuleNotFound, "Module '%s' not found.", importPath)
+}
+
+// Get the module object of the module at the canonical path, parsing and executing the module
+// the first time it's imported.
+func (l *Loader) Load(path string, execute Executor) (interface{}, error) {
+	if module, ok := l.modules[path]; ok {
+		return module, nil
+	}
+
+	for i, importer := range l.chain {
+		if importer == path {
+			return nil, loxerr.Errorf(loxerr.ImportCycle, "Import cycle: %s.", describeChain(l.chain[i:], path))
+		}
+	}
+
+	program, err := parser.ParseSourceFile(path)
+	if err != nil {
+		return nil, moduleError(path, err)
+	}
+
+	l.chain = append(l.chain, path)
+	module, err := execute(path, program)
+	l.chain = l.chain[:len(l.chain)-1]
+	if err != nil {
+		return nil, moduleError(path, err)
+	}
+
+	l.modules[path] = module
+	return module, nil
+}
+
+// Wrap the error that occurred parsing or executing the module at the canonical path, so that it's
+// described with the module's file and source code.
+func moduleError(path string, err error) error {
+	source, _ := os.ReadFile(path)
+	return &loxerr.ModuleError{File: display(path), Source: string(source), Err: err}
+}
+
+// Get the names defined at the top level of the program, which a module exports.
+func Exports(program *ast.Program) []string {
+	names := make([]string, 0)
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarStmt:
+			names = append(names, s.Left.Lexeme)
+		case *ast.FunctionStmt:
+			names = append(names, s.Name.Lexeme)
+		case *ast.ClassStmt:
+			names = append(names, s.Name.Lexeme)
+		case *ast.ImportStmt:
+			names = append(names, s.Name.Lexeme)
+		}
+	}
+	return names
+}
+
+// Get the name of the module at the canonical path, which is its file name without the extension.
+func Name(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// Describe the chain of imports that ends by importing the module at path again.
+func describeChain(chain []string, path string) string {
+	paths := make([]string, 0, len(chain)+1)
+	for _, importer := range chain {
+		paths = append(paths, display(importer))
+	}
+	paths = append(paths, display(path))
+	return strings.Join(paths, " -> ")
+}
+
+// Get the absolute path of the file with symlinks resolved, so that each file has a single path.
+func canonicalize(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(absPath)
+}
+
+// Get the path of the file relative to the working directory if it's inside of it.
+func display(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	relPath, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return path
+	}
+	return relPath
+}
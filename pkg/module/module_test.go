@@ -0,0 +1,145 @@
+package module
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaschnit/golox/pkg/ast"
+	"github.com/kaschnit/golox/pkg/ast/astutil"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// Write the source code to a file in the directory, returning the file's canonical path.
+func writeModule(t *testing.T, dir string, name string, source string) string {
+	path := filepath.Join(dir, name)
+	assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.Nil(t, os.WriteFile(path, []byte(source), 0644))
+
+	canonicalPath, err := canonicalize(path)
+	assert.Nil(t, err)
+	return canonicalPath
+}
+
+func TestSearchPathFromEnv(t *testing.T) {
+	t.Setenv(SearchPathEnv, "")
+	assert.Empty(t, SearchPathFromEnv())
+
+	t.Setenv(SearchPathEnv, "a"+string(os.PathListSeparator)+"b")
+	assert.Equal(t, []string{"a", "b"}, SearchPathFromEnv())
+}
+
+func TestLoader_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	local := writeModule(t, dir, "main/util.lox", "")
+	shared := writeModule(t, dir, "lib/shared.lox", "")
+	writeModule(t, dir, "lib/util.lox", "")
+
+	loader := NewLoader([]string{filepath.Join(dir, "lib")})
+
+	// Modules next to the importer take precedence over the search path.
+	path, err := loader.Resolve("util.lox", filepath.Join(dir, "main"))
+	assert.Nil(t, err)
+	assert.Equal(t, local, path)
+
+	path, err = loader.Resolve("shared.lox", filepath.Join(dir, "main"))
+	assert.Nil(t, err)
+	assert.Equal(t, shared, path)
+
+	path, err = loader.Resolve(shared, "")
+	assert.Nil(t, err)
+	assert.Equal(t, shared, path)
+
+	_, err = loader.Resolve("missing.lox", filepath.Join(dir, "main"))
+	assert.EqualError(t, err, "Module 'missing.lox' not found.")
+
+	_, err = loader.Resolve("lib", dir)
+	assert.EqualError(t, err, "Module 'lib' not found.")
+}
+
+func TestLoader_LoadExecutesOnce(t *testing.T) {
+	path := writeModule(t, t.TempDir(), "util.lox", "var a = 1;")
+	loader := NewLoader([]string{})
+
+	executions := 0
+	execute := func(executedPath string, program *ast.Program) (interface{}, error) {
+		executions++
+		assert.Equal(t, path, executedPath)
+		assert.Len(t, program.Statements, 1)
+		return "module", nil
+	}
+
+	first, err := loader.Load(path, execute)
+	assert.Nil(t, err)
+	second, err := loader.Load(path, execute)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "module", first)
+	assert.Equal(t, "module", second)
+	assert.Equal(t, 1, executions)
+}
+
+func TestLoader_LoadError(t *testing.T) {
+	path := writeModule(t, t.TempDir(), "util.lox", "var a = 1;")
+	loader := NewLoader([]string{})
+
+	_, err := loader.Load(path, func(string, *ast.Program) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	assert.ErrorContains(t, err, "Error in module '")
+	assert.ErrorContains(t, err, "util.lox': failed")
+
+	// The module's error is kept, along with the module's source code that it's located in.
+	var moduleErr *loxerr.ModuleError
+	if assert.ErrorAs(t, err, &moduleErr) {
+		assert.Equal(t, "failed", errors.Unwrap(err).Error())
+		assert.Equal(t, "var a = 1;", moduleErr.Source)
+	}
+
+	// A module that failed isn't cached, so importing it again retries it.
+	module, err := loader.Load(path, func(string, *ast.Program) (interface{}, error) {
+		return "module", nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "module", module)
+}
+
+func TestLoader_LoadCycle(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := writeModule(t, dir, "main.lox", "")
+	utilPath := writeModule(t, dir, "util.lox", "")
+	loader := NewLoader([]string{})
+
+	var cycleErr error
+	err := loader.RunMain(mainPath, func() error {
+		_, err := loader.Load(utilPath, func(string, *ast.Program) (interface{}, error) {
+			_, cycleErr = loader.Load(mainPath, func(string, *ast.Program) (interface{}, error) {
+				return "main", nil
+			})
+			return "util", nil
+		})
+		return err
+	})
+	assert.Nil(t, err)
+	assert.EqualError(t, cycleErr, "Import cycle: "+mainPath+" -> "+utilPath+" -> "+mainPath+".")
+}
+
+func TestExports(t *testing.T) {
+	program, err := astutil.ParseLine(`
+		var a = 1;
+		fun b() {}
+		class C {}
+		import "d.lox" as d;
+		{ var hidden = 2; }
+		print a;
+	`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "C", "d"}, Exports(program))
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "util", Name("/a/b/util.lox"))
+	assert.Equal(t, "util", Name("/a/b/util"))
+}
@@ -0,0 +1,27 @@
+package native
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Create a registry of the natives that read input, which read from the reader that input returns
+// at the time of each call.
+func Input(input func() *bufio.Reader) *Registry {
+	r := NewRegistry()
+	r.MustRegister(&Function{
+		Name: "readLine",
+		Doc:  "Read the next line of input without its line ending, or nil if there is no more input.",
+		Code: func(args []interface{}) (interface{}, error) {
+			line, err := input().ReadString('\n')
+			if err == io.EOF && line == "" {
+				return nil, nil
+			} else if err != nil && err != io.EOF {
+				return nil, err
+			}
+			return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+		},
+	})
+	return r
+}
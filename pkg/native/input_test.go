@@ -0,0 +1,21 @@
+package native
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInput(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("first\r\nsecond"))
+	readLine, ok := Input(func() *bufio.Reader { return reader }).Lookup("readLine")
+	assert.True(t, ok)
+
+	for _, expected := range []interface{}{"first", "second", nil, nil} {
+		line, err := readLine.Call([]interface{}{})
+		assert.Nil(t, err)
+		assert.Equal(t, expected, line)
+	}
+}
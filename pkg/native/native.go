@@ -0,0 +1,144 @@
+package native
+
+import (
+	"fmt"
+	"strings"
+
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+)
+
+// The type of the values that a parameter of a native function accepts.
+type Type struct {
+	name    string
+	accepts func(value interface{}) bool
+}
+
+// Create a type with the given name, such as "a list", that accepts the values the function accepts.
+func NewType(name string, accepts func(value interface{}) bool) Type {
+	return Type{name: name, accepts: accepts}
+}
+
+var (
+	Any    = NewType("any value", func(value interface{}) bool { return true })
+	Number = NewType("a number", isNumber)
+	String = NewType("a string", func(value interface{}) bool { _, ok := value.(string); return ok })
+	Bool   = NewType("a bool", func(value interface{}) bool { _, ok := value.(bool); return ok })
+)
+
+// Get the name of the type, such as "a number".
+func (t Type) Name() string {
+	return t.name
+}
+
+// Check whether the type accepts the value.
+func (t Type) Accepts(value interface{}) bool {
+	return t.accepts(value)
+}
+
+// A parameter of a native function.
+type Param struct {
+	Name string
+	Type Type
+}
+
+// A function implemented in Go that Lox code can call.
+type Function struct {
+	// The name of the function, qualified by its namespaces, such as "math.sqrt".
+	Name string
+
+	Params []Param
+
+	// Whether the last parameter accepts any number of arguments, including none.
+	Variadic bool
+
+	// Documentation of what the function does.
+	Doc string
+
+	// The implementation of the function, which is called with arguments that match the parameters.
+	Code func(args []interface{}) (interface{}, error)
+}
+
+// Get the minimum number of arguments the function takes.
+func (f *Function) Arity() int {
+	if f.Variadic {
+		return len(f.Params) - 1
+	}
+	return len(f.Params)
+}
+
+// Call the function with the arguments, after checking that they match its parameters.
+func (f *Function) Call(args []interface{}) (interface{}, error) {
+	if err := f.CheckArgs(args); err != nil {
+		return nil, err
+	}
+	return f.Code(args)
+}
+
+// Check that the number of arguments and their types match the function's parameters.
+func (f *Function) CheckArgs(args []interface{}) error {
+	if f.Variadic && len(args) < f.Arity() {
+		return loxerr.Errorf(loxerr.WrongArgCount, "Expected at least %d args, got %d.", f.Arity(), len(args))
+	}
+	if !f.Variadic && len(args) != f.Arity() {
+		return loxerr.Errorf(loxerr.WrongArgCount, "Expected %d args, got %d.", f.Arity(), len(args))
+	}
+
+	for i, arg := range args {
+		param := f.Params[len(f.Params)-1]
+		if i < len(f.Params) {
+			param = f.Params[i]
+		}
+		if !param.Type.Accepts(arg) {
+			return loxerr.Errorf(loxerr.ArgumentType, "Argument %d of '%s' must be %s, got %s.", i+1, f.Name, param.Type.Name(), TypeName(arg))
+		}
+	}
+	return nil
+}
+
+// Describe how the function is called, such as "math.sqrt(x: a number)".
+func (f *Function) Signature() string {
+	params := make([]string, len(f.Params))
+	for i, param := range f.Params {
+		params[i] = fmt.Sprintf("%s: %s", param.Name, param.Type.Name())
+		if f.Variadic && i == len(f.Params)-1 {
+			params[i] = fmt.Sprintf("%s...: %s", param.Name, param.Type.Name())
+		}
+	}
+	return fmt.Sprintf("%s(%s)", f.Name, strings.Join(params, ", "))
+}
+
+// A Lox value that knows the name of its type, like "list" or "function".
+type Typed interface {
+	TypeName() string
+}
+
+// A Lox value whose property names can be listed, like an instance or a module.
+type PropertyLister interface {
+	PropertyNames() []string
+}
+
+// Get the name of the type of a Lox value, which is "object" for values other than nil, bools,
+// numbers and strings that don't know the name of their type.
+func TypeName(value interface{}) string {
+	if typed, ok := value.(Typed); ok {
+		return typed.TypeName()
+	}
+
+	switch {
+	case value == nil:
+		return "nil"
+	case Bool.Accepts(value):
+		return "bool"
+	case Number.Accepts(value):
+		return "number"
+	case String.Accepts(value):
+		return "string"
+	default:
+		return "object"
+	}
+}
+
+func isNumber(value interface{}) bool {
+	_, ok := value.(float64)
+	return ok
+}
@@ -0,0 +1,74 @@
+package native
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFunction(variadic bool) *Function {
+	return &Function{
+		Name:     "test.fn",
+		Params:   []Param{{Name: "s", Type: String}, {Name: "n", Type: Number}},
+		Variadic: variadic,
+		Code: func(args []interface{}) (interface{}, error) {
+			return len(args), nil
+		},
+	}
+}
+
+func TestFunction_CheckArgs(t *testing.T) {
+	fn := newTestFunction(false)
+	assert.Nil(t, fn.CheckArgs([]interface{}{"a", 1.0}))
+	assert.EqualError(t, fn.CheckArgs([]interface{}{"a"}), "Expected 2 args, got 1.")
+	assert.EqualError(t, fn.CheckArgs([]interface{}{"a", 1.0, 2.0}), "Expected 2 args, got 3.")
+	assert.EqualError(t, fn.CheckArgs([]interface{}{1.0, 1.0}), "Argument 1 of 'test.fn' must be a string, got number.")
+	assert.EqualError(t, fn.CheckArgs([]interface{}{"a", nil}), "Argument 2 of 'test.fn' must be a number, got nil.")
+	assert.EqualError(t, fn.CheckArgs([]interface{}{"a", int64(1)}), "Argument 2 of 'test.fn' must be a number, got object.")
+}
+
+func TestFunction_CheckArgsVariadic(t *testing.T) {
+	fn := newTestFunction(true)
+	assert.Equal(t, 1, fn.Arity())
+	assert.Nil(t, fn.CheckArgs([]interface{}{"a"}))
+	assert.Nil(t, fn.CheckArgs([]interface{}{"a", 1.0, 2.0, 3.0}))
+	assert.EqualError(t, fn.CheckArgs([]interface{}{}), "Expected at least 1 args, got 0.")
+	assert.EqualError(t, fn.CheckArgs([]interface{}{"a", 1.0, true}), "Argument 3 of 'test.fn' must be a number, got bool.")
+}
+
+func TestFunction_Call(t *testing.T) {
+	fn := newTestFunction(true)
+	result, err := fn.Call([]interface{}{"a", 1.0, 2.0})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result)
+
+	_, err = fn.Call([]interface{}{true})
+	assert.EqualError(t, err, "Argument 1 of 'test.fn' must be a string, got bool.")
+
+	fn.Code = func(args []interface{}) (interface{}, error) {
+		return nil, errors.New("failed")
+	}
+	_, err = fn.Call([]interface{}{"a"})
+	assert.EqualError(t, err, "failed")
+}
+
+func TestFunction_Signature(t *testing.T) {
+	assert.Equal(t, "test.fn(s: a string, n: a number)", newTestFunction(false).Signature())
+	assert.Equal(t, "test.fn(s: a string, n...: a number)", newTestFunction(true).Signature())
+}
+
+func TestTypeName(t *testing.T) {
+	assert.Equal(t, "nil", TypeName(nil))
+	assert.Equal(t, "bool", TypeName(false))
+	assert.Equal(t, "number", TypeName(1.0))
+	assert.Equal(t, "string", TypeName(""))
+	assert.Equal(t, "object", TypeName([]interface{}{}))
+	assert.Equal(t, "widget", TypeName(widget{}))
+}
+
+type widget struct{}
+
+func (widget) TypeName() string {
+	return "widget"
+}
@@ -0,0 +1,114 @@
+package native
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kaschnit/golox/pkg/scanner"
+	"github.com/kaschnit/golox/pkg/token/tokentype"
+)
+
+// A collection of native functions, grouped into namespaces by the qualifiers of their names.
+// A function named "math.sqrt" is registered as "sqrt" in the "math" namespace.
+type Registry struct {
+	functions  map[string]*Function
+	namespaces map[string]*Registry
+}
+
+// Create an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		functions:  make(map[string]*Function),
+		namespaces: make(map[string]*Registry),
+	}
+}
+
+// Add the function to the registry, creating the namespaces it's qualified by.
+func (r *Registry) Register(fn *Function) error {
+	if fn.Variadic && len(fn.Params) == 0 {
+		return fmt.Errorf("Variadic native '%s' must have a parameter.", fn.Name)
+	}
+
+	parts := strings.Split(fn.Name, ".")
+	for _, part := range parts {
+		if !isIdentifier(part) {
+			return fmt.Errorf("Native name '%s' must be identifiers separated by '.'.", fn.Name)
+		}
+	}
+
+	registry := r
+	for i, part := range parts[:len(parts)-1] {
+		if _, ok := registry.functions[part]; ok {
+			return fmt.Errorf("Native '%s' is already registered as a function.", strings.Join(parts[:i+1], "."))
+		}
+		namespace, ok := registry.namespaces[part]
+		if !ok {
+			namespace = NewRegistry()
+			registry.namespaces[part] = namespace
+		}
+		registry = namespace
+	}
+
+	name := parts[len(parts)-1]
+	if _, ok := registry.functions[name]; ok {
+		return fmt.Errorf("Native '%s' is already registered.", fn.Name)
+	}
+	if _, ok := registry.namespaces[name]; ok {
+		return fmt.Errorf("Native '%s' is already registered as a namespace.", fn.Name)
+	}
+	registry.functions[name] = fn
+	return nil
+}
+
+// Add the function to the registry, panicking if it can't be registered.
+func (r *Registry) MustRegister(fn *Function) {
+	if err := r.Register(fn); err != nil {
+		panic(err)
+	}
+}
+
+// Get the names of the functions and namespaces directly in the registry, in sorted order.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.functions)+len(r.namespaces))
+	for name := range r.functions {
+		names = append(names, name)
+	}
+	for name := range r.namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get the function directly in the registry with the given name.
+func (r *Registry) Function(name string) (*Function, bool) {
+	fn, ok := r.functions[name]
+	return fn, ok
+}
+
+// Get the namespace directly in the registry with the given name.
+func (r *Registry) Namespace(name string) (*Registry, bool) {
+	namespace, ok := r.namespaces[name]
+	return namespace, ok
+}
+
+// Get the function with the qualified name, such as "math.sqrt".
+func (r *Registry) Lookup(name string) (*Function, bool) {
+	parts := strings.Split(name, ".")
+	registry := r
+	for _, part := range parts[:len(parts)-1] {
+		namespace, ok := registry.namespaces[part]
+		if !ok {
+			return nil, false
+		}
+		registry = namespace
+	}
+	return registry.Function(parts[len(parts)-1])
+}
+
+// Check whether the name is scanned as a single identifier, so that Lox code can refer to it.
+func isIdentifier(name string) bool {
+	tokens, err := scanner.NewScanner(name).ScanAllTokens()
+	return err == nil && len(tokens) == 2 && tokens[0].Type == tokentype.IDENTIFIER && tokens[0].Lexeme == name
+}
@@ -0,0 +1,59 @@
+package native
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	assert.Nil(t, r.Register(&Function{Name: "clock"}))
+	assert.Nil(t, r.Register(&Function{Name: "math.sqrt"}))
+	assert.Nil(t, r.Register(&Function{Name: "math.trig.sin"}))
+
+	assert.Equal(t, []string{"clock", "math"}, r.Names())
+
+	math, ok := r.Namespace("math")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"sqrt", "trig"}, math.Names())
+
+	fn, ok := r.Lookup("math.trig.sin")
+	assert.True(t, ok)
+	assert.Equal(t, "math.trig.sin", fn.Name)
+
+	_, ok = r.Lookup("math.cos")
+	assert.False(t, ok)
+	_, ok = r.Lookup("math")
+	assert.False(t, ok)
+}
+
+func TestRegistry_RegisterErrors(t *testing.T) {
+	r := NewRegistry()
+	assert.Nil(t, r.Register(&Function{Name: "clock"}))
+	assert.Nil(t, r.Register(&Function{Name: "math.sqrt"}))
+
+	assert.EqualError(t, r.Register(&Function{Name: "clock"}), "Native 'clock' is already registered.")
+	assert.EqualError(t, r.Register(&Function{Name: "math"}), "Native 'math' is already registered as a namespace.")
+	assert.EqualError(t, r.Register(&Function{Name: "clock.now"}), "Native 'clock' is already registered as a function.")
+	assert.EqualError(t, r.Register(&Function{Name: "print"}), "Native name 'print' must be identifiers separated by '.'.")
+	assert.EqualError(t, r.Register(&Function{Name: "math..floor"}), "Native name 'math..floor' must be identifiers separated by '.'.")
+	assert.EqualError(t, r.Register(&Function{Name: "f", Variadic: true}), "Variadic native 'f' must have a parameter.")
+}
+
+func TestStandard(t *testing.T) {
+	r := Standard()
+	assert.Equal(t, []string{"clock", "math"}, r.Names())
+
+	sqrt, ok := r.Lookup("math.sqrt")
+	assert.True(t, ok)
+	result, err := sqrt.Call([]interface{}{9.0})
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, result)
+
+	max, ok := r.Lookup("math.max")
+	assert.True(t, ok)
+	result, err = max.Call([]interface{}{1.0, 3.0, 2.0})
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, result)
+}
@@ -0,0 +1,61 @@
+package native
+
+import (
+	"math"
+	"time"
+)
+
+// Create a registry of the native functions that every program can call.
+func Standard() *Registry {
+	r := NewRegistry()
+	r.MustRegister(&Function{
+		Name: "clock",
+		Doc:  "Get the current Unix time in seconds.",
+		Code: func(args []interface{}) (interface{}, error) {
+			return float64(time.Now().Unix()), nil
+		},
+	})
+
+	r.MustRegister(mathFunction("math.sqrt", "Get the square root of x.", math.Sqrt))
+	r.MustRegister(mathFunction("math.floor", "Get the greatest integer less than or equal to x.", math.Floor))
+	r.MustRegister(mathFunction("math.abs", "Get the absolute value of x.", math.Abs))
+	r.MustRegister(&Function{
+		Name:     "math.max",
+		Params:   []Param{{Name: "x", Type: Number}, {Name: "rest", Type: Number}},
+		Variadic: true,
+		Doc:      "Get the largest of the numbers.",
+		Code: func(args []interface{}) (interface{}, error) {
+			max := args[0].(float64)
+			for _, arg := range args[1:] {
+				max = math.Max(max, arg.(float64))
+			}
+			return max, nil
+		},
+	})
+	r.MustRegister(&Function{
+		Name:     "math.min",
+		Params:   []Param{{Name: "x", Type: Number}, {Name: "rest", Type: Number}},
+		Variadic: true,
+		Doc:      "Get the smallest of the numbers.",
+		Code: func(args []interface{}) (interface{}, error) {
+			min := args[0].(float64)
+			for _, arg := range args[1:] {
+				min = math.Min(min, arg.(float64))
+			}
+			return min, nil
+		},
+	})
+	return r
+}
+
+// Create a native function of one number from a function of the math package.
+func mathFunction(name string, doc string, fn func(float64) float64) *Function {
+	return &Function{
+		Name:   name,
+		Params: []Param{{Name: "x", Type: Number}},
+		Doc:    doc,
+		Code: func(args []interface{}) (interface{}, error) {
+			return fn(args[0].(float64)), nil
+		},
+	}
+}
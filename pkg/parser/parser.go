@@ -17,6 +17,9 @@ type Parser struct {
 
 	// The current token in the sequence of tokens currently being parsed.
 	current int
+
+	// Whether the semicolon after an expression statement may be left out at the end of the source code.
+	optionalFinalSemicolon bool
 }
 
 // Create a Parser instance.
@@ -38,11 +41,26 @@ func (p *Parser) Reset() {
 // with a root of type ast.Program.
 func (p *Parser) Parse() (*ast.Program, error) {
 	if numTokens := len(p.tokens); numTokens == 0 {
-		return nil, loxerr.AtLine(0, "Expected EOF.")
+		return nil, loxerr.WithStage(loxerr.AtLine(0, loxerr.ExpectedToken, "Expected EOF."), loxerr.StageParse)
 	} else if p.tokens[numTokens-1].Type != tokentype.EOF {
-		return nil, loxerr.AtToken(p.tokens[numTokens-1], "Expected EOF.")
+		return nil, loxerr.WithStage(loxerr.AtToken(p.tokens[numTokens-1], loxerr.ExpectedToken, "Expected EOF."), loxerr.StageParse)
+	}
+
+	programAst, err := p.parseProgram()
+	if err != nil {
+		return nil, loxerr.WithStage(err, loxerr.StageParse)
 	}
-	return p.parseProgram()
+	return programAst, nil
+}
+
+// Parse source code entered at a REPL, which is parsed like Parse except that the semicolon after
+// a final expression statement may be left out, so that "1 + 2" is the same as "1 + 2;".
+func (p *Parser) ParseRepl() (*ast.Program, error) {
+	p.optionalFinalSemicolon = true
+	defer func() {
+		p.optionalFinalSemicolon = false
+	}()
+	return p.Parse()
 }
 
 // Parse a program, which is the root of the AST.
@@ -74,6 +92,18 @@ func (p *Parser) parseStatement() (ast.Stmt, error) {
 	case tokentype.RETURN:
 		p.advance()
 		return p.parseReturnStatement()
+	case tokentype.BREAK:
+		p.advance()
+		return p.parseBreakStatement()
+	case tokentype.CONTINUE:
+		p.advance()
+		return p.parseContinueStatement()
+	case tokentype.THROW:
+		p.advance()
+		return p.parseThrowStatement()
+	case tokentype.TRY:
+		p.advance()
+		return p.parseTryStatement()
 	case tokentype.IF:
 		p.advance()
 		return p.parseIfStatement()
@@ -87,11 +117,18 @@ func (p *Parser) parseStatement() (ast.Stmt, error) {
 		p.advance()
 		return p.parseForStatement()
 	case tokentype.FUN:
+		if p.peekMatches(2, tokentype.LEFT_PAREN) {
+			// An anonymous function used as an expression statement.
+			return p.parseExpressionStatement()
+		}
 		p.advance()
 		return p.parseFunctionStatement()
 	case tokentype.VAR:
 		p.advance()
 		return p.parseVarStatement()
+	case tokentype.IMPORT:
+		p.advance()
+		return p.parseImportStatement()
 	case tokentype.LEFT_BRACE:
 		p.advance()
 		return p.parseBlockStatement()
@@ -136,6 +173,106 @@ func (p *Parser) parseReturnStatement() (*ast.ReturnStmt, error) {
 	}, nil
 }
 
+// Parse a break statement.
+func (p *Parser) parseBreakStatement() (*ast.BreakStmt, error) {
+	breakKeyword := p.peek(0)
+	_, err := p.consume(tokentype.SEMICOLON, "Expected ';' after 'break'.")
+	if err != nil {
+		return nil, err
+	}
+	return &ast.BreakStmt{Keyword: breakKeyword}, nil
+}
+
+// Parse a continue statement.
+func (p *Parser) parseContinueStatement() (*ast.ContinueStmt, error) {
+	continueKeyword := p.peek(0)
+	_, err := p.consume(tokentype.SEMICOLON, "Expected ';' after 'continue'.")
+	if err != nil {
+		return nil, err
+	}
+	return &ast.ContinueStmt{Keyword: continueKeyword}, nil
+}
+
+// Parse a throw statement.
+func (p *Parser) parseThrowStatement() (*ast.ThrowStmt, error) {
+	throwKeyword := p.peek(0)
+	value, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(tokentype.SEMICOLON, "Expected ';' after thrown value.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ThrowStmt{
+		Keyword: throwKeyword,
+		Value:   value,
+	}, nil
+}
+
+// Parse a try statement, which needs a catch block, a finally block, or both.
+func (p *Parser) parseTryStatement() (*ast.TryStmt, error) {
+	tryKeyword := p.peek(0)
+	body, err := p.parseBlockStartingWith("Expected '{' after 'try'.")
+	if err != nil {
+		return nil, err
+	}
+
+	var catchName *token.Token
+	var catchBody *ast.BlockStmt
+	if p.peekMatches(1, tokentype.CATCH) {
+		p.advance()
+		_, err = p.consume(tokentype.LEFT_PAREN, "Expected '(' after 'catch'.")
+		if err != nil {
+			return nil, err
+		}
+		catchName, err = p.consume(tokentype.IDENTIFIER, "Expected identifier.")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(tokentype.RIGHT_PAREN, "Expected ')' after catch variable.")
+		if err != nil {
+			return nil, err
+		}
+		catchBody, err = p.parseBlockStartingWith("Expected '{' after catch variable.")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var finallyBody *ast.BlockStmt
+	if p.peekMatches(1, tokentype.FINALLY) {
+		p.advance()
+		finallyBody, err = p.parseBlockStartingWith("Expected '{' after 'finally'.")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if catchBody == nil && finallyBody == nil {
+		return nil, loxerr.AtToken(tryKeyword, loxerr.ExpectedToken, "Expected 'catch' or 'finally' after try block.")
+	}
+
+	return &ast.TryStmt{
+		Keyword:     tryKeyword,
+		Body:        body,
+		CatchName:   catchName,
+		CatchBody:   catchBody,
+		FinallyBody: finallyBody,
+	}, nil
+}
+
+// Parse a block statement that must start with '{', reporting the message if it doesn't.
+func (p *Parser) parseBlockStartingWith(message string) (*ast.BlockStmt, error) {
+	_, err := p.consume(tokentype.LEFT_BRACE, message)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseBlockStatement()
+}
+
 // Parse an expression statement.
 func (p *Parser) parseExpressionStatement() (*ast.ExprStmt, error) {
 
@@ -149,6 +286,9 @@ func (p *Parser) parseExpressionStatement() (*ast.ExprStmt, error) {
 	if err != nil {
 		return nil, err
 	}
+	if p.optionalFinalSemicolon && p.isAtEnd() {
+		return &ast.ExprStmt{Expression: expr}, nil
+	}
 
 	_, err = p.consume(tokentype.SEMICOLON, "Expected ';' after expression.")
 	if err != nil {
@@ -201,6 +341,7 @@ func (p *Parser) parseIfStatement() (*ast.IfStmt, error) {
 // Parse a while loop statement.
 func (p *Parser) parseWhileStatement() (*ast.WhileStmt, error) {
 	var err error
+	whileKeyword := p.peek(0)
 
 	_, err = p.consume(tokentype.LEFT_PAREN, "Expected '(' after 'while'.")
 	if err != nil {
@@ -221,6 +362,7 @@ func (p *Parser) parseWhileStatement() (*ast.WhileStmt, error) {
 	}
 
 	return &ast.WhileStmt{
+		Keyword:       whileKeyword,
 		Condition:     condition,
 		LoopStatement: loopStatement,
 	}, nil
@@ -229,14 +371,15 @@ func (p *Parser) parseWhileStatement() (*ast.WhileStmt, error) {
 // Parse a for loop statement.
 // Desugars the for loop to a while loop. The following two are equivalent:
 //  1. for (int i = 0; i < 5; i++) { doSomething() }
-//  2. { int i = 0; while (i < 5) { { doSomething(); } i++; } }
+//  2. { int i = 0; while (i < 5) { doSomething(); } } with i++ as the while loop's increment
 //
 // The while loop is placed inside its own block. The initializer is placed at the beginning
-// of this block. The loop body is placed inside a nested block, and the increment is placed
-// after this nested block.
+// of this block. The increment is kept on the while loop rather than appended to the loop body
+// so that it still runs when an iteration is ended early by 'continue'.
 func (p *Parser) parseForStatement() (ast.Stmt, error) {
 	var err error
 	var nextToken *token.Token
+	forKeyword := p.peek(0)
 
 	_, err = p.consume(tokentype.LEFT_PAREN, "Expected '(' after 'for'.")
 	if err != nil {
@@ -299,19 +442,13 @@ func (p *Parser) parseForStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	// If there's an increment, place it at the end of the loop body.
-	if increment != nil {
-		incrementStmt := &ast.ExprStmt{Expression: increment}
-		loopBody = &ast.BlockStmt{
-			Statements: []ast.Stmt{loopBody, incrementStmt},
-		}
-	}
-
 	// Construct the while loop from the parsed expressions and statement.
 	var result ast.Stmt
 	result = &ast.WhileStmt{
+		Keyword:       forKeyword,
 		Condition:     condition,
 		LoopStatement: loopBody,
+		Increment:     increment,
 	}
 
 	// If there's an initializer, wrap the while statement in a block
@@ -350,6 +487,17 @@ func (p *Parser) parseClassStatement() (*ast.ClassStmt, error) {
 		return nil, err
 	}
 
+	// Optional superclass after the name, e.g. "class B < A".
+	var superclass *ast.VarExpr
+	if p.peekMatches(1, tokentype.LESS) {
+		p.advance()
+		superclassName, err := p.consume(tokentype.IDENTIFIER, "Expected superclass name.")
+		if err != nil {
+			return nil, err
+		}
+		superclass = &ast.VarExpr{Name: superclassName}
+	}
+
 	_, err = p.consume(tokentype.LEFT_BRACE, "Expected '{'.")
 	if err != nil {
 		return nil, err
@@ -386,6 +534,7 @@ func (p *Parser) parseClassStatement() (*ast.ClassStmt, error) {
 
 	return &ast.ClassStmt{
 		Name:          name,
+		Superclass:    superclass,
 		Constructor:   constructor,
 		Methods:       methods,
 		StaticMethods: staticMethods,
@@ -400,8 +549,26 @@ func (p *Parser) parseFunctionStatement() (*ast.FunctionStmt, error) {
 		return nil, err
 	}
 
-	// Parse the args within the parentheses.
-	_, err = p.consume(tokentype.LEFT_PAREN, "Expected '('.")
+	params, err := p.parseParams()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.parseFunctionBody()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.FunctionStmt{
+		Name:   name,
+		Params: params,
+		Body:   body,
+	}, nil
+}
+
+// Parse the parenthesized params of a function.
+func (p *Parser) parseParams() ([]*token.Token, error) {
+	_, err := p.consume(tokentype.LEFT_PAREN, "Expected '('.")
 	if err != nil {
 		return nil, err
 	}
@@ -423,27 +590,56 @@ func (p *Parser) parseFunctionStatement() (*ast.FunctionStmt, error) {
 			if nextSep.Type == tokentype.RIGHT_PAREN {
 				break
 			} else if nextSep.Type != tokentype.COMMA {
-				return nil, loxerr.AtToken(nextSep, "Expected ')' after args.")
+				return nil, loxerr.AtToken(nextSep, loxerr.ExpectedToken, "Expected ')' after args.")
 			}
 		}
 	}
 
-	// After args is the function body starting with '{'.
-	_, err = p.consume(tokentype.LEFT_BRACE, "Expected '{'.")
+	return params, nil
+}
+
+// Parse the body of a function, which is a block starting with '{'.
+func (p *Parser) parseFunctionBody() ([]ast.Stmt, error) {
+	_, err := p.consume(tokentype.LEFT_BRACE, "Expected '{'.")
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse the function body.
 	funcBody, err := p.parseBlockStatement()
 	if err != nil {
 		return nil, err
 	}
+	return funcBody.Statements, nil
+}
 
-	return &ast.FunctionStmt{
-		Name:   name,
-		Params: params,
-		Body:   funcBody.Statements,
+// Parse an import statement.
+func (p *Parser) parseImportStatement() (*ast.ImportStmt, error) {
+	keyword := p.peek(0)
+
+	path, err := p.consume(tokentype.STRING, "Expected module path after 'import'.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(tokentype.AS, "Expected 'as' after module path.")
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := p.consume(tokentype.IDENTIFIER, "Expected identifier after 'as'.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(tokentype.SEMICOLON, "Expected ';' after import.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ImportStmt{
+		Keyword: keyword,
+		Path:    path,
+		Name:    name,
 	}, nil
 }
 
@@ -509,8 +705,15 @@ func (p *Parser) parseAssignment() (ast.Expr, error) {
 				Value:        right,
 				ParentObject: getPropertyExpr.ParentObject,
 			}, nil
+		} else if indexGetExpr, ok := expr.(*ast.IndexGetExpr); ok {
+			expr, err = &ast.IndexSetExpr{
+				Object:      indexGetExpr.Object,
+				OpenBracket: indexGetExpr.OpenBracket,
+				Index:       indexGetExpr.Index,
+				Value:       right,
+			}, nil
 		} else {
-			expr, err = nil, loxerr.AtToken(equalsToken, "Invalid assignment target.")
+			expr, err = nil, loxerr.AtToken(equalsToken, loxerr.InvalidAssignmentTarget, "Invalid assignment target.")
 		}
 	}
 	return expr, err
@@ -529,7 +732,7 @@ func (p *Parser) parseLogicalOr() (ast.Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		expr = &ast.BinaryExpr{Left: left, Operator: operator, Right: right}
+		expr = &ast.LogicalExpr{Left: left, Operator: operator, Right: right}
 	}
 	return expr, nil
 }
@@ -543,11 +746,11 @@ func (p *Parser) parseLogicalAnd() (ast.Expr, error) {
 	for p.peekMatches(1, tokentype.AND) {
 		left := expr
 		operator := p.advance()
-		right, err := p.parseLogicalAnd()
+		right, err := p.parseEquality()
 		if err != nil {
 			return nil, err
 		}
-		expr = &ast.BinaryExpr{Left: left, Operator: operator, Right: right}
+		expr = &ast.LogicalExpr{Left: left, Operator: operator, Right: right}
 	}
 	return expr, nil
 }
@@ -700,6 +903,24 @@ func (p *Parser) parseCall() (ast.Expr, error) {
 				Name:         propertyName,
 				ParentObject: expr,
 			}
+		} else if nextToken.Type == tokentype.LEFT_BRACKET { // Indexing
+			p.advance()
+
+			index, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = p.consume(tokentype.RIGHT_BRACKET, "Expected ']' after index.")
+			if err != nil {
+				return nil, err
+			}
+
+			expr = &ast.IndexGetExpr{
+				Object:      expr,
+				OpenBracket: nextToken,
+				Index:       index,
+			}
 		} else {
 			return expr, nil
 		}
@@ -712,7 +933,8 @@ func (p *Parser) parsePrimary() (ast.Expr, error) {
 		tokentype.NUMBER, tokentype.STRING,
 		tokentype.TRUE, tokentype.FALSE,
 		tokentype.IDENTIFIER, tokentype.NIL,
-		tokentype.THIS,
+		tokentype.THIS, tokentype.SUPER,
+		tokentype.INTERPOLATION,
 	}
 	if p.peekMatches(1, matchTokens...) {
 		matched := p.advance()
@@ -723,11 +945,27 @@ func (p *Parser) parsePrimary() (ast.Expr, error) {
 			return &ast.LiteralExpr{Value: false}, nil
 		case tokentype.THIS:
 			return &ast.ThisExpr{Keyword: p.peek(0)}, nil
+		case tokentype.SUPER:
+			_, err := p.consume(tokentype.DOT, "Expected '.' after 'super'.")
+			if err != nil {
+				return nil, err
+			}
+			method, err := p.consume(tokentype.IDENTIFIER, "Expected superclass method name.")
+			if err != nil {
+				return nil, err
+			}
+			return &ast.SuperExpr{Keyword: matched, Method: method}, nil
 		case tokentype.IDENTIFIER:
 			return &ast.VarExpr{Name: matched}, nil
+		case tokentype.INTERPOLATION:
+			return p.parseInterpolation(matched)
 		default:
 			return &ast.LiteralExpr{Value: matched.Literal}, nil
 		}
+	} else if p.peekMatches(1, tokentype.FUN) {
+		return p.parseFunctionExpression()
+	} else if p.isArrowFunction() {
+		return p.parseArrowFunction()
 	} else if p.peekMatches(1, tokentype.LEFT_PAREN) {
 		p.advance()
 		expr, err := p.parseExpression()
@@ -740,9 +978,207 @@ func (p *Parser) parsePrimary() (ast.Expr, error) {
 			return nil, err
 		}
 		return &ast.GroupingExpr{Expression: expr}, nil
+	} else if p.peekMatches(1, tokentype.LEFT_BRACKET) {
+		return p.parseList()
+	} else if p.peekMatches(1, tokentype.LEFT_BRACE) {
+		// A '{' that starts a statement is always a block, so one reached here is a map.
+		return p.parseMap()
 	} else {
-		return nil, loxerr.AtToken(p.peek(1), "Expected expression.")
+		return nil, loxerr.AtToken(p.peek(1), loxerr.ExpectedExpression, "Expected expression.")
+	}
+}
+
+// Parse an anonymous function, e.g. "fun (a, b) { return a + b; }".
+func (p *Parser) parseFunctionExpression() (ast.Expr, error) {
+	keyword := p.advance()
+
+	params, err := p.parseParams()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.parseFunctionBody()
+	if err != nil {
+		return nil, err
 	}
+
+	return &ast.FunctionExpr{
+		Keyword: keyword,
+		Params:  params,
+		Body:    body,
+	}, nil
+}
+
+// Parse an arrow function, e.g. "(a, b) => a + b".
+// Desugars the arrow function to an anonymous function that returns its expression. The following two are equivalent:
+//  1. (a, b) => a + b
+//  2. fun (a, b) { return a + b; }
+func (p *Parser) parseArrowFunction() (ast.Expr, error) {
+	params, err := p.parseParams()
+	if err != nil {
+		return nil, err
+	}
+
+	arrow, err := p.consume(tokentype.ARROW, "Expected '=>' after params.")
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.FunctionExpr{
+		Keyword: arrow,
+		Params:  params,
+		Body:    []ast.Stmt{&ast.ReturnStmt{Keyword: arrow, Expression: value}},
+	}, nil
+}
+
+// Whether the next tokens are the params of an arrow function, i.e. "()" or "(a, b, ...)" followed by "=>".
+func (p *Parser) isArrowFunction() bool {
+	if !p.peekMatches(1, tokentype.LEFT_PAREN) {
+		return false
+	}
+
+	lookahead := 2
+	if !p.peekMatches(lookahead, tokentype.RIGHT_PAREN) {
+		for {
+			if !p.peekMatches(lookahead, tokentype.IDENTIFIER) {
+				return false
+			}
+			lookahead++
+			if !p.peekMatches(lookahead, tokentype.COMMA) {
+				break
+			}
+			lookahead++
+		}
+		if !p.peekMatches(lookahead, tokentype.RIGHT_PAREN) {
+			return false
+		}
+	}
+	return p.peekMatches(lookahead+1, tokentype.ARROW)
+}
+
+// Parse a list literal.
+func (p *Parser) parseList() (ast.Expr, error) {
+	openBracket := p.advance()
+
+	// Extract the elements of the list, if any.
+	elements := []ast.Expr{}
+	if !p.peekMatches(1, tokentype.RIGHT_BRACKET) {
+		for {
+			element, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+
+			// After the element, if there is not a comma, the elements are done being parsed.
+			if !p.peekMatches(1, tokentype.COMMA) {
+				break
+			}
+			p.advance()
+		}
+	}
+
+	_, err := p.consume(tokentype.RIGHT_BRACKET, "Expected ']' after list elements.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ListExpr{
+		OpenBracket: openBracket,
+		Elements:    elements,
+	}, nil
+}
+
+// Parse a map literal.
+func (p *Parser) parseMap() (ast.Expr, error) {
+	openBrace := p.advance()
+
+	// Extract the entries of the map, if any.
+	keys := []ast.Expr{}
+	values := []ast.Expr{}
+	if !p.peekMatches(1, tokentype.RIGHT_BRACE) {
+		for {
+			key, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = p.consume(tokentype.COLON, "Expected ':' after map key.")
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+
+			// After the entry, if there is not a comma, the entries are done being parsed.
+			if !p.peekMatches(1, tokentype.COMMA) {
+				break
+			}
+			p.advance()
+		}
+	}
+
+	_, err := p.consume(tokentype.RIGHT_BRACE, "Expected '}' after map entries.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.MapExpr{
+		OpenBrace: openBrace,
+		Keys:      keys,
+		Values:    values,
+	}, nil
+}
+
+// Parse the rest of an interpolated string, starting from the string's first part.
+// Desugars the interpolated string to a concatenation of its parts. The following two are equivalent:
+//  1. "x = ${x}, y = ${y}."
+//  2. (((("x = " + x) + ", y = ") + y) + ".")
+//
+// The concatenation always starts with a string, so each embedded expression is converted to a string.
+func (p *Parser) parseInterpolation(firstPart *token.Token) (ast.Expr, error) {
+	var expr ast.Expr = &ast.LiteralExpr{Value: firstPart.Literal}
+	part := firstPart
+	for part.Type == tokentype.INTERPOLATION {
+		embedded, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		expr = concatenate(expr, embedded, part)
+
+		if !p.peekMatches(1, tokentype.INTERPOLATION, tokentype.STRING) {
+			return nil, loxerr.AtToken(p.peek(1), loxerr.ExpectedToken, "Expected '}' after string interpolation expression.")
+		}
+		part = p.advance()
+		if part.Literal != "" {
+			expr = concatenate(expr, &ast.LiteralExpr{Value: part.Literal}, part)
+		}
+	}
+	return expr, nil
+}
+
+// Create the expression "left + right", attributed to the given part of an interpolated string.
+func concatenate(left ast.Expr, right ast.Expr, part *token.Token) ast.Expr {
+	plus := &token.Token{
+		Type:    tokentype.PLUS,
+		Lexeme:  "+",
+		Literal: nil,
+		Line:    part.Line,
+		Column:  part.Column,
+		Offset:  part.Offset,
+		Length:  0,
+	}
+	return &ast.BinaryExpr{Left: left, Operator: plus, Right: right}
 }
 
 // Advance the current pointer to the next token if it matches typeToMatch.
@@ -752,7 +1188,7 @@ func (p *Parser) consume(typeToMatch tokentype.TokenType, errorMessage string) (
 		p.current++
 		return nextToken, nil
 	}
-	return nil, loxerr.AtToken(nextToken, errorMessage)
+	return nil, loxerr.AtToken(nextToken, loxerr.ExpectedToken, errorMessage)
 }
 
 // Advance the current pointer to the next token.
@@ -791,7 +1227,7 @@ func (p *Parser) synchronize() {
 	stopAtTokens := []tokentype.TokenType{
 		tokentype.CLASS, tokentype.FUN, tokentype.VAR,
 		tokentype.FOR, tokentype.IF, tokentype.WHILE,
-		tokentype.PRINT, tokentype.RETURN,
+		tokentype.PRINT, tokentype.RETURN, tokentype.IMPORT,
 	}
 
 	for !p.isAtEnd() {
@@ -4,7 +4,9 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/kaschnit/golox/pkg/ast"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
 	"github.com/kaschnit/golox/pkg/token"
 	"github.com/kaschnit/golox/pkg/token/tokentype"
 	"github.com/stretchr/testify/assert"
@@ -107,6 +109,12 @@ func assertIsBinaryExpr(t *testing.T, expr ast.Expr) *ast.BinaryExpr {
 	return tree
 }
 
+func assertIsLogicalExpr(t *testing.T, expr ast.Expr) *ast.LogicalExpr {
+	tree, ok := expr.(*ast.LogicalExpr)
+	assert.True(t, ok)
+	return tree
+}
+
 func assertIsUnaryExpr(t *testing.T, expr ast.Expr) *ast.UnaryExpr {
 	tree, ok := expr.(*ast.UnaryExpr)
 	assert.True(t, ok)
@@ -203,6 +211,23 @@ func testBinaryExpressionWithLiterals(t *testing.T, expectedOp *token.Token) {
 	assertBinaryExprOfLiterals(t, expr, lhsValue, expectedOp, rhsValue)
 }
 
+func testLogicalExpressionWithLiterals(t *testing.T, expectedOp *token.Token) {
+	lhsValue := "lhsToken"
+	rhsValue := "rhsToken"
+
+	// "lhsToken" <expectedOp> "rhsToken" <EOF>
+	parser := NewParser([]*token.Token{
+		strToken(lhsValue), expectedOp, strToken(rhsValue), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	expr := assertIsLogicalExpr(t, tree)
+	assertTokensEqual(t, expectedOp, expr.Operator)
+	assert.Equal(t, lhsValue, assertIsLiteralExpr(t, expr.Left).Value)
+	assert.Equal(t, rhsValue, assertIsLiteralExpr(t, expr.Right).Value)
+}
+
 func testUnaryExpression(t *testing.T, expectedOp *token.Token) {
 	rhsValue := "rhsToken"
 
@@ -460,11 +485,43 @@ func TestParseExpression_Comparison(t *testing.T) {
 }
 
 func TestParseExpression_LogicalOr(t *testing.T) {
-	testBinaryExpressionWithLiterals(t, symToken(tokentype.OR, "or"))
+	testLogicalExpressionWithLiterals(t, symToken(tokentype.OR, "or"))
 }
 
 func TestParseExpression_LogicalAnd(t *testing.T) {
-	testBinaryExpressionWithLiterals(t, symToken(tokentype.AND, "and"))
+	testLogicalExpressionWithLiterals(t, symToken(tokentype.AND, "and"))
+}
+
+func TestParseExpression_LogicalAndBindsTighterThanOr(t *testing.T) {
+	// true or false and false <EOF>
+	orToken := symToken(tokentype.OR, "or")
+	andToken := symToken(tokentype.AND, "and")
+	parser := NewParser([]*token.Token{
+		boolToken(true), orToken, boolToken(false), andToken, boolToken(false), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	orExpr := assertIsLogicalExpr(t, tree)
+	assertTokensEqual(t, orToken, orExpr.Operator)
+	assertIsLiteralExpr(t, orExpr.Left)
+
+	andExpr := assertIsLogicalExpr(t, orExpr.Right)
+	assertTokensEqual(t, andToken, andExpr.Operator)
+}
+
+func TestParseExpression_LogicalAndIsLeftAssociative(t *testing.T) {
+	// true and false and false <EOF>
+	parser := NewParser([]*token.Token{
+		boolToken(true), symToken(tokentype.AND, "and"), boolToken(false),
+		symToken(tokentype.AND, "and"), boolToken(false), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	outer := assertIsLogicalExpr(t, tree)
+	assertIsLogicalExpr(t, outer.Left)
+	assertIsLiteralExpr(t, outer.Right)
 }
 
 func TestParseExpression_Term(t *testing.T) {
@@ -671,6 +728,46 @@ func TestParseVarStmt_Basic(t *testing.T) {
 	assert.Equal(t, rhsVal, rhsExpr.Value)
 }
 
+func TestParseImportStmt(t *testing.T) {
+	// import "util.lox" as util; <EOF>
+	importKeyword := symToken(tokentype.IMPORT, "import")
+	path := strToken("util.lox")
+	parser := NewParser([]*token.Token{
+		importKeyword, path, symToken(tokentype.AS, "as"), symToken(tokentype.IDENTIFIER, "util"),
+		symToken(tokentype.SEMICOLON, ";"), eofToken(),
+	})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, err)
+
+	importStmt, ok := tree.(*ast.ImportStmt)
+	assert.True(t, ok)
+	assert.Equal(t, importKeyword, importStmt.Keyword)
+	assert.Equal(t, path, importStmt.Path)
+	assert.Equal(t, "util", importStmt.Name.Lexeme)
+}
+
+func TestParseImportStmt_MissingAs(t *testing.T) {
+	// import "util.lox" util; <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.IMPORT, "import"), strToken("util.lox"), symToken(tokentype.IDENTIFIER, "util"),
+		symToken(tokentype.SEMICOLON, ";"), eofToken(),
+	})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, tree)
+	assert.ErrorContains(t, err, "Expected 'as' after module path.")
+}
+
+func TestParseImportStmt_PathNotString(t *testing.T) {
+	// import util as util; <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.IMPORT, "import"), symToken(tokentype.IDENTIFIER, "util"), symToken(tokentype.AS, "as"),
+		symToken(tokentype.IDENTIFIER, "util"), symToken(tokentype.SEMICOLON, ";"), eofToken(),
+	})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, tree)
+	assert.ErrorContains(t, err, "Expected module path after 'import'.")
+}
+
 func TestParseVarStmt_InvalidLhsNumerical(t *testing.T) {
 	lhsVal := 1
 	rhsVal := "hello"
@@ -766,6 +863,7 @@ func TestParseWhileStmt_Basic(t *testing.T) {
 	assert.Nil(t, err)
 
 	whileStmt := assertIsWhileStmt(t, tree)
+	assert.Equal(t, tokentype.WHILE, whileStmt.Keyword.Type)
 	cond := assertIsLiteralExpr(t, whileStmt.Condition)
 	block := assertIsBlockStmt(t, whileStmt.LoopStatement)
 	assert.Equal(t, cond.Value, false)
@@ -797,6 +895,7 @@ func TestParseForStmt_Empty(t *testing.T) {
 
 	// For loop desugared to a while loop.
 	whileStmt := assertIsWhileStmt(t, tree)
+	assert.Equal(t, tokentype.FOR, whileStmt.Keyword.Type)
 
 	// Condition is implicitly true if not provided.
 	cond := assertIsLiteralExpr(t, whileStmt.Condition)
@@ -941,15 +1040,12 @@ func TestParseForStmt_OnlyIncrement(t *testing.T) {
 	cond := assertIsLiteralExpr(t, whileStmt.Condition)
 	assert.Equal(t, cond.Value, true)
 
-	// Block has a block within it.
-	// The outer block contains the inner block, then the increment.
-	// The inner block contains the actual loop body.
-	outerBlock := assertIsBlockStmt(t, whileStmt.LoopStatement)
-	assert.Len(t, outerBlock.Statements, 2)
-	innerBlock := assertIsBlockStmt(t, outerBlock.Statements[0])
-	assert.Len(t, innerBlock.Statements, 1)
-	increment := assertIsExprStmt(t, outerBlock.Statements[1])
-	incrementExpr := assertIsBinaryExpr(t, increment.Expression)
+	// The loop body is the block as written, and the increment is kept on the while loop
+	// so that it still runs after a 'continue'.
+	block := assertIsBlockStmt(t, whileStmt.LoopStatement)
+	assert.Len(t, block.Statements, 1)
+	assertIsPrintStmt(t, block.Statements[0])
+	incrementExpr := assertIsBinaryExpr(t, whileStmt.Increment)
 	incrementLeft := assertIsLiteralExpr(t, incrementExpr.Left)
 	assert.Equal(t, incrLeftVal, incrementLeft.Value)
 	incrementRight := assertIsLiteralExpr(t, incrementExpr.Right)
@@ -994,17 +1090,451 @@ func TestParseForStmt_AllLoopExprs(t *testing.T) {
 	cond := assertIsLiteralExpr(t, whileStmt.Condition)
 	assert.Equal(t, false, cond.Value)
 
-	// Block has a block within it.
-	// The outer block contains the inner block, then the increment.
-	// The inner block contains the actual loop body.
-	outerBlock := assertIsBlockStmt(t, whileStmt.LoopStatement)
-	assert.Len(t, outerBlock.Statements, 2)
-	innerBlock := assertIsBlockStmt(t, outerBlock.Statements[0])
-	assert.Len(t, innerBlock.Statements, 1)
-	increment := assertIsExprStmt(t, outerBlock.Statements[1])
-	incrementExpr := assertIsBinaryExpr(t, increment.Expression)
+	// The loop body is the block as written, and the increment is kept on the while loop
+	// so that it still runs after a 'continue'.
+	block := assertIsBlockStmt(t, whileStmt.LoopStatement)
+	assert.Len(t, block.Statements, 1)
+	assertIsPrintStmt(t, block.Statements[0])
+	incrementExpr := assertIsBinaryExpr(t, whileStmt.Increment)
 	incrementLeft := assertIsLiteralExpr(t, incrementExpr.Left)
 	assert.Equal(t, incrLeftVal, incrementLeft.Value)
 	incrementRight := assertIsLiteralExpr(t, incrementExpr.Right)
 	assert.Equal(t, incrRightVal, incrementRight.Value)
 }
+
+func TestParseBreakAndContinueStmt(t *testing.T) {
+	// break; continue; <EOF>
+	breakKeyword := symToken(tokentype.BREAK, "break")
+	continueKeyword := symToken(tokentype.CONTINUE, "continue")
+	parser := NewParser([]*token.Token{
+		breakKeyword, symToken(tokentype.SEMICOLON, ";"),
+		continueKeyword, symToken(tokentype.SEMICOLON, ";"),
+		eofToken(),
+	})
+
+	tree, err := parser.parseStatement()
+	assert.Nil(t, err)
+	breakStmt, ok := tree.(*ast.BreakStmt)
+	assert.True(t, ok)
+	assert.Equal(t, breakKeyword, breakStmt.Keyword)
+
+	tree, err = parser.parseStatement()
+	assert.Nil(t, err)
+	continueStmt, ok := tree.(*ast.ContinueStmt)
+	assert.True(t, ok)
+	assert.Equal(t, continueKeyword, continueStmt.Keyword)
+}
+
+func TestParseBreakStmt_MissingSemicolon(t *testing.T) {
+	// break <EOF>
+	parser := NewParser([]*token.Token{symToken(tokentype.BREAK, "break"), eofToken()})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, tree)
+	assert.ErrorContains(t, err, "Expected ';' after 'break'.")
+}
+
+func TestParseThrowStmt(t *testing.T) {
+	// throw "oops"; <EOF>
+	throwKeyword := symToken(tokentype.THROW, "throw")
+	parser := NewParser([]*token.Token{
+		throwKeyword, strToken("oops"), symToken(tokentype.SEMICOLON, ";"), eofToken(),
+	})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, err)
+
+	throwStmt, ok := tree.(*ast.ThrowStmt)
+	assert.True(t, ok)
+	assert.Equal(t, throwKeyword, throwStmt.Keyword)
+	assert.Equal(t, "oops", assertIsLiteralExpr(t, throwStmt.Value).Value)
+}
+
+func TestParseTryStmt_CatchAndFinally(t *testing.T) {
+	// try { print 1; } catch (e) {} finally {} <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.TRY, "try"), symToken(tokentype.LEFT_BRACE, "{"),
+		printToken(), numToken(1), symToken(tokentype.SEMICOLON, ";"), symToken(tokentype.RIGHT_BRACE, "}"),
+		symToken(tokentype.CATCH, "catch"), symToken(tokentype.LEFT_PAREN, "("), symToken(tokentype.IDENTIFIER, "e"),
+		symToken(tokentype.RIGHT_PAREN, ")"), symToken(tokentype.LEFT_BRACE, "{"), symToken(tokentype.RIGHT_BRACE, "}"),
+		symToken(tokentype.FINALLY, "finally"), symToken(tokentype.LEFT_BRACE, "{"), symToken(tokentype.RIGHT_BRACE, "}"),
+		eofToken(),
+	})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, err)
+
+	tryStmt, ok := tree.(*ast.TryStmt)
+	assert.True(t, ok)
+	assert.Len(t, tryStmt.Body.Statements, 1)
+	assertIsPrintStmt(t, tryStmt.Body.Statements[0])
+	assert.Equal(t, "e", tryStmt.CatchName.Lexeme)
+	assert.Empty(t, tryStmt.CatchBody.Statements)
+	assert.Empty(t, tryStmt.FinallyBody.Statements)
+}
+
+func TestParseTryStmt_OnlyFinally(t *testing.T) {
+	// try {} finally {} <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.TRY, "try"), symToken(tokentype.LEFT_BRACE, "{"), symToken(tokentype.RIGHT_BRACE, "}"),
+		symToken(tokentype.FINALLY, "finally"), symToken(tokentype.LEFT_BRACE, "{"), symToken(tokentype.RIGHT_BRACE, "}"),
+		eofToken(),
+	})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, err)
+
+	tryStmt, ok := tree.(*ast.TryStmt)
+	assert.True(t, ok)
+	assert.Nil(t, tryStmt.CatchName)
+	assert.Nil(t, tryStmt.CatchBody)
+	assert.NotNil(t, tryStmt.FinallyBody)
+}
+
+func TestParseTryStmt_MissingCatchAndFinally(t *testing.T) {
+	// try {} <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.TRY, "try"), symToken(tokentype.LEFT_BRACE, "{"), symToken(tokentype.RIGHT_BRACE, "}"),
+		eofToken(),
+	})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, tree)
+	assert.ErrorContains(t, err, "Expected 'catch' or 'finally' after try block.")
+}
+
+func TestParseClassStmt_WithSuperclass(t *testing.T) {
+	// class B < A { m() { return super.m; } } <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.CLASS, "class"), symToken(tokentype.IDENTIFIER, "B"),
+		symToken(tokentype.LESS, "<"), symToken(tokentype.IDENTIFIER, "A"),
+		symToken(tokentype.LEFT_BRACE, "{"),
+		symToken(tokentype.IDENTIFIER, "m"), symToken(tokentype.LEFT_PAREN, "("), symToken(tokentype.RIGHT_PAREN, ")"),
+		symToken(tokentype.LEFT_BRACE, "{"),
+		symToken(tokentype.RETURN, "return"), symToken(tokentype.SUPER, "super"), symToken(tokentype.DOT, "."),
+		symToken(tokentype.IDENTIFIER, "m"), symToken(tokentype.SEMICOLON, ";"),
+		symToken(tokentype.RIGHT_BRACE, "}"),
+		symToken(tokentype.RIGHT_BRACE, "}"),
+		eofToken(),
+	})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, err)
+
+	classStmt, ok := tree.(*ast.ClassStmt)
+	assert.True(t, ok)
+	assert.Equal(t, "B", classStmt.Name.Lexeme)
+	assert.Equal(t, "A", classStmt.Superclass.Name.Lexeme)
+	assert.Len(t, classStmt.Methods, 1)
+
+	returnStmt, ok := classStmt.Methods[0].Body[0].(*ast.ReturnStmt)
+	assert.True(t, ok)
+	superExpr, ok := returnStmt.Expression.(*ast.SuperExpr)
+	assert.True(t, ok)
+	assert.Equal(t, tokentype.SUPER, superExpr.Keyword.Type)
+	assert.Equal(t, "m", superExpr.Method.Lexeme)
+}
+
+func TestParseClassStmt_WithoutSuperclass(t *testing.T) {
+	// class A {} <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.CLASS, "class"), symToken(tokentype.IDENTIFIER, "A"),
+		symToken(tokentype.LEFT_BRACE, "{"), symToken(tokentype.RIGHT_BRACE, "}"),
+		eofToken(),
+	})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, err)
+
+	classStmt, ok := tree.(*ast.ClassStmt)
+	assert.True(t, ok)
+	assert.Nil(t, classStmt.Superclass)
+}
+
+func TestParseExpression_SuperWithoutMethod(t *testing.T) {
+	// super <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.SUPER, "super"), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, tree)
+	assert.Error(t, err)
+}
+
+func TestParseExpression_StringInterpolation(t *testing.T) {
+	firstPart := &token.Token{Type: tokentype.INTERPOLATION, Lexeme: `"x = ${`, Literal: "x = ", Line: 1}
+	lastPart := &token.Token{Type: tokentype.STRING, Lexeme: `}!"`, Literal: "!", Line: 1}
+
+	// "x = ${x}!" <EOF>
+	parser := NewParser([]*token.Token{
+		firstPart, symToken(tokentype.IDENTIFIER, "x"), lastPart, eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	// Desugared to ("x = " + x) + "!"
+	outer := assertIsBinaryExpr(t, tree)
+	assert.Equal(t, tokentype.PLUS, outer.Operator.Type)
+	assert.Equal(t, "!", assertIsLiteralExpr(t, outer.Right).Value)
+
+	inner := assertIsBinaryExpr(t, outer.Left)
+	assert.Equal(t, tokentype.PLUS, inner.Operator.Type)
+	assert.Equal(t, "x = ", assertIsLiteralExpr(t, inner.Left).Value)
+	assert.Equal(t, "x", assertIsVarExpr(t, inner.Right).Name.Lexeme)
+}
+
+func TestParseExpression_StringInterpolationUnterminated(t *testing.T) {
+	firstPart := &token.Token{Type: tokentype.INTERPOLATION, Lexeme: `"x = ${`, Literal: "x = ", Line: 1}
+
+	// "x = ${x <EOF>
+	parser := NewParser([]*token.Token{
+		firstPart, symToken(tokentype.IDENTIFIER, "x"), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, tree)
+	assert.ErrorContains(t, err, "Expected '}' after string interpolation expression.")
+}
+
+func TestParseExpression_ListLiteral(t *testing.T) {
+	// [1, "two"] <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.LEFT_BRACKET, "["), numToken(1), symToken(tokentype.COMMA, ","),
+		strToken("two"), symToken(tokentype.RIGHT_BRACKET, "]"), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	listExpr, ok := tree.(*ast.ListExpr)
+	assert.True(t, ok)
+	assert.Len(t, listExpr.Elements, 2)
+	assert.Equal(t, 1, assertIsLiteralExpr(t, listExpr.Elements[0]).Value)
+	assert.Equal(t, "two", assertIsLiteralExpr(t, listExpr.Elements[1]).Value)
+}
+
+func TestParseExpression_EmptyListLiteral(t *testing.T) {
+	// [] <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.LEFT_BRACKET, "["), symToken(tokentype.RIGHT_BRACKET, "]"), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	listExpr, ok := tree.(*ast.ListExpr)
+	assert.True(t, ok)
+	assert.Empty(t, listExpr.Elements)
+}
+
+func TestParseExpression_UnterminatedListLiteral(t *testing.T) {
+	// [1 <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.LEFT_BRACKET, "["), numToken(1), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, tree)
+	assert.ErrorContains(t, err, "Expected ']' after list elements.")
+}
+
+func TestParseExpression_FunctionExpr(t *testing.T) {
+	// fun (a) { print a; } <EOF>
+	funKeyword := symToken(tokentype.FUN, "fun")
+	parser := NewParser([]*token.Token{
+		funKeyword, symToken(tokentype.LEFT_PAREN, "("), symToken(tokentype.IDENTIFIER, "a"),
+		symToken(tokentype.RIGHT_PAREN, ")"), symToken(tokentype.LEFT_BRACE, "{"),
+		printToken(), symToken(tokentype.IDENTIFIER, "a"), symToken(tokentype.SEMICOLON, ";"),
+		symToken(tokentype.RIGHT_BRACE, "}"), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	functionExpr, ok := tree.(*ast.FunctionExpr)
+	assert.True(t, ok)
+	assert.Equal(t, funKeyword, functionExpr.Keyword)
+	assert.Len(t, functionExpr.Params, 1)
+	assert.Equal(t, "a", functionExpr.Params[0].Lexeme)
+	assert.Len(t, functionExpr.Body, 1)
+	assertIsPrintStmt(t, functionExpr.Body[0])
+}
+
+func TestParseExpression_ArrowFunction(t *testing.T) {
+	// (a, b) => a <EOF>
+	arrow := symToken(tokentype.ARROW, "=>")
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.LEFT_PAREN, "("), symToken(tokentype.IDENTIFIER, "a"), symToken(tokentype.COMMA, ","),
+		symToken(tokentype.IDENTIFIER, "b"), symToken(tokentype.RIGHT_PAREN, ")"), arrow,
+		symToken(tokentype.IDENTIFIER, "a"), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	functionExpr, ok := tree.(*ast.FunctionExpr)
+	assert.True(t, ok)
+	assert.Equal(t, arrow, functionExpr.Keyword)
+	assert.Len(t, functionExpr.Params, 2)
+	assert.Len(t, functionExpr.Body, 1)
+
+	returnStmt, ok := functionExpr.Body[0].(*ast.ReturnStmt)
+	assert.True(t, ok)
+	assert.Equal(t, arrow, returnStmt.Keyword)
+	assert.Equal(t, "a", assertIsVarExpr(t, returnStmt.Expression).Name.Lexeme)
+}
+
+func TestParseExpression_ArrowFunctionNoParams(t *testing.T) {
+	// () => 1 <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.LEFT_PAREN, "("), symToken(tokentype.RIGHT_PAREN, ")"),
+		symToken(tokentype.ARROW, "=>"), numToken(1), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	functionExpr, ok := tree.(*ast.FunctionExpr)
+	assert.True(t, ok)
+	assert.Empty(t, functionExpr.Params)
+}
+
+func TestParseExpression_GroupedIdentifierIsNotArrowFunction(t *testing.T) {
+	// (a) <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.LEFT_PAREN, "("), symToken(tokentype.IDENTIFIER, "a"),
+		symToken(tokentype.RIGHT_PAREN, ")"), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+	assertIsVarExpr(t, assertIsGroupingExpr(t, tree).Expression)
+}
+
+func TestParseStmt_FunctionExprStatement(t *testing.T) {
+	// fun () {}(); <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.FUN, "fun"), symToken(tokentype.LEFT_PAREN, "("), symToken(tokentype.RIGHT_PAREN, ")"),
+		symToken(tokentype.LEFT_BRACE, "{"), symToken(tokentype.RIGHT_BRACE, "}"),
+		symToken(tokentype.LEFT_PAREN, "("), symToken(tokentype.RIGHT_PAREN, ")"),
+		symToken(tokentype.SEMICOLON, ";"), eofToken(),
+	})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, err)
+
+	call := assertIsCallExpr(t, assertIsExprStmt(t, tree).Expression)
+	_, ok := call.Callee.(*ast.FunctionExpr)
+	assert.True(t, ok)
+}
+
+func TestParseExpression_MapLiteral(t *testing.T) {
+	// {"a": 1, 2: true} <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.LEFT_BRACE, "{"), strToken("a"), symToken(tokentype.COLON, ":"), numToken(1),
+		symToken(tokentype.COMMA, ","), numToken(2), symToken(tokentype.COLON, ":"), boolToken(true),
+		symToken(tokentype.RIGHT_BRACE, "}"), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	mapExpr, ok := tree.(*ast.MapExpr)
+	assert.True(t, ok)
+	assert.Len(t, mapExpr.Keys, 2)
+	assert.Len(t, mapExpr.Values, 2)
+	assert.Equal(t, "a", assertIsLiteralExpr(t, mapExpr.Keys[0]).Value)
+	assert.Equal(t, 1, assertIsLiteralExpr(t, mapExpr.Values[0]).Value)
+	assert.Equal(t, 2, assertIsLiteralExpr(t, mapExpr.Keys[1]).Value)
+	assert.Equal(t, true, assertIsLiteralExpr(t, mapExpr.Values[1]).Value)
+}
+
+func TestParseExpression_EmptyMapLiteral(t *testing.T) {
+	// {} <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.LEFT_BRACE, "{"), symToken(tokentype.RIGHT_BRACE, "}"), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	mapExpr, ok := tree.(*ast.MapExpr)
+	assert.True(t, ok)
+	assert.Empty(t, mapExpr.Keys)
+}
+
+func TestParseExpression_MapLiteralMissingColon(t *testing.T) {
+	// {"a" 1} <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.LEFT_BRACE, "{"), strToken("a"), numToken(1), symToken(tokentype.RIGHT_BRACE, "}"), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, tree)
+	assert.ErrorContains(t, err, "Expected ':' after map key.")
+}
+
+func TestParseStatement_BraceIsBlockNotMap(t *testing.T) {
+	// {} <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.LEFT_BRACE, "{"), symToken(tokentype.RIGHT_BRACE, "}"), eofToken(),
+	})
+	tree, err := parser.parseStatement()
+	assert.Nil(t, err)
+
+	_, ok := tree.(*ast.BlockStmt)
+	assert.True(t, ok)
+}
+
+func TestParseExpression_IndexGet(t *testing.T) {
+	// xs[0][1] <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.IDENTIFIER, "xs"),
+		symToken(tokentype.LEFT_BRACKET, "["), numToken(0), symToken(tokentype.RIGHT_BRACKET, "]"),
+		symToken(tokentype.LEFT_BRACKET, "["), numToken(1), symToken(tokentype.RIGHT_BRACKET, "]"),
+		eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	outer, ok := tree.(*ast.IndexGetExpr)
+	assert.True(t, ok)
+	assert.Equal(t, 1, assertIsLiteralExpr(t, outer.Index).Value)
+
+	inner, ok := outer.Object.(*ast.IndexGetExpr)
+	assert.True(t, ok)
+	assert.Equal(t, 0, assertIsLiteralExpr(t, inner.Index).Value)
+	assert.Equal(t, "xs", assertIsVarExpr(t, inner.Object).Name.Lexeme)
+}
+
+func TestParseExpression_IndexSet(t *testing.T) {
+	// xs[0] = 5 <EOF>
+	openBracket := symToken(tokentype.LEFT_BRACKET, "[")
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.IDENTIFIER, "xs"), openBracket, numToken(0), symToken(tokentype.RIGHT_BRACKET, "]"),
+		symToken(tokentype.EQUAL, "="), numToken(5), eofToken(),
+	})
+	tree, err := parser.parseExpression()
+	assert.Nil(t, err)
+
+	indexSetExpr, ok := tree.(*ast.IndexSetExpr)
+	assert.True(t, ok)
+	assert.Equal(t, openBracket, indexSetExpr.OpenBracket)
+	assert.Equal(t, "xs", assertIsVarExpr(t, indexSetExpr.Object).Name.Lexeme)
+	assert.Equal(t, 0, assertIsLiteralExpr(t, indexSetExpr.Index).Value)
+	assert.Equal(t, 5, assertIsLiteralExpr(t, indexSetExpr.Value).Value)
+}
+
+func TestParse_ErrorsAreInParseStage(t *testing.T) {
+	// print ; <EOF>
+	parser := NewParser([]*token.Token{
+		symToken(tokentype.PRINT, "print"), symToken(tokentype.SEMICOLON, ";"), eofToken(),
+	})
+	_, err := parser.Parse()
+	assert.Error(t, err)
+	assert.Equal(t, loxerr.StageParse, err.(*multierror.Error).Errors[0].(loxerr.Staged).Stage())
+	assert.Equal(t, loxerr.ExpectedExpression, err.(*multierror.Error).Errors[0].(loxerr.Coded).Code())
+}
+
+func TestParseRepl_FinalSemicolonIsOptional(t *testing.T) {
+	// 1 + 2 <EOF>
+	parser := NewParser([]*token.Token{
+		numToken(1), symToken(tokentype.PLUS, "+"), numToken(2), eofToken(),
+	})
+	program, err := parser.ParseRepl()
+	assert.Nil(t, err)
+	assert.Len(t, program.Statements, 1)
+	exprStmt := assertIsExprStmt(t, program.Statements[0])
+	assertIsBinaryExpr(t, exprStmt.Expression)
+
+	// The semicolon is only optional at the end, and only in a REPL.
+	// 1 2 <EOF>
+	parser = NewParser([]*token.Token{numToken(1), numToken(2), eofToken()})
+	_, err = parser.ParseRepl()
+	assert.Error(t, err)
+
+	parser = NewParser([]*token.Token{numToken(1), eofToken()})
+	_, err = parser.Parse()
+	assert.Error(t, err)
+}
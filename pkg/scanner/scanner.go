@@ -3,6 +3,8 @@ package scanner
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/hashicorp/go-multierror"
 	loxerr "github.com/kaschnit/golox/pkg/errors"
@@ -15,6 +17,9 @@ type Scanner struct {
 	// The source code to tokenize.
 	source []rune
 
+	// The byte offset in the source code of each character, followed by the length of the source code in bytes.
+	offsets []int
+
 	// Whether or not any errors have been encountered while scanning so far.
 	hasError bool
 
@@ -30,17 +35,39 @@ type Scanner struct {
 
 	// Line number of the lexeme being tokenized.
 	line int
+
+	// Points to the first character of the current line.
+	lineStart int
+
+	// The column of the start of the lexeme currently being tokenized.
+	startColumn int
+
+	// The brace depth inside each "${...}" string interpolation being scanned, innermost last.
+	// The string resumes when a '}' is found at depth 0.
+	interpolations []int
 }
 
 // Create a Scanner instance.
 func NewScanner(source string) *Scanner {
+	runes := []rune(source)
+	offsets := make([]int, 0, len(runes)+1)
+	for offset := range source {
+		offsets = append(offsets, offset)
+	}
+	offsets = append(offsets, len(source))
+
 	return &Scanner{
-		source:   []rune(source),
-		hasError: false,
-		finished: false,
-		start:    0,
-		current:  0,
-		line:     1,
+		source:      runes,
+		offsets:     offsets,
+		hasError:    false,
+		finished:    false,
+		start:       0,
+		current:     0,
+		line:        1,
+		lineStart:   0,
+		startColumn: 1,
+
+		interpolations: make([]int, 0),
 	}
 }
 
@@ -51,6 +78,9 @@ func (s *Scanner) Reset() {
 	s.start = 0
 	s.current = 0
 	s.line = 1
+	s.lineStart = 0
+	s.startColumn = 1
+	s.interpolations = s.interpolations[:0]
 }
 
 // Tokenize the remaining input that has not been scanned yet.
@@ -71,19 +101,24 @@ func (s *Scanner) ScanAllTokens() ([]*token.Token, error) {
 	return tokens, errs.ErrorOrNil()
 }
 
+// Check whether the source code scanned so far ends inside of a "${...}" string interpolation.
+func (s *Scanner) InInterpolation() bool {
+	return len(s.interpolations) > 0
+}
+
 // Scan the next token.
 func (s *Scanner) ScanToken() (*token.Token, error) {
 	if s.finished {
 		return nil, loxerr.Internal("Scanner has already reached EOF")
 	}
 
-	s.start = s.current
+	s.startLexeme()
 	result, err := s.scanToken()
 
 	// Keep moving on if there's no errors but no token is returned.
 	// Do this to handle whitespace that won't matter after tokenization.
 	for err == nil && result == nil {
-		s.start = s.current
+		s.startLexeme()
 		result, err = s.scanToken()
 	}
 
@@ -95,12 +130,7 @@ func (s *Scanner) ScanToken() (*token.Token, error) {
 func (s *Scanner) scanToken() (*token.Token, error) {
 	if s.isAtEnd() {
 		s.finished = true
-		return &token.Token{
-			Type:    tokentype.EOF,
-			Lexeme:  "",
-			Literal: nil,
-			Line:    s.line,
-		}, nil
+		return s.createToken(tokentype.EOF), nil
 	}
 
 	char := s.advance()
@@ -112,9 +142,24 @@ func (s *Scanner) scanToken() (*token.Token, error) {
 	case ')':
 		return s.createToken(tokentype.RIGHT_PAREN), nil
 	case '{':
+		if depth := len(s.interpolations); depth > 0 {
+			s.interpolations[depth-1]++
+		}
 		return s.createToken(tokentype.LEFT_BRACE), nil
 	case '}':
+		if depth := len(s.interpolations); depth > 0 {
+			// A '}' that closes an interpolation resumes the string it's embedded in.
+			if s.interpolations[depth-1] == 0 {
+				s.interpolations = s.interpolations[:depth-1]
+				return s.scanString()
+			}
+			s.interpolations[depth-1]--
+		}
 		return s.createToken(tokentype.RIGHT_BRACE), nil
+	case '[':
+		return s.createToken(tokentype.LEFT_BRACKET), nil
+	case ']':
+		return s.createToken(tokentype.RIGHT_BRACKET), nil
 	case ',':
 		return s.createToken(tokentype.COMMA), nil
 	case '.':
@@ -125,6 +170,8 @@ func (s *Scanner) scanToken() (*token.Token, error) {
 		return s.createToken(tokentype.PLUS), nil
 	case ';':
 		return s.createToken(tokentype.SEMICOLON), nil
+	case ':':
+		return s.createToken(tokentype.COLON), nil
 	case '*':
 		return s.createToken(tokentype.STAR), nil
 
@@ -161,13 +208,16 @@ func (s *Scanner) scanToken() (*token.Token, error) {
 		if s.peek(1) == '=' {
 			s.current++
 			return s.createToken(tokentype.EQUAL_EQUAL), nil
+		} else if s.peek(1) == '>' {
+			s.current++
+			return s.createToken(tokentype.ARROW), nil
 		} else {
 			return s.createToken(tokentype.EQUAL), nil
 		}
 
 	// Ignore whitespace
 	case '\n':
-		s.line++
+		s.newLine()
 		fallthrough
 	case ' ':
 		fallthrough
@@ -190,33 +240,121 @@ func (s *Scanner) scanToken() (*token.Token, error) {
 		}
 		s.hasError = true
 		errMsg := fmt.Sprintf("Unrecognized character %s", string(char))
-		return nil, loxerr.AtLine(s.line, errMsg)
+		return nil, s.errorAt(s.start, loxerr.UnrecognizedCharacter, errMsg)
 	}
 }
 
-// Tokenize a string.
+// Tokenize a string, decoding its escape sequences.
+// The string ends at the closing '"', producing a STRING token, or at the start of an
+// embedded "${expression}", producing an INTERPOLATION token. In the latter case the
+// rest of the string is scanned once the '}' closing the expression is reached.
 func (s *Scanner) scanString() (*token.Token, error) {
-	// Advance the current pointer until EOF or closing quote is encountered.
-	for s.peek(1) != '"' && !s.isAtEnd() {
-		if s.peek(1) == '\n' {
-			s.line++
+	var value strings.Builder
+	var escapeErr error
+
+	for !s.isAtEnd() {
+		char := s.advance()
+		switch char {
+		case '"':
+			if escapeErr != nil {
+				return nil, escapeErr
+			}
+			return s.createStringToken(tokentype.STRING, value.String()), nil
+		case '$':
+			if s.peek(1) != '{' {
+				value.WriteRune(char)
+				continue
+			}
+			s.current++
+			s.interpolations = append(s.interpolations, 0)
+			if escapeErr != nil {
+				return nil, escapeErr
+			}
+			return s.createStringToken(tokentype.INTERPOLATION, value.String()), nil
+		case '\\':
+			decoded, err := s.scanEscape(s.current - 1)
+			if err != nil {
+				// Keep scanning to the end of the string so scanning can recover after it.
+				s.hasError = true
+				if escapeErr == nil {
+					escapeErr = err
+				}
+				continue
+			}
+			value.WriteRune(decoded)
+		case '\n':
+			s.newLine()
+			value.WriteRune(char)
+		default:
+			value.WriteRune(char)
 		}
-		s.current++
 	}
 
+	return nil, s.errorAt(s.start, loxerr.UnterminatedString, "Unterminated string.")
+}
+
+// Decode the escape sequence following the backslash at start in a string.
+func (s *Scanner) scanEscape(start int) (rune, error) {
 	if s.isAtEnd() {
-		return nil, loxerr.AtLine(s.line, "Unterminated string.")
+		return 0, s.errorAt(s.start, loxerr.UnterminatedString, "Unterminated string.")
 	}
 
-	// Increment the current pointer past the closing '"'
-	s.current++
+	char := s.advance()
+	switch char {
+	case 'n':
+		return '\n', nil
+	case 't':
+		return '\t', nil
+	case 'r':
+		return '\r', nil
+	case '0':
+		return '\x00', nil
+	case '"', '\\', '$':
+		return char, nil
+	case 'u':
+		return s.scanUnicodeEscape(start)
+	case '\n':
+		err := loxerr.WithStage(loxerr.AtSpan(s.lineOf(start), s.span(start, start+1), loxerr.InvalidEscape, "Invalid escape sequence at end of line."), loxerr.StageScan)
+		s.newLine()
+		return 0, err
+	default:
+		return 0, s.errorAt(start, loxerr.InvalidEscape, fmt.Sprintf("Invalid escape sequence '\\%c'.", char))
+	}
+}
 
-	return &token.Token{
-		Type:    tokentype.STRING,
-		Lexeme:  s.currentLexeme(),
-		Literal: s.subCurrentLexeme(1, 1),
-		Line:    s.line,
-	}, nil
+// Decode a unicode escape sequence starting at start, either "\uXXXX" with exactly 4 hex digits
+// or "\u{X}" with 1 to 6 hex digits, after the "\u" has been consumed.
+func (s *Scanner) scanUnicodeEscape(start int) (rune, error) {
+	invalidErr := s.errorAt(start, loxerr.InvalidEscape, "Invalid unicode escape sequence.")
+
+	var digits string
+	if s.peek(1) == '{' {
+		s.current++
+		start := s.current
+		for !s.isAtEnd() && s.peek(1) != '}' && s.peek(1) != '"' {
+			s.current++
+		}
+		if s.peek(1) != '}' {
+			return 0, invalidErr
+		}
+		digits = string(s.source[start:s.current])
+		s.current++
+		if len(digits) < 1 || len(digits) > 6 {
+			return 0, invalidErr
+		}
+	} else {
+		if s.current+4 > len(s.source) {
+			return 0, invalidErr
+		}
+		digits = string(s.source[s.current : s.current+4])
+		s.current += 4
+	}
+
+	codePoint, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(codePoint)) {
+		return 0, invalidErr
+	}
+	return rune(codePoint), nil
 }
 
 // Tokenize a number.
@@ -238,12 +376,7 @@ func (s *Scanner) scanNumber() (*token.Token, error) {
 		return nil, loxerr.Internal(errMsg)
 	}
 
-	return &token.Token{
-		Type:    tokentype.NUMBER,
-		Lexeme:  lexeme,
-		Literal: literal,
-		Line:    s.line,
-	}, nil
+	return s.createLiteralToken(tokentype.NUMBER, literal), nil
 }
 
 // Tokenize an identifier.
@@ -252,13 +385,7 @@ func (s *Scanner) scanIdentifier() (*token.Token, error) {
 		s.current++
 	}
 
-	lexeme := s.currentLexeme()
-	return &token.Token{
-		Type:    tokentype.FromIdentifier(lexeme),
-		Lexeme:  lexeme,
-		Literal: nil,
-		Line:    s.line,
-	}, nil
+	return s.createToken(tokentype.FromIdentifier(s.currentLexeme())), nil
 }
 
 func (s *Scanner) discardLine() {
@@ -271,24 +398,79 @@ func (s *Scanner) discardLine() {
 
 // Helper for creating a token based on the scanner's current state.
 func (s *Scanner) createToken(tokenType tokentype.TokenType) *token.Token {
+	return s.createLiteralToken(tokenType, nil)
+}
+
+// Helper for creating a string or string interpolation token with the decoded value as its literal.
+func (s *Scanner) createStringToken(tokenType tokentype.TokenType, value string) *token.Token {
+	return s.createLiteralToken(tokenType, value)
+}
+
+// Helper for creating a token with a literal value based on the scanner's current state.
+func (s *Scanner) createLiteralToken(tokenType tokentype.TokenType, literal interface{}) *token.Token {
 	return &token.Token{
 		Type:    tokenType,
 		Lexeme:  s.currentLexeme(),
-		Literal: nil,
+		Literal: literal,
 		Line:    s.line,
+		Column:  s.startColumn,
+		Offset:  s.offsets[s.start],
+		Length:  s.offsets[s.current] - s.offsets[s.start],
 	}
 }
 
+// Start scanning a lexeme at the current character.
+func (s *Scanner) startLexeme() {
+	s.start = s.current
+	s.startColumn = s.current - s.lineStart + 1
+}
+
+// Move to the line after the newline that was just consumed.
+func (s *Scanner) newLine() {
+	s.line++
+	s.lineStart = s.current
+}
+
+// Get the span of the source code from the character at start up to the character at end.
+func (s *Scanner) span(start int, end int) loxerr.Span {
+	if end > len(s.source) {
+		end = len(s.source)
+	}
+	lineStart := start
+	for lineStart > 0 && s.source[lineStart-1] != '\n' {
+		lineStart--
+	}
+	return loxerr.Span{
+		Offset: s.offsets[start],
+		Length: s.offsets[end] - s.offsets[start],
+		Line:   s.lineOf(start),
+		Column: start - lineStart + 1,
+		Text:   string(s.source[start:end]),
+	}
+}
+
+// Get the line that the character at start is on, which is an earlier line than the current one
+// when the error is in a string that spans lines.
+func (s *Scanner) lineOf(start int) int {
+	line := 1
+	for _, char := range s.source[:start] {
+		if char == '\n' {
+			line++
+		}
+	}
+	return line
+}
+
+// Create an error on the line of start located at the source code from start up to the current character.
+func (s *Scanner) errorAt(start int, code loxerr.Code, message string) error {
+	return loxerr.WithStage(loxerr.AtSpan(s.lineOf(start), s.span(start, s.current), code, message), loxerr.StageScan)
+}
+
 // Get the lexeme that the scanner is currently pointing to.
 func (s *Scanner) currentLexeme() string {
 	return string(s.source[s.start:s.current])
 }
 
-// Get a substring of the lexeme that the scanner is currently pointing to.
-func (s *Scanner) subCurrentLexeme(beginOffset int, endOffset int) string {
-	return string(s.source[s.start+beginOffset : s.current-endOffset])
-}
-
 // Get the character that is lookahead in front of the current pointer.
 func (s *Scanner) peek(lookahead int) rune {
 	// Return null char if the scanner is at the end of input
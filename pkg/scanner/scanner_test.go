@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/go-multierror"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
 	"github.com/kaschnit/golox/pkg/token"
 	"github.com/kaschnit/golox/pkg/token/tokentype"
 	"github.com/stretchr/testify/assert"
@@ -87,16 +88,24 @@ func TestScanTokenFloat_Success(t *testing.T) {
 
 func TestScanTokenKeyword_Success(t *testing.T) {
 	verifyScanTokenSingleKeyword(t, "and", tokentype.AND)
+	verifyScanTokenSingleKeyword(t, "as", tokentype.AS)
 	verifyScanTokenSingleKeyword(t, "class", tokentype.CLASS)
 	verifyScanTokenSingleKeyword(t, "else", tokentype.ELSE)
 	verifyScanTokenSingleKeyword(t, "false", tokentype.FALSE)
 	verifyScanTokenSingleKeyword(t, "fun", tokentype.FUN)
 	verifyScanTokenSingleKeyword(t, "for", tokentype.FOR)
 	verifyScanTokenSingleKeyword(t, "if", tokentype.IF)
+	verifyScanTokenSingleKeyword(t, "import", tokentype.IMPORT)
 	verifyScanTokenSingleKeyword(t, "nil", tokentype.NIL)
 	verifyScanTokenSingleKeyword(t, "or", tokentype.OR)
 	verifyScanTokenSingleKeyword(t, "print", tokentype.PRINT)
 	verifyScanTokenSingleKeyword(t, "return", tokentype.RETURN)
+	verifyScanTokenSingleKeyword(t, "break", tokentype.BREAK)
+	verifyScanTokenSingleKeyword(t, "continue", tokentype.CONTINUE)
+	verifyScanTokenSingleKeyword(t, "throw", tokentype.THROW)
+	verifyScanTokenSingleKeyword(t, "try", tokentype.TRY)
+	verifyScanTokenSingleKeyword(t, "catch", tokentype.CATCH)
+	verifyScanTokenSingleKeyword(t, "finally", tokentype.FINALLY)
 	verifyScanTokenSingleKeyword(t, "super", tokentype.SUPER)
 	verifyScanTokenSingleKeyword(t, "this", tokentype.THIS)
 	verifyScanTokenSingleKeyword(t, "true", tokentype.TRUE)
@@ -206,17 +215,21 @@ func TestScanSymbols_Success(t *testing.T) {
 	verifyScanTokenSingle(t, ")", tokentype.RIGHT_PAREN, nil)
 	verifyScanTokenSingle(t, "{", tokentype.LEFT_BRACE, nil)
 	verifyScanTokenSingle(t, "}", tokentype.RIGHT_BRACE, nil)
+	verifyScanTokenSingle(t, "[", tokentype.LEFT_BRACKET, nil)
+	verifyScanTokenSingle(t, "]", tokentype.RIGHT_BRACKET, nil)
 	verifyScanTokenSingle(t, ",", tokentype.COMMA, nil)
 	verifyScanTokenSingle(t, ".", tokentype.DOT, nil)
 	verifyScanTokenSingle(t, "-", tokentype.MINUS, nil)
 	verifyScanTokenSingle(t, "+", tokentype.PLUS, nil)
 	verifyScanTokenSingle(t, ";", tokentype.SEMICOLON, nil)
+	verifyScanTokenSingle(t, ":", tokentype.COLON, nil)
 	verifyScanTokenSingle(t, "/", tokentype.SLASH, nil)
 	verifyScanTokenSingle(t, "*", tokentype.STAR, nil)
 	verifyScanTokenSingle(t, "!", tokentype.BANG, nil)
 	verifyScanTokenSingle(t, "!=", tokentype.BANG_EQUAL, nil)
 	verifyScanTokenSingle(t, "=", tokentype.EQUAL, nil)
 	verifyScanTokenSingle(t, "==", tokentype.EQUAL_EQUAL, nil)
+	verifyScanTokenSingle(t, "=>", tokentype.ARROW, nil)
 	verifyScanTokenSingle(t, "<", tokentype.LESS, nil)
 	verifyScanTokenSingle(t, "<=", tokentype.LESS_EQUAL, nil)
 	verifyScanTokenSingle(t, ">", tokentype.GREATER, nil)
@@ -489,7 +502,7 @@ func TestScanAllTokens_RecoversFromErrors(t *testing.T) {
 	var err error
 
 	input := `abc 123 program
-xyz ^^~[ _ {()()} ~
+xyz ^^~@ _ {()()} ~
 
 
 `
@@ -521,3 +534,141 @@ func TestScanAllTokens_IgnoresComments(t *testing.T) {
 	assert.Len(t, tokens, 6) // The number of tokens in the string, plus an EOF token
 	assert.Nil(t, err)       // All valid input
 }
+
+func TestScanTokenString_EscapeSequences(t *testing.T) {
+	input := `"a\nb\tc\rd\"e\\f\$g\0"`
+	scanner := NewScanner(input)
+	token, err := scanner.ScanToken()
+	assert.Nil(t, err)
+	assert.Equal(t, tokentype.STRING, token.Type)
+	assert.Equal(t, input, token.Lexeme)
+	assert.Equal(t, "a\nb\tc\rd\"e\\f$g\x00", token.Literal)
+	verifyNextScanTokenIsEOF(t, scanner, 1)
+}
+
+func TestScanTokenString_UnicodeEscapes(t *testing.T) {
+	verifyScanTokenSingle(t, `"é"`, tokentype.STRING, "é")
+	verifyScanTokenSingle(t, `"\u{e9}"`, tokentype.STRING, "é")
+	verifyScanTokenSingle(t, `"\u{1F600}!"`, tokentype.STRING, "😀!")
+}
+
+func TestScanTokenString_InvalidEscapes(t *testing.T) {
+	inputs := []string{`"\q"`, `"\u12"`, `"\u{}"`, `"\u{110000}"`, `"\u{12345678}"`, `"\uZZZZ"`, `"\u{12"`}
+	for _, input := range inputs {
+		scanner := NewScanner(input)
+		token, err := scanner.ScanToken()
+		assert.Nil(t, token, input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestScanTokenString_InvalidEscapeRecovers(t *testing.T) {
+	scanner := NewScanner(`"bad \q escape" 123`)
+	tokens, err := scanner.ScanAllTokens()
+	assert.IsType(t, &multierror.Error{}, err)
+	assert.Equal(t, 1, err.(*multierror.Error).Len())
+	assert.ErrorContains(t, err, `Invalid escape sequence '\q'.`)
+
+	assert.Len(t, tokens, 2)
+	assert.Equal(t, tokentype.NUMBER, tokens[0].Type)
+	assert.Equal(t, tokentype.EOF, tokens[1].Type)
+}
+
+func TestScanTokenString_Interpolation(t *testing.T) {
+	scanner := NewScanner(`"x = ${x}, y = ${ {} }."`)
+	tokens, err := scanner.ScanAllTokens()
+	assert.Nil(t, err)
+
+	expectedTypes := []tokentype.TokenType{
+		tokentype.INTERPOLATION, tokentype.IDENTIFIER,
+		tokentype.INTERPOLATION, tokentype.LEFT_BRACE, tokentype.RIGHT_BRACE,
+		tokentype.STRING, tokentype.EOF,
+	}
+	assert.Len(t, tokens, len(expectedTypes))
+	for i, expectedType := range expectedTypes {
+		assert.Equal(t, expectedType, tokens[i].Type)
+	}
+
+	assert.Equal(t, "x = ", tokens[0].Literal)
+	assert.Equal(t, `"x = ${`, tokens[0].Lexeme)
+	assert.Equal(t, ", y = ", tokens[2].Literal)
+	assert.Equal(t, ".", tokens[5].Literal)
+}
+
+func TestScanTokenString_NestedInterpolation(t *testing.T) {
+	scanner := NewScanner(`"a ${"b ${c}"} d"`)
+	tokens, err := scanner.ScanAllTokens()
+	assert.Nil(t, err)
+
+	expectedTypes := []tokentype.TokenType{
+		tokentype.INTERPOLATION, tokentype.INTERPOLATION, tokentype.IDENTIFIER,
+		tokentype.STRING, tokentype.STRING, tokentype.EOF,
+	}
+	assert.Len(t, tokens, len(expectedTypes))
+	for i, expectedType := range expectedTypes {
+		assert.Equal(t, expectedType, tokens[i].Type)
+	}
+	assert.Equal(t, "", tokens[3].Literal)
+	assert.Equal(t, " d", tokens[4].Literal)
+}
+
+func TestScanTokenString_DollarWithoutBrace(t *testing.T) {
+	verifyScanTokenSingle(t, `"costs $5"`, tokentype.STRING, "costs $5")
+}
+
+func TestScanAllTokens_TracksPositions(t *testing.T) {
+	scanner := NewScanner("var s = \"é\nb\";\n  print s;")
+	tokens, err := scanner.ScanAllTokens()
+	assert.Nil(t, err)
+
+	type position struct {
+		lexeme                       string
+		line, column, offset, length int
+	}
+	expected := []position{
+		{"var", 1, 1, 0, 3},
+		{"s", 1, 5, 4, 1},
+		{"=", 1, 7, 6, 1},
+		{"\"é\nb\"", 2, 9, 8, 6},
+		{";", 2, 3, 14, 1},
+		{"print", 3, 3, 18, 5},
+		{"s", 3, 9, 24, 1},
+		{";", 3, 10, 25, 1},
+		{"", 3, 11, 26, 0},
+	}
+	assert.Len(t, tokens, len(expected))
+	for i, token := range tokens {
+		assert.Equal(t, expected[i], position{token.Lexeme, token.Line, token.Column, token.Offset, token.Length})
+	}
+}
+
+func TestScanToken_ErrorSpans(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		span  loxerr.Span
+	}{
+		{"a @", loxerr.Span{Offset: 2, Length: 1, Line: 1, Column: 3, Text: "@"}},
+		{`x "abc`, loxerr.Span{Offset: 2, Length: 4, Line: 1, Column: 3, Text: `"abc`}},
+		{`"a \q"`, loxerr.Span{Offset: 3, Length: 2, Line: 1, Column: 4, Text: `\q`}},
+		{`"\u12"`, loxerr.Span{Offset: 1, Length: 2, Line: 1, Column: 2, Text: `\u`}},
+		{"a\n  \"é\" @", loxerr.Span{Offset: 9, Length: 1, Line: 2, Column: 7, Text: "@"}},
+		{"print 1;\nvar x = \"abc\n", loxerr.Span{Offset: 17, Length: 5, Line: 2, Column: 9, Text: "\"abc\n"}},
+		{"\"a\nb \\q\"", loxerr.Span{Offset: 5, Length: 2, Line: 2, Column: 3, Text: `\q`}},
+	} {
+		_, err := NewScanner(tc.input).ScanAllTokens()
+		assert.IsType(t, &multierror.Error{}, err, tc.input)
+
+		spanned, ok := err.(*multierror.Error).Errors[0].(loxerr.Spanned)
+		assert.True(t, ok, tc.input)
+		span, ok := spanned.Span()
+		assert.True(t, ok, tc.input)
+		assert.Equal(t, tc.span, span, tc.input)
+	}
+}
+
+func TestScanAllTokens_ErrorsAreInScanStage(t *testing.T) {
+	_, err := NewScanner("@").ScanAllTokens()
+	assert.IsType(t, &multierror.Error{}, err)
+	assert.Equal(t, loxerr.StageScan, err.(*multierror.Error).Errors[0].(loxerr.Staged).Stage())
+	assert.Equal(t, loxerr.UnrecognizedCharacter, err.(*multierror.Error).Errors[0].(loxerr.Coded).Code())
+}
@@ -9,6 +9,9 @@ import (
 
 func ScanSourceFile(filepath string) ([]*token.Token, error) {
 	f, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
 	defer func() {
 		err := f.Close()
 		if err != nil {
@@ -16,10 +19,6 @@ func ScanSourceFile(filepath string) ([]*token.Token, error) {
 		}
 	}()
 
-	if err != nil {
-		return nil, err
-	}
-
 	sourceCode, err := io.ReadAll(f)
 	if err != nil {
 		return nil, err
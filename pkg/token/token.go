@@ -7,10 +7,20 @@ import (
 )
 
 type Token struct {
-	Type    tokentype.TokenType
-	Lexeme  string
-	Literal interface{}
-	Line    int
+	Type    tokentype.TokenType `json:"type"`
+	Lexeme  string              `json:"lexeme"`
+	Literal interface{}         `json:"literal"`
+
+	// The line the token ends on.
+	Line int `json:"line"`
+
+	// The column of the token's first character on the line it starts on, counting from 1.
+	Column int `json:"column"`
+
+	// The position of the token in the source code in bytes, and its length in bytes.
+	// Tokens that don't appear in the source code have a length of 0.
+	Offset int `json:"offset"`
+	Length int `json:"length"`
 }
 
 func (t *Token) String() string {
@@ -11,34 +11,34 @@ func TestTokenToString(t *testing.T) {
 	var token Token
 
 	token = Token{
-		tokentype.ELSE,
-		"abc",
-		nil,
-		3,
+		Type:    tokentype.ELSE,
+		Lexeme:  "abc",
+		Literal: nil,
+		Line:    3,
 	}
 	assert.Equal(t, "ELSE abc nil", token.String())
 
 	token = Token{
-		tokentype.FOR,
-		"for",
-		55,
-		3,
+		Type:    tokentype.FOR,
+		Lexeme:  "for",
+		Literal: 55,
+		Line:    3,
 	}
 	assert.Equal(t, "FOR for 55", token.String())
 
 	token = Token{
-		tokentype.BANG_EQUAL,
-		"123",
-		"xyz",
-		3,
+		Type:    tokentype.BANG_EQUAL,
+		Lexeme:  "123",
+		Literal: "xyz",
+		Line:    3,
 	}
 	assert.Equal(t, "BANG_EQUAL 123 xyz", token.String())
 
 	token = Token{
-		tokentype.EOF,
-		"",
-		nil,
-		3,
+		Type:    tokentype.EOF,
+		Lexeme:  "",
+		Literal: nil,
+		Line:    3,
 	}
 	assert.Equal(t, "EOF  nil", token.String())
 }
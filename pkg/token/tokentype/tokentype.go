@@ -1,5 +1,10 @@
 package tokentype
 
+import (
+	"fmt"
+	"sort"
+)
+
 type TokenType int
 
 //go:generate go run golang.org/x/tools/cmd/stringer -type=TokenType -output tokentype_string.generated.go
@@ -9,17 +14,21 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
 	DOT
 	MINUS
 	PLUS
 	SEMICOLON
+	COLON
 	SLASH
 	STAR
 	BANG
 	BANG_EQUAL
 	EQUAL
 	EQUAL_EQUAL
+	ARROW
 	GREATER
 	GREATER_EQUAL
 	LESS
@@ -27,60 +36,95 @@ const (
 	IDENTIFIER
 	NUMBER
 	STRING
+
+	// The part of an interpolated string before an embedded "${expression}".
+	INTERPOLATION
+
 	AND
+	AS
+	BREAK
+	CATCH
 	CLASS
+	CONTINUE
 	ELSE
 	FALSE
+	FINALLY
 	FUN
 	FOR
 	IF
+	IMPORT
 	NIL
 	OR
 	PRINT
 	RETURN
 	SUPER
 	THIS
+	THROW
 	TRUE
+	TRY
 	VAR
 	WHILE
 	EOF
 )
 
+// The keywords of the language and the types of their tokens.
+var keywords = map[string]TokenType{
+	"and":      AND,
+	"as":       AS,
+	"break":    BREAK,
+	"catch":    CATCH,
+	"class":    CLASS,
+	"continue": CONTINUE,
+	"else":     ELSE,
+	"false":    FALSE,
+	"finally":  FINALLY,
+	"fun":      FUN,
+	"for":      FOR,
+	"if":       IF,
+	"import":   IMPORT,
+	"nil":      NIL,
+	"or":       OR,
+	"print":    PRINT,
+	"return":   RETURN,
+	"super":    SUPER,
+	"this":     THIS,
+	"throw":    THROW,
+	"true":     TRUE,
+	"try":      TRY,
+	"var":      VAR,
+	"while":    WHILE,
+}
+
+// Get the type of the token of an identifier, which is IDENTIFIER unless the identifier is a keyword.
 func FromIdentifier(identifier string) TokenType {
-	switch identifier {
-	case "and":
-		return AND
-	case "class":
-		return CLASS
-	case "else":
-		return ELSE
-	case "false":
-		return FALSE
-	case "fun":
-		return FUN
-	case "for":
-		return FOR
-	case "if":
-		return IF
-	case "nil":
-		return NIL
-	case "or":
-		return OR
-	case "print":
-		return PRINT
-	case "return":
-		return RETURN
-	case "super":
-		return SUPER
-	case "this":
-		return THIS
-	case "true":
-		return TRUE
-	case "var":
-		return VAR
-	case "while":
-		return WHILE
-	default:
-		return IDENTIFIER
+	if tokenType, ok := keywords[identifier]; ok {
+		return tokenType
+	}
+	return IDENTIFIER
+}
+
+// Get the keywords of the language in alphabetical order.
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Encode the token type as its name, like "IDENTIFIER", so that it's readable in JSON.
+func (t TokenType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Decode the token type from its name, like "IDENTIFIER".
+func (t *TokenType) UnmarshalText(text []byte) error {
+	for tokenType := TokenType(0); tokenType <= EOF; tokenType++ {
+		if tokenType.String() == string(text) {
+			*t = tokenType
+			return nil
+		}
 	}
+	return fmt.Errorf("unknown token type '%s'", text)
 }
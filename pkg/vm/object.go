@@ -0,0 +1,587 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/kaschnit/golox/pkg/bytecode"
+	"github.com/kaschnit/golox/pkg/conversion"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/native"
+)
+
+// Runtime representation of a function along with the variables it captures.
+type Closure struct {
+	Function *bytecode.Function
+	upvalues []*Upvalue
+
+	// The script or module that created the closure, whose globals the function's body uses.
+	module *moduleScope
+}
+
+func NewClosure(function *bytecode.Function, module *moduleScope) *Closure {
+	return &Closure{
+		Function: function,
+		upvalues: make([]*Upvalue, function.UpvalueCount),
+		module:   module,
+	}
+}
+
+// The globals of a script or imported module, and the directory that its imports are relative to.
+type moduleScope struct {
+	globals map[string]interface{}
+	dir     string
+
+	// The file the script or module was loaded from, or "" if it wasn't loaded from a file.
+	file string
+}
+
+// Get the name of the closure's function as shown in stack traces.
+func (c *Closure) name() string {
+	if c.Function.Name == "" {
+		return "<script>"
+	}
+	return c.Function.Name
+}
+
+func (c *Closure) String() string {
+	if c.Function.Name == "" {
+		return "<script>"
+	}
+	return fmt.Sprintf("<function %s [%p]>", c.Function.Name, c)
+}
+
+func (c *Closure) TypeName() string {
+	return "function"
+}
+
+// Runtime representation of a variable captured by a closure.
+// While the variable is still on the stack the upvalue is "open" and refers to its stack slot.
+// Once the variable goes out of scope the upvalue is "closed" and holds the value itself.
+type Upvalue struct {
+	// The stack slot of the variable while the upvalue is open.
+	slot int
+
+	// The value of the variable once the upvalue is closed.
+	closed   interface{}
+	isClosed bool
+
+	// The next open upvalue, in order of decreasing stack slot.
+	next *Upvalue
+}
+
+func (u *Upvalue) get(vm *VM) interface{} {
+	if u.isClosed {
+		return u.closed
+	}
+	return vm.stack[u.slot]
+}
+
+func (u *Upvalue) set(vm *VM, value interface{}) {
+	if u.isClosed {
+		u.closed = value
+	} else {
+		vm.stack[u.slot] = value
+	}
+}
+
+// Runtime representation of user-defined class.
+type Class struct {
+	Name          string
+	initializer   *Closure
+	methods       map[string]*Closure
+	staticMethods map[string]*Closure
+	fields        map[string]interface{}
+}
+
+func NewClass(name string) *Class {
+	return &Class{
+		Name:          name,
+		initializer:   nil,
+		methods:       make(map[string]*Closure),
+		staticMethods: make(map[string]*Closure),
+		fields:        make(map[string]interface{}),
+	}
+}
+
+// Copy the superclass's methods into the class. This happens before the class's
+// own methods are attached, so the class's own methods override inherited ones.
+func (c *Class) inherit(superclass *Class) {
+	c.initializer = superclass.initializer
+	for name, method := range superclass.methods {
+		c.methods[name] = method
+	}
+	for name, method := range superclass.staticMethods {
+		c.staticMethods[name] = method
+	}
+}
+
+// Find the method that "super.name" refers to. Static methods refer to the
+// superclass's static methods, since their receiver is the class itself.
+func (c *Class) findSuperMethod(receiver interface{}, name string) (*Closure, bool) {
+	if _, isStatic := receiver.(*Class); isStatic {
+		method, ok := c.staticMethods[name]
+		return method, ok
+	}
+	if name == "init" {
+		return c.initializer, c.initializer != nil
+	}
+	method, ok := c.methods[name]
+	return method, ok
+}
+
+func (c *Class) Arity() int {
+	if c.initializer == nil {
+		return 0
+	}
+	return c.initializer.Function.Arity
+}
+
+func (c *Class) String() string {
+	return fmt.Sprintf("<class %s [%p]>", c.Name, c)
+}
+
+func (c *Class) TypeName() string {
+	return "class"
+}
+
+// Runtime representation of an instance of a user-defined class.
+type Instance struct {
+	Class  *Class
+	fields map[string]interface{}
+}
+
+func NewInstance(cls *Class) *Instance {
+	return &Instance{
+		Class:  cls,
+		fields: make(map[string]interface{}),
+	}
+}
+
+func (i *Instance) String() string {
+	return fmt.Sprintf("<instance of %s [%p]>", i.Class, i)
+}
+
+func (i *Instance) TypeName() string {
+	return "instance"
+}
+
+// Get the names of the instance's fields and of its class's methods, sorted.
+func (i *Instance) PropertyNames() []string {
+	names := make([]string, 0, len(i.fields)+len(i.Class.methods))
+	for name := range i.fields {
+		names = append(names, name)
+	}
+	for name := range i.Class.methods {
+		if _, ok := i.fields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Runtime representation of a method bound to the object it was accessed on.
+type BoundMethod struct {
+	Receiver interface{}
+	Method   *Closure
+}
+
+func NewBoundMethod(receiver interface{}, method *Closure) *BoundMethod {
+	return &BoundMethod{
+		Receiver: receiver,
+		Method:   method,
+	}
+}
+
+func (b *BoundMethod) String() string {
+	return b.Method.String()
+}
+
+func (b *BoundMethod) TypeName() string {
+	return "function"
+}
+
+// Runtime representation of a list.
+type List struct {
+	Elements []interface{}
+}
+
+func NewList(elements []interface{}) *List {
+	return &List{Elements: elements}
+}
+
+func (l *List) get(indexValue interface{}) (interface{}, error) {
+	index, err := l.toIndex(indexValue, len(l.Elements)-1)
+	if err != nil {
+		return nil, err
+	}
+	return l.Elements[index], nil
+}
+
+func (l *List) set(indexValue interface{}, value interface{}) error {
+	index, err := l.toIndex(indexValue, len(l.Elements)-1)
+	if err != nil {
+		return err
+	}
+	l.Elements[index] = value
+	return nil
+}
+
+// Get the native method with the given name, bound to the list.
+func (l *List) method(name string) (*NativeFunction, bool) {
+	switch name {
+	case "push":
+		return NewNativeFunction("push", 1, func(args []interface{}) (interface{}, error) {
+			l.Elements = append(l.Elements, args[0])
+			return nil, nil
+		}), true
+	case "pop":
+		return NewNativeFunction("pop", 0, func(args []interface{}) (interface{}, error) {
+			if len(l.Elements) == 0 {
+				return nil, loxerr.New(loxerr.EmptyList, "Can't pop from an empty list.")
+			}
+			last := l.Elements[len(l.Elements)-1]
+			l.Elements = l.Elements[:len(l.Elements)-1]
+			return last, nil
+		}), true
+	case "len":
+		return NewNativeFunction("len", 0, func(args []interface{}) (interface{}, error) {
+			return float64(len(l.Elements)), nil
+		}), true
+	case "slice":
+		return NewNativeFunction("slice", 2, func(args []interface{}) (interface{}, error) {
+			start, err := l.toIndex(args[0], len(l.Elements))
+			if err != nil {
+				return nil, err
+			}
+			end, err := l.toIndex(args[1], len(l.Elements))
+			if err != nil {
+				return nil, err
+			}
+			if start > end {
+				return nil, loxerr.Errorf(loxerr.InvalidIndex, "Slice start %d is after slice end %d.", start, end)
+			}
+			elements := make([]interface{}, end-start)
+			copy(elements, l.Elements[start:end])
+			return NewList(elements), nil
+		}), true
+	case "insert":
+		return NewNativeFunction("insert", 2, func(args []interface{}) (interface{}, error) {
+			index, err := l.toIndex(args[0], len(l.Elements))
+			if err != nil {
+				return nil, err
+			}
+			l.Elements = append(l.Elements, nil)
+			copy(l.Elements[index+1:], l.Elements[index:])
+			l.Elements[index] = args[1]
+			return nil, nil
+		}), true
+	case "remove":
+		return NewNativeFunction("remove", 1, func(args []interface{}) (interface{}, error) {
+			index, err := l.toIndex(args[0], len(l.Elements)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed := l.Elements[index]
+			l.Elements = append(l.Elements[:index], l.Elements[index+1:]...)
+			return removed, nil
+		}), true
+	default:
+		return nil, false
+	}
+}
+
+func (l *List) String() string {
+	return l.StringVisiting(make(map[interface{}]bool))
+}
+
+// Convert the list to a string, where a list that's already being converted is shown as "[...]".
+func (l *List) StringVisiting(visiting map[interface{}]bool) string {
+	if visiting[l] {
+		return "[...]"
+	}
+	visiting[l] = true
+	defer delete(visiting, l)
+
+	elements := make([]string, len(l.Elements))
+	for i, element := range l.Elements {
+		elements[i] = conversion.ToStringVisiting(element, visiting)
+	}
+	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+}
+
+func (l *List) TypeName() string {
+	return "list"
+}
+
+// Convert the value to an index into the list, which must be a whole number from 0 to max.
+func (l *List) toIndex(value interface{}, max int) (int, error) {
+	floatValue, ok := value.(float64)
+	if !ok || floatValue != math.Trunc(floatValue) {
+		return 0, loxerr.Errorf(loxerr.InvalidIndex, "List index must be a whole number, got %v.", value)
+	}
+	if floatValue < 0 || floatValue > float64(max) {
+		return 0, loxerr.Errorf(loxerr.InvalidIndex, "List index %v is out of bounds for list of length %d.", value, len(l.Elements))
+	}
+	return int(floatValue), nil
+}
+
+// Runtime representation of a map.
+// Numbers, strings, booleans and nil are keyed by value; all other values are keyed by identity.
+// Reading a key that is not in the map is a runtime error, so use has() to check for a key first.
+// Entries are kept in insertion order.
+type Map struct {
+	keys    []interface{}
+	entries map[interface{}]interface{}
+}
+
+func NewMap() *Map {
+	return &Map{
+		keys:    []interface{}{},
+		entries: make(map[interface{}]interface{}),
+	}
+}
+
+func (m *Map) get(key interface{}) (interface{}, error) {
+	value, ok := m.entries[key]
+	if !ok {
+		return nil, loxerr.Errorf(loxerr.KeyNotFound, "Key '%s' not found in map.", conversion.ToString(key))
+	}
+	return value, nil
+}
+
+// Get the value of the key, and whether the map has the key.
+func (m *Map) Lookup(key interface{}) (interface{}, bool) {
+	value, ok := m.entries[key]
+	return value, ok
+}
+
+// Get the keys of the map, in insertion order.
+func (m *Map) Keys() []interface{} {
+	keys := make([]interface{}, len(m.keys))
+	copy(keys, m.keys)
+	return keys
+}
+
+// Set the value of the key, adding the key after the existing keys if the map doesn't have it.
+func (m *Map) Set(key interface{}, value interface{}) {
+	if _, ok := m.entries[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = value
+}
+
+func (m *Map) delete(key interface{}) bool {
+	if _, ok := m.entries[key]; !ok {
+		return false
+	}
+	delete(m.entries, key)
+	for i, existing := range m.keys {
+		if existing == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Get the native method with the given name, bound to the map.
+func (m *Map) method(name string) (*NativeFunction, bool) {
+	switch name {
+	case "keys":
+		return NewNativeFunction("keys", 0, func(args []interface{}) (interface{}, error) {
+			return NewList(m.Keys()), nil
+		}), true
+	case "values":
+		return NewNativeFunction("values", 0, func(args []interface{}) (interface{}, error) {
+			values := make([]interface{}, len(m.keys))
+			for i, key := range m.keys {
+				values[i] = m.entries[key]
+			}
+			return NewList(values), nil
+		}), true
+	case "has":
+		return NewNativeFunction("has", 1, func(args []interface{}) (interface{}, error) {
+			_, ok := m.entries[args[0]]
+			return ok, nil
+		}), true
+	case "delete":
+		return NewNativeFunction("delete", 1, func(args []interface{}) (interface{}, error) {
+			return m.delete(args[0]), nil
+		}), true
+	case "len":
+		return NewNativeFunction("len", 0, func(args []interface{}) (interface{}, error) {
+			return float64(len(m.keys)), nil
+		}), true
+	default:
+		return nil, false
+	}
+}
+
+func (m *Map) String() string {
+	return m.StringVisiting(make(map[interface{}]bool))
+}
+
+// Convert the map to a string, where a map that's already being converted is shown as "{...}".
+func (m *Map) StringVisiting(visiting map[interface{}]bool) string {
+	if visiting[m] {
+		return "{...}"
+	}
+	visiting[m] = true
+	defer delete(visiting, m)
+
+	entries := make([]string, len(m.keys))
+	for i, key := range m.keys {
+		entries[i] = fmt.Sprintf("%s: %s", conversion.ToString(key), conversion.ToStringVisiting(m.entries[key], visiting))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(entries, ", "))
+}
+
+func (m *Map) TypeName() string {
+	return "map"
+}
+
+// Runtime representation of a caught runtime error, with "message" and "line" properties.
+type Error struct {
+	cause *loxerr.LoxRuntimeError
+}
+
+func NewError(cause *loxerr.LoxRuntimeError) *Error {
+	return &Error{cause: cause}
+}
+
+func (e *Error) property(name string) (interface{}, bool) {
+	switch name {
+	case "message":
+		return e.cause.Message(), true
+	case "line":
+		return float64(e.cause.Token.Line), true
+	default:
+		return nil, false
+	}
+}
+
+func (e *Error) String() string {
+	return fmt.Sprintf("<error: %s>", e.cause.Message())
+}
+
+func (e *Error) TypeName() string {
+	return "error"
+}
+
+// Runtime representation of an imported module, whose properties are the names it defines at the top level.
+// Properties are read from the module's globals, so they reflect later assignments made by the module.
+type Module struct {
+	Name    string
+	globals map[string]interface{}
+	exports map[string]bool
+}
+
+func NewModule(name string, globals map[string]interface{}, exports []string) *Module {
+	exported := make(map[string]bool)
+	for _, export := range exports {
+		exported[export] = true
+	}
+	return &Module{
+		Name:    name,
+		globals: globals,
+		exports: exported,
+	}
+}
+
+// Get the value the module exports with the given name.
+func (m *Module) property(name string) (interface{}, bool) {
+	value, ok := m.globals[name]
+	return value, ok && m.exports[name]
+}
+
+func (m *Module) String() string {
+	return fmt.Sprintf("<module %s>", m.Name)
+}
+
+func (m *Module) TypeName() string {
+	return "module"
+}
+
+// Get the names that the module exports, sorted.
+func (m *Module) PropertyNames() []string {
+	names := make([]string, 0, len(m.exports))
+	for name := range m.exports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// A value being thrown, which unwinds the VM until an exception handler catches it.
+// Exceptions are only on the stack while a finally block runs before rethrowing them.
+type exception struct {
+	value interface{}
+
+	// The error reported if nothing catches the thrown value.
+	uncaught error
+}
+
+func (ex *exception) Error() string {
+	return ex.uncaught.Error()
+}
+
+// Runtime representation of interpreter-defined ("native") function.
+type NativeFunction struct {
+	name  string
+	arity int
+	code  func(args []interface{}) (interface{}, error)
+
+	// Whether the function takes arity or more args, which it checks itself.
+	variadic bool
+}
+
+func NewNativeFunction(name string, arity int, code func(args []interface{}) (interface{}, error)) *NativeFunction {
+	return &NativeFunction{
+		name:  name,
+		arity: arity,
+		code:  code,
+	}
+}
+
+// Create a NativeFunction that checks its args against the parameters of the registered native before calling it.
+func NewRegisteredNativeFunction(fn *native.Function) *NativeFunction {
+	return &NativeFunction{
+		name:     fn.Name,
+		arity:    fn.Arity(),
+		code:     fn.Call,
+		variadic: fn.Variadic,
+	}
+}
+
+func (f *NativeFunction) Arity() int {
+	return f.arity
+}
+
+func (f *NativeFunction) Call(args []interface{}) (interface{}, error) {
+	return f.code(args)
+}
+
+func (f *NativeFunction) String() string {
+	return fmt.Sprintf("<native function %s [%p]>", f.name, f)
+}
+
+func (f *NativeFunction) TypeName() string {
+	return "function"
+}
+
+// Get the values of the functions and namespaces in the registry by name, with namespaces as modules.
+func nativeGlobals(registry *native.Registry) map[string]interface{} {
+	globals := make(map[string]interface{})
+	for _, name := range registry.Names() {
+		if fn, ok := registry.Function(name); ok {
+			globals[name] = NewRegisteredNativeFunction(fn)
+		} else if namespace, ok := registry.Namespace(name); ok {
+			globals[name] = NewModule(name, nativeGlobals(namespace), namespace.Names())
+		}
+	}
+	return globals
+}
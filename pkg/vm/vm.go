@@ -0,0 +1,814 @@
+package vm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kaschnit/golox/pkg/bytecode"
+	"github.com/kaschnit/golox/pkg/conversion"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/module"
+	"github.com/kaschnit/golox/pkg/native"
+)
+
+// The default maximum depth of nested calls before a stack overflow is reported.
+const FramesMax = 1024
+
+// The state of a single function invocation.
+type callFrame struct {
+	closure *Closure
+
+	// The offset of the next instruction to execute in the closure's chunk.
+	ip int
+
+	// The index in the VM's stack of the frame's slot 0.
+	slots int
+}
+
+// An exception handler installed by a try statement.
+type handler struct {
+	// The number of frames and stack values when the handler was installed, which the VM unwinds to.
+	frameCount  int
+	stackHeight int
+
+	// The offset of the handler's code in the chunk of the frame that installed it.
+	ip int
+}
+
+// A stack-based virtual machine that executes compiled bytecode.
+type VM struct {
+	frames []*callFrame
+	stack  []interface{}
+
+	// The globals of the scripts the VM interprets, and the directory their imports are relative to.
+	main *moduleScope
+
+	// The upvalues still pointing at stack slots, in order of decreasing slot.
+	openUpvalues *Upvalue
+
+	// The installed exception handlers, innermost last.
+	handlers []handler
+
+	// The number of loop iterations a script may run, or 0 if there is no limit, and the number
+	// run so far, which is shared with the VMs of imported modules.
+	stepBudget int
+	steps      *int
+
+	// The maximum depth of nested calls, counting the script itself.
+	maxDepth int
+
+	// The loader of imported modules.
+	modules *module.Loader
+
+	// The context of the running script, which stops the script once it's done.
+	ctx context.Context
+
+	// The number of frames below the innermost call made from Go, which execution returns to.
+	baseFrames int
+
+	// The registries of native functions installed in addition to the standard ones.
+	natives []*native.Registry
+
+	// The streams that print statements write to and the readLine native reads from.
+	stdout io.Writer
+	stdin  *bufio.Reader
+}
+
+// Create a VM.
+func NewVM() *VM {
+	vm := &VM{
+		frames: make([]*callFrame, 0, FramesMax),
+		stack:  make([]interface{}, 0, FramesMax),
+		main: &moduleScope{
+			globals: nativeGlobals(native.Standard()),
+			dir:     "",
+			file:    "",
+		},
+		openUpvalues: nil,
+		handlers:     make([]handler, 0),
+		steps:        new(int),
+		maxDepth:     FramesMax,
+		modules:      module.NewLoader(module.SearchPathFromEnv()),
+		ctx:          context.Background(),
+		baseFrames:   0,
+		natives:      make([]*native.Registry, 0),
+		stdout:       os.Stdout,
+		stdin:        bufio.NewReader(os.Stdin),
+	}
+	for name, value := range nativeGlobals(native.Input(func() *bufio.Reader { return vm.stdin })) {
+		vm.main.globals[name] = value
+	}
+	return vm
+}
+
+// Set the writer that print statements write to.
+func (vm *VM) SetStdout(stdout io.Writer) {
+	vm.stdout = stdout
+}
+
+// Set the reader that the readLine native reads from.
+func (vm *VM) SetStdin(stdin io.Reader) {
+	vm.stdin = bufio.NewReader(stdin)
+}
+
+// Define the native functions of the registry as globals, in scripts and the modules they import.
+// Namespaces of natives are defined as modules.
+func (vm *VM) Install(registry *native.Registry) {
+	vm.natives = append(vm.natives, registry)
+	for name, value := range nativeGlobals(registry) {
+		vm.main.globals[name] = value
+	}
+}
+
+// Limit the number of loop iterations each script may run before it's stopped with a
+// runtime error. A budget of 0, the default, means there is no limit.
+func (vm *VM) SetStepBudget(budget int) {
+	vm.stepBudget = budget
+}
+
+// Set the maximum depth of nested calls, counting the script itself, before a stack overflow
+// is reported as a runtime error.
+func (vm *VM) SetMaxDepth(depth int) {
+	vm.maxDepth = depth
+}
+
+// Set the directories searched for imported modules that aren't found relative to the importing file.
+func (vm *VM) SetSearchPath(searchPath []string) {
+	vm.modules.SetSearchPath(searchPath)
+}
+
+// Get the value of the global variable with the given name, and whether it's defined.
+func (vm *VM) Global(name string) (interface{}, bool) {
+	value, ok := vm.main.globals[name]
+	return value, ok
+}
+
+// Get the global variables and their values, including the native functions.
+func (vm *VM) Globals() map[string]interface{} {
+	globals := make(map[string]interface{}, len(vm.main.globals))
+	for name, value := range vm.main.globals {
+		globals[name] = value
+	}
+	return globals
+}
+
+// Define the global variable with the given name, replacing its value if it's already defined.
+func (vm *VM) SetGlobal(name string, value interface{}) {
+	vm.main.globals[name] = value
+}
+
+// Execute the compiled top-level script.
+// Globals defined by the script remain available to later calls.
+func (vm *VM) Interpret(script *bytecode.Function) error {
+	_, err := vm.Run(context.Background(), script)
+	return err
+}
+
+// Execute the compiled top-level script, returning the value it returns.
+// The script is stopped with the context's error once the context is done.
+func (vm *VM) Run(ctx context.Context, script *bytecode.Function) (interface{}, error) {
+	*vm.steps = 0
+	return vm.runScript(ctx, script)
+}
+
+// Execute the compiled top-level script without resetting the step count, so that it counts
+// against the budget of the script that imported it.
+func (vm *VM) runScript(ctx context.Context, script *bytecode.Function) (interface{}, error) {
+	prevCtx := vm.ctx
+	vm.ctx = ctx
+	defer func() {
+		vm.ctx = prevCtx
+	}()
+
+	return vm.Call(NewClosure(script, vm.main))
+}
+
+// Call the callee with the args and run it to completion, returning its result.
+// Native functions may use this to call back into the script that called them.
+func (vm *VM) Call(callee interface{}, args ...interface{}) (interface{}, error) {
+	prevBaseFrames, stackHeight := vm.baseFrames, len(vm.stack)
+	vm.baseFrames = len(vm.frames)
+	defer func() {
+		vm.baseFrames = prevBaseFrames
+	}()
+
+	vm.push(callee)
+	for _, arg := range args {
+		vm.push(arg)
+	}
+	err := vm.callValue(callee, len(args))
+	if err == nil && len(vm.frames) > vm.baseFrames {
+		err = vm.run()
+	}
+	if err != nil {
+		vm.unwind(stackHeight)
+		// A value thrown through a native function keeps unwinding the script that called it.
+		if thrown, ok := err.(*exception); ok && vm.baseFrames == 0 {
+			return nil, thrown.uncaught
+		}
+		return nil, err
+	}
+	return vm.pop(), nil
+}
+
+// Execute until the script returns, resuming at the innermost exception handler whenever an
+// error that can be caught is raised.
+func (vm *VM) run() error {
+	for {
+		err := vm.execute()
+		if err == nil {
+			return nil
+		}
+		if !vm.handle(err) {
+			vm.recordTrace(err)
+			return err
+		}
+	}
+}
+
+// Execute instructions from the current frame until the script returns or an error is raised.
+func (vm *VM) execute() error {
+	frame := vm.frames[len(vm.frames)-1]
+	chunk := frame.closure.Function.Chunk
+
+	for {
+		start := frame.ip
+		op := bytecode.OpCode(chunk.Code[frame.ip])
+		frame.ip++
+
+		switch op {
+		case bytecode.OP_CONSTANT:
+			vm.push(chunk.Constants[vm.readShort(frame)])
+		case bytecode.OP_NIL:
+			vm.push(nil)
+		case bytecode.OP_TRUE:
+			vm.push(true)
+		case bytecode.OP_FALSE:
+			vm.push(false)
+		case bytecode.OP_POP:
+			vm.pop()
+
+		case bytecode.OP_GET_LOCAL:
+			vm.push(vm.stack[frame.slots+vm.readByte(frame)])
+		case bytecode.OP_SET_LOCAL:
+			vm.stack[frame.slots+vm.readByte(frame)] = vm.peek(0)
+		case bytecode.OP_GET_UPVALUE:
+			vm.push(frame.closure.upvalues[vm.readByte(frame)].get(vm))
+		case bytecode.OP_SET_UPVALUE:
+			frame.closure.upvalues[vm.readByte(frame)].set(vm, vm.peek(0))
+
+		case bytecode.OP_GET_GLOBAL:
+			name := chunk.Constants[vm.readShort(frame)].(string)
+			value, ok := frame.closure.module.globals[name]
+			if !ok {
+				return vm.runtimeError(chunk, start, loxerr.UndefinedVariable, fmt.Sprintf("Variable '%s' not defined", name))
+			}
+			vm.push(value)
+		case bytecode.OP_DEFINE_GLOBAL:
+			name := chunk.Constants[vm.readShort(frame)].(string)
+			frame.closure.module.globals[name] = vm.pop()
+		case bytecode.OP_SET_GLOBAL:
+			name := chunk.Constants[vm.readShort(frame)].(string)
+			if _, ok := frame.closure.module.globals[name]; !ok {
+				return vm.runtimeError(chunk, start, loxerr.UndefinedVariable, fmt.Sprintf("Variable '%s' not defined", name))
+			}
+			frame.closure.module.globals[name] = vm.peek(0)
+
+		case bytecode.OP_GET_PROPERTY:
+			name := chunk.Constants[vm.readShort(frame)].(string)
+			value, err := vm.getProperty(vm.peek(0), name)
+			if err != nil {
+				return vm.runtimeErrorFrom(chunk, start, err)
+			}
+			vm.stack[len(vm.stack)-1] = value
+		case bytecode.OP_SET_PROPERTY:
+			name := chunk.Constants[vm.readShort(frame)].(string)
+			value := vm.pop()
+			switch object := vm.pop().(type) {
+			case *Instance:
+				object.fields[name] = value
+			case *Class:
+				object.fields[name] = value
+			default:
+				return vm.runtimeError(chunk, start, loxerr.NotAnInstance, "Only instances have properties.")
+			}
+			vm.push(value)
+
+		case bytecode.OP_LIST:
+			count := vm.readShort(frame)
+			elements := make([]interface{}, count)
+			copy(elements, vm.stack[len(vm.stack)-count:])
+			vm.stack = vm.stack[:len(vm.stack)-count]
+			vm.push(NewList(elements))
+		case bytecode.OP_MAP:
+			count := vm.readShort(frame)
+			m := NewMap()
+			for i := len(vm.stack) - 2*count; i < len(vm.stack); i += 2 {
+				m.Set(vm.stack[i], vm.stack[i+1])
+			}
+			vm.stack = vm.stack[:len(vm.stack)-2*count]
+			vm.push(m)
+		case bytecode.OP_INDEX_GET:
+			index := vm.pop()
+			var value interface{}
+			var err error
+			switch object := vm.pop().(type) {
+			case *List:
+				value, err = object.get(index)
+			case *Map:
+				value, err = object.get(index)
+			default:
+				err = loxerr.New(loxerr.NotIndexable, "Only lists and maps can be indexed.")
+			}
+			if err != nil {
+				return vm.runtimeErrorFrom(chunk, start, err)
+			}
+			vm.push(value)
+		case bytecode.OP_INDEX_SET:
+			value, index := vm.pop(), vm.pop()
+			var err error
+			switch object := vm.pop().(type) {
+			case *List:
+				err = object.set(index, value)
+			case *Map:
+				object.Set(index, value)
+			default:
+				err = loxerr.New(loxerr.NotIndexable, "Only lists and maps can be indexed.")
+			}
+			if err != nil {
+				return vm.runtimeErrorFrom(chunk, start, err)
+			}
+			vm.push(value)
+
+		case bytecode.OP_EQUAL:
+			rhs, lhs := vm.pop(), vm.pop()
+			vm.push(lhs == rhs)
+		case bytecode.OP_NOT_EQUAL:
+			rhs, lhs := vm.pop(), vm.pop()
+			vm.push(lhs != rhs)
+		case bytecode.OP_GREATER, bytecode.OP_GREATER_EQUAL, bytecode.OP_LESS, bytecode.OP_LESS_EQUAL,
+			bytecode.OP_ADD, bytecode.OP_SUBTRACT, bytecode.OP_MULTIPLY, bytecode.OP_DIVIDE:
+			rhs, lhs := vm.pop(), vm.pop()
+			result, ok := binaryOp(op, lhs, rhs)
+			if !ok {
+				return vm.runtimeError(chunk, start, loxerr.InvalidOperand, fmt.Sprintf("Invalid operator '%s'", chunk.Tokens[start].Lexeme))
+			}
+			vm.push(result)
+		case bytecode.OP_NOT:
+			vm.push(!conversion.IsTruthy(vm.pop()))
+		case bytecode.OP_NEGATE:
+			value := vm.pop()
+			floatValue, ok := conversion.ToFloat(value)
+			if !ok {
+				lexeme := chunk.Tokens[start].Lexeme
+				return vm.runtimeError(chunk, start, loxerr.InvalidOperand, fmt.Sprintf("Unable to apply operator '%s' to value: %v", lexeme, value))
+			}
+			vm.push(-floatValue)
+		case bytecode.OP_PRINT:
+			fmt.Fprint(vm.stdout, vm.pop())
+
+		case bytecode.OP_JUMP:
+			offset := vm.readShort(frame)
+			frame.ip += offset
+		case bytecode.OP_JUMP_IF_FALSE:
+			offset := vm.readShort(frame)
+			if !conversion.IsTruthy(vm.peek(0)) {
+				frame.ip += offset
+			}
+		case bytecode.OP_LOOP:
+			offset := vm.readShort(frame)
+			frame.ip -= offset
+			if vm.stepBudget > 0 {
+				*vm.steps++
+				if *vm.steps > vm.stepBudget {
+					return vm.runtimeError(chunk, start, loxerr.StepBudgetExceeded, fmt.Sprintf("Step budget of %d exceeded.", vm.stepBudget))
+				}
+			}
+			if err := vm.ctx.Err(); err != nil {
+				return err
+			}
+
+		case bytecode.OP_CALL:
+			argCount := vm.readByte(frame)
+			if err := vm.ctx.Err(); err != nil {
+				return err
+			}
+			if err := vm.callValue(vm.peek(argCount), argCount); err != nil {
+				if thrown, ok := err.(*exception); ok {
+					return thrown
+				}
+				if ctxErr := vm.ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+				// Errors of Lox code that a native function called back into are already located.
+				if runtimeErr, ok := err.(*loxerr.LoxRuntimeError); ok {
+					return runtimeErr
+				}
+				return vm.runtimeErrorFrom(chunk, start, err)
+			}
+			frame = vm.frames[len(vm.frames)-1]
+			chunk = frame.closure.Function.Chunk
+
+		case bytecode.OP_CLOSURE:
+			function := chunk.Constants[vm.readShort(frame)].(*bytecode.Function)
+			closure := NewClosure(function, frame.closure.module)
+			for i := range closure.upvalues {
+				isLocal := vm.readByte(frame) == 1
+				index := vm.readByte(frame)
+				if isLocal {
+					closure.upvalues[i] = vm.captureUpvalue(frame.slots + index)
+				} else {
+					closure.upvalues[i] = frame.closure.upvalues[index]
+				}
+			}
+			vm.push(closure)
+		case bytecode.OP_CLOSE_UPVALUE:
+			vm.closeUpvalues(len(vm.stack) - 1)
+			vm.pop()
+		case bytecode.OP_RETURN:
+			result := vm.pop()
+			vm.closeUpvalues(frame.slots)
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			vm.stack = vm.stack[:frame.slots]
+			if len(vm.frames) == vm.baseFrames {
+				vm.push(result)
+				return nil
+			}
+
+			vm.push(result)
+			frame = vm.frames[len(vm.frames)-1]
+			chunk = frame.closure.Function.Chunk
+
+		case bytecode.OP_TRY:
+			offset := vm.readShort(frame)
+			vm.handlers = append(vm.handlers, handler{
+				frameCount:  len(vm.frames),
+				stackHeight: len(vm.stack),
+				ip:          frame.ip + offset,
+			})
+		case bytecode.OP_END_TRY:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case bytecode.OP_CATCH:
+			vm.stack[len(vm.stack)-1] = vm.peek(0).(*exception).value
+		case bytecode.OP_THROW:
+			value := vm.pop()
+			if rethrown, ok := value.(*exception); ok {
+				return rethrown
+			}
+			uncaught := vm.runtimeError(chunk, start, loxerr.UncaughtException, fmt.Sprintf("Uncaught exception: %s", conversion.ToString(value)))
+			if loxError, ok := value.(*Error); ok {
+				uncaught = loxError.cause
+			}
+			return &exception{value: value, uncaught: uncaught}
+
+		case bytecode.OP_IMPORT:
+			importPath := chunk.Constants[vm.readShort(frame)].(string)
+			mod, err := vm.importModule(importPath, frame.closure.module.dir)
+			if err != nil {
+				return vm.runtimeErrorFrom(chunk, start, err)
+			}
+			vm.push(mod)
+
+		case bytecode.OP_CLASS:
+			name := chunk.Constants[vm.readShort(frame)].(string)
+			vm.push(NewClass(name))
+		case bytecode.OP_METHOD:
+			name := chunk.Constants[vm.readShort(frame)].(string)
+			method := vm.pop().(*Closure)
+			cls := vm.peek(0).(*Class)
+			if name == "init" {
+				cls.initializer = method
+			} else {
+				cls.methods[name] = method
+			}
+		case bytecode.OP_STATIC_METHOD:
+			name := chunk.Constants[vm.readShort(frame)].(string)
+			method := vm.pop().(*Closure)
+			cls := vm.peek(0).(*Class)
+			cls.staticMethods[name] = method
+
+		case bytecode.OP_INHERIT:
+			superclass, ok := vm.peek(1).(*Class)
+			if !ok {
+				return vm.runtimeError(chunk, start, loxerr.SuperclassNotClass, "Superclass must be a class.")
+			}
+			vm.pop().(*Class).inherit(superclass)
+		case bytecode.OP_GET_SUPER:
+			name := chunk.Constants[vm.readShort(frame)].(string)
+			superclass := vm.pop().(*Class)
+			receiver := vm.pop()
+			method, ok := superclass.findSuperMethod(receiver, name)
+			if !ok {
+				return vm.runtimeError(chunk, start, loxerr.UndefinedProperty, fmt.Sprintf("Property '%s' is not defined on %s", name, superclass))
+			}
+			vm.push(NewBoundMethod(receiver, method))
+
+		default:
+			return loxerr.Internal(fmt.Sprintf("Unknown opcode %d reached VM!", op))
+		}
+	}
+}
+
+// Apply the arithmetic or comparison operator to the operands.
+// Returns false if the operator can't be applied to the operands' types.
+func binaryOp(op bytecode.OpCode, lhsValue interface{}, rhsValue interface{}) (interface{}, bool) {
+	lhs, isLhsFloat := conversion.ToFloat(lhsValue)
+	rhs, isRhsFloat := conversion.ToFloat(rhsValue)
+	if isLhsFloat && isRhsFloat {
+		return floatBinaryOp(op, lhs, rhs), true
+	}
+
+	lhsString, isLhsString := lhsValue.(string)
+	rhsString, isRhsString := rhsValue.(string)
+	if op == bytecode.OP_ADD && (isLhsString || isRhsString) {
+		// Adding a string to any value concatenates the value's string form.
+		return conversion.ToString(lhsValue) + conversion.ToString(rhsValue), true
+	}
+	if !isLhsString || !isRhsString {
+		return nil, false
+	}
+
+	switch op {
+	case bytecode.OP_GREATER:
+		return lhsString > rhsString, true
+	case bytecode.OP_GREATER_EQUAL:
+		return lhsString >= rhsString, true
+	case bytecode.OP_LESS:
+		return lhsString < rhsString, true
+	case bytecode.OP_LESS_EQUAL:
+		return lhsString <= rhsString, true
+	default:
+		return nil, false
+	}
+}
+
+func floatBinaryOp(op bytecode.OpCode, lhs float64, rhs float64) interface{} {
+	switch op {
+	case bytecode.OP_GREATER:
+		return lhs > rhs
+	case bytecode.OP_GREATER_EQUAL:
+		return lhs >= rhs
+	case bytecode.OP_LESS:
+		return lhs < rhs
+	case bytecode.OP_LESS_EQUAL:
+		return lhs <= rhs
+	case bytecode.OP_ADD:
+		return lhs + rhs
+	case bytecode.OP_SUBTRACT:
+		return lhs - rhs
+	case bytecode.OP_MULTIPLY:
+		return lhs * rhs
+	default:
+		return lhs / rhs
+	}
+}
+
+// Look up a property on an instance, or a static property on a class.
+// Fields shadow methods, and methods are bound to the object they were accessed on.
+func (vm *VM) getProperty(object interface{}, name string) (interface{}, error) {
+	switch obj := object.(type) {
+	case *Instance:
+		if value, ok := obj.fields[name]; ok {
+			return value, nil
+		}
+		if method, ok := obj.Class.methods[name]; ok {
+			return NewBoundMethod(obj, method), nil
+		}
+		// Calling "init" directly re-runs the initializer on the instance.
+		if name == "init" && obj.Class.initializer != nil {
+			return NewBoundMethod(obj, obj.Class.initializer), nil
+		}
+	case *Class:
+		if value, ok := obj.fields[name]; ok {
+			return value, nil
+		}
+		if method, ok := obj.staticMethods[name]; ok {
+			return NewBoundMethod(obj, method), nil
+		}
+	case *List:
+		if method, ok := obj.method(name); ok {
+			return method, nil
+		}
+	case *Map:
+		if method, ok := obj.method(name); ok {
+			return method, nil
+		}
+	case *Error:
+		if value, ok := obj.property(name); ok {
+			return value, nil
+		}
+	case *Module:
+		if value, ok := obj.property(name); ok {
+			return value, nil
+		}
+		return nil, loxerr.Errorf(loxerr.UndefinedProperty, "Module '%s' has no export '%s'.", obj.Name, name)
+	default:
+		return nil, loxerr.New(loxerr.NotAnInstance, "Only instances have properties.")
+	}
+	return nil, loxerr.Errorf(loxerr.UndefinedProperty, "Property '%s' is not defined on %s", name, object)
+}
+
+// Call the callee that sits on the stack below its argCount args.
+func (vm *VM) callValue(callee interface{}, argCount int) error {
+	switch c := callee.(type) {
+	case *Closure:
+		return vm.call(c, argCount)
+	case *BoundMethod:
+		vm.stack[len(vm.stack)-argCount-1] = c.Receiver
+		return vm.call(c.Method, argCount)
+	case *Class:
+		vm.stack[len(vm.stack)-argCount-1] = NewInstance(c)
+		if c.initializer != nil {
+			return vm.call(c.initializer, argCount)
+		} else if argCount != 0 {
+			return loxerr.Errorf(loxerr.WrongArgCount, "Expected %d args, got %d.", 0, argCount)
+		}
+		return nil
+	case *NativeFunction:
+		if !c.variadic && argCount != c.Arity() {
+			return loxerr.Errorf(loxerr.WrongArgCount, "Expected %d args, got %d.", c.Arity(), argCount)
+		}
+		args := make([]interface{}, argCount)
+		copy(args, vm.stack[len(vm.stack)-argCount:])
+		result, err := c.Call(args)
+		if err != nil {
+			return err
+		}
+		vm.stack = vm.stack[:len(vm.stack)-argCount-1]
+		vm.push(result)
+		return nil
+	default:
+		return loxerr.Errorf(loxerr.NotCallable, "Expression '%v' is not callable", callee)
+	}
+}
+
+// Push a new frame to execute the closure with the argCount args on top of the stack.
+func (vm *VM) call(closure *Closure, argCount int) error {
+	if argCount != closure.Function.Arity {
+		return loxerr.Errorf(loxerr.WrongArgCount, "Expected %d args, got %d.", closure.Function.Arity, argCount)
+	}
+	if len(vm.frames) >= vm.maxDepth {
+		return loxerr.New(loxerr.StackOverflow, "Stack overflow.")
+	}
+
+	vm.frames = append(vm.frames, &callFrame{
+		closure: closure,
+		ip:      0,
+		slots:   len(vm.stack) - argCount - 1,
+	})
+	return nil
+}
+
+// Get the open upvalue for the stack slot, creating it if no closure has captured the slot yet.
+func (vm *VM) captureUpvalue(slot int) *Upvalue {
+	var prev *Upvalue
+	current := vm.openUpvalues
+	for current != nil && current.slot > slot {
+		prev = current
+		current = current.next
+	}
+	if current != nil && current.slot == slot {
+		return current
+	}
+
+	created := &Upvalue{slot: slot, next: current}
+	if prev == nil {
+		vm.openUpvalues = created
+	} else {
+		prev.next = created
+	}
+	return created
+}
+
+// Close every open upvalue that refers to a stack slot at or above lastSlot.
+func (vm *VM) closeUpvalues(lastSlot int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.slot >= lastSlot {
+		upvalue := vm.openUpvalues
+		upvalue.closed = vm.stack[upvalue.slot]
+		upvalue.isClosed = true
+		vm.openUpvalues = upvalue.next
+	}
+}
+
+// Unwind to the innermost exception handler and resume execution at it with the exception,
+// returning false if the error can't be caught. Running out of the step budget or having the
+// context done can't be caught, so that scripts can't keep running after it. Handlers installed
+// outside of the innermost call made from Go are left to that call's caller.
+func (vm *VM) handle(err error) bool {
+	if len(vm.handlers) == 0 || vm.handlers[len(vm.handlers)-1].frameCount <= vm.baseFrames {
+		return false
+	}
+	if (vm.stepBudget > 0 && *vm.steps > vm.stepBudget) || vm.ctx.Err() != nil {
+		return false
+	}
+
+	var caught *exception
+	switch err := err.(type) {
+	case *exception:
+		caught = err
+	case *loxerr.LoxRuntimeError:
+		caught = &exception{value: NewError(err), uncaught: err}
+	default:
+		return false
+	}
+
+	h := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	if h.frameCount < len(vm.frames) {
+		vm.recordTrace(err)
+	}
+	vm.closeUpvalues(h.stackHeight)
+	vm.frames = vm.frames[:h.frameCount]
+	vm.stack = vm.stack[:h.stackHeight]
+	vm.push(caught)
+	vm.frames[len(vm.frames)-1].ip = h.ip
+	return true
+}
+
+func (vm *VM) runtimeError(chunk *bytecode.Chunk, offset int, code loxerr.Code, message string) error {
+	t := chunk.Tokens[offset]
+	if t == nil {
+		return loxerr.AtLine(chunk.Line(offset), code, message)
+	}
+	return loxerr.Runtime(t, code, message)
+}
+
+// Locate an error returned by Go code at the instruction, keeping its code if it has one.
+func (vm *VM) runtimeErrorFrom(chunk *bytecode.Chunk, offset int, err error) error {
+	if t := chunk.Tokens[offset]; t != nil {
+		return loxerr.RuntimeFrom(t, err)
+	}
+	code := loxerr.NativeFailure
+	if coded, ok := err.(loxerr.Coded); ok {
+		code = coded.Code()
+	}
+	return loxerr.AtLine(chunk.Line(offset), code, err.Error())
+}
+
+// Record the calls in progress as the stack trace of the runtime error or the error reported if the
+// exception is uncaught, unless it already has one. Errors raised outside of any function have no trace.
+func (vm *VM) recordTrace(err error) {
+	if thrown, ok := err.(*exception); ok {
+		err = thrown.uncaught
+	}
+	runtimeErr, ok := err.(*loxerr.LoxRuntimeError)
+	frameCount := len(vm.frames)
+	if !ok || len(runtimeErr.Trace) > 0 || frameCount < 2 {
+		return
+	}
+
+	// The innermost frame is executing the code the error occurred at, and the others are executing calls.
+	trace := make([]loxerr.StackFrame, 0, frameCount)
+	line := runtimeErr.Token.Line
+	for i := frameCount - 1; i >= 0; i-- {
+		frame := vm.frames[i]
+		trace = append(trace, loxerr.StackFrame{
+			Function: frame.closure.name(),
+			File:     frame.closure.module.file,
+			Line:     line,
+		})
+		if i > 0 {
+			line = vm.frames[i-1].closure.Function.Chunk.Line(vm.frames[i-1].ip - 1)
+		}
+	}
+	runtimeErr.Trace = trace
+}
+
+// Discard the frames, stack values and handlers of the innermost call made from Go after it failed.
+func (vm *VM) unwind(stackHeight int) {
+	vm.closeUpvalues(stackHeight)
+	vm.frames = vm.frames[:vm.baseFrames]
+	vm.stack = vm.stack[:stackHeight]
+	for len(vm.handlers) > 0 && vm.handlers[len(vm.handlers)-1].frameCount > vm.baseFrames {
+		vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	}
+}
+
+func (vm *VM) readByte(frame *callFrame) int {
+	b := frame.closure.Function.Chunk.Code[frame.ip]
+	frame.ip++
+	return int(b)
+}
+
+func (vm *VM) readShort(frame *callFrame) int {
+	value := frame.closure.Function.Chunk.ReadShort(frame.ip)
+	frame.ip += 2
+	return int(value)
+}
+
+func (vm *VM) push(value interface{}) {
+	vm.stack = append(vm.stack, value)
+}
+
+func (vm *VM) pop() interface{} {
+	value := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return value
+}
+
+func (vm *VM) peek(distance int) interface{} {
+	return vm.stack[len(vm.stack)-1-distance]
+}
@@ -0,0 +1,273 @@
+package vm
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaschnit/golox/test/programs"
+	"github.com/kaschnit/golox/test/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func interpretSourceFile(subPath string) error {
+	filepath := programs.GetPath(subPath)
+	return NewVMWrapper().InterpretSourceFile(filepath)
+}
+
+func getVMOutput(programName string) (string, error) {
+	return testutil.CaptureOutput(func() error {
+		return interpretSourceFile(programName)
+	})
+}
+
+func TestOutput_Construct_Assignment(t *testing.T) {
+	result, err := getVMOutput("constructs/Assignment.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "-12 398", result)
+}
+
+func TestOutput_Construct_BreakContinue(t *testing.T) {
+	result, err := getVMOutput("constructs/BreakContinue.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "123|0245|0 10 11 20 21 22 |0268|9|02", result)
+}
+
+func TestOutput_Construct_ClassConstructorEarlyReturn(t *testing.T) {
+	result, err := getVMOutput("constructs/ClassConstructorEarlyReturn.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "123123123", result)
+}
+
+func TestOutput_Construct_ClassConstructorReinit(t *testing.T) {
+	result, err := getVMOutput("constructs/ClassConstructorReinit.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "init 1;init 2;true 2 true 500", result)
+}
+
+func TestOutput_Construct_ClassConstructorWithArgs(t *testing.T) {
+	result, err := getVMOutput("constructs/ClassConstructorWithArgs.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "bye bob bar", result)
+}
+
+func TestOutput_Construct_ClassConstructorNoArgs(t *testing.T) {
+	result, err := getVMOutput("constructs/ClassConstructorNoArgs.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "a1a2a3", result)
+}
+
+func TestOutput_Construct_ClassMethods(t *testing.T) {
+	result, err := getVMOutput("constructs/ClassMethods.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "AH! 100", result)
+}
+func TestOutput_Construct_ClassStaticMethods(t *testing.T) {
+	result, err := getVMOutput("constructs/ClassStaticMethods.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "getting instance; instance value 99; static print; param static print 49", result)
+}
+
+func TestOutput_Construct_ClassInheritance(t *testing.T) {
+	result, err := getVMOutput("constructs/ClassInheritance.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "Rex barks; animal Rex; Bit barks; animal Bit", result)
+}
+
+func TestOutput_Construct_ClassSuperCall(t *testing.T) {
+	result, err := getVMOutput("constructs/ClassSuperCall.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "derived 20 then base 10; most derived then derived 2 then base 1", result)
+}
+
+func TestOutput_Construct_ClassStaticInheritance(t *testing.T) {
+	result, err := getVMOutput("constructs/ClassStaticInheritance.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "shape create square then shape", result)
+}
+
+func TestOutput_Construct_ClassThisKeyword(t *testing.T) {
+	result, err := getVMOutput("constructs/ClassThisKeyword.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "1 10 11   10 99 10   99 99 12   99 99 13   13 1 13", result)
+}
+
+func TestOutput_Construct_Exceptions(t *testing.T) {
+	result, err := getVMOutput("constructs/Exceptions.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "2 caught too big: 5 finally1|Variable 'undefinedThing' not defined @16|Expected 2 args, got 1.|"+
+		"<error: Invalid operator '-'>|cleanup from try|0f0 f1 2f2 f3 |inner finally outer caught inner|"+
+		"Variable 'nope' not defined|fnfn|captured x1|abvalue|yx!|fin second|ctor|finally|0ff2f|swallowed|"+
+		"bottom|Key 'k' not found in map.|134|Can't pop from an empty list.", result)
+}
+
+func TestOutput_Construct_ForLoop(t *testing.T) {
+	result, err := getVMOutput("constructs/ForLoop.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "0 1 2 3 4 5 Text Text ", result)
+}
+
+func TestOutput_Construct_FunctionCallWithArgs(t *testing.T) {
+	result, err := getVMOutput("constructs/FunctionCallWithArgs.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "Printing 0: Printing 1: a Printing 2: b c Printing 3: d e f", result)
+}
+
+func TestOutput_Construct_GlobalClosure(t *testing.T) {
+	result, err := getVMOutput("constructs/GlobalClosure.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "1112 334", result)
+}
+
+func TestOutput_Construct_IfElse(t *testing.T) {
+	result, err := getVMOutput("constructs/IfElse.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "1 if 2 if 3 else 4 if 5 else 6 if 7 else 8 if ", result)
+}
+
+func TestOutput_Construct_IfElseIf(t *testing.T) {
+	result, err := getVMOutput("constructs/IfElseIf.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "1 if 2 else if 3 else ", result)
+}
+
+func TestOutput_Construct_Imports(t *testing.T) {
+	result, err := getVMOutput("constructs/Imports.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "loading geometry;|12|circle 3|3|3|3|<module Geometry>|Module 'Geometry' has no export 'missing'.|"+
+		"Module '../modules/Missing.lox' not found.|circle 12|3", result)
+}
+
+func TestOutput_Construct_Lambdas(t *testing.T) {
+	result, err := getVMOutput("constructs/Lambdas.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "3|16|k|[10, 20, 30]|3|6|Hi, Lox|immediate|true|9", result)
+}
+
+func TestOutput_Construct_Lists(t *testing.T) {
+	result, err := getVMOutput("constructs/Lists.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "[1, 2, 3]|4|[1, two, 3]|4|4|[zero, 1, two, 3]|two|[1, 3]|0|[[1, 2], [20, 4]]|18", result)
+}
+
+func TestOutput_Construct_LocalClosure(t *testing.T) {
+	result, err := getVMOutput("constructs/LocalClosure.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "HelloHello15", result)
+}
+
+func TestOutput_Construct_LogicalAnd(t *testing.T) {
+	result, err := getVMOutput("constructs/LogicalAnd.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "false false false true false", result)
+}
+
+func TestOutput_Construct_LogicalOr(t *testing.T) {
+	result, err := getVMOutput("constructs/LogicalOr.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "false true true true true", result)
+}
+
+func TestOutput_Construct_LogicalShortCircuit(t *testing.T) {
+	result, err := getVMOutput("constructs/LogicalShortCircuit.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "false foo false true default 2 first called last", result)
+}
+
+func TestOutput_Construct_LongRunningLoop(t *testing.T) {
+	result, err := getVMOutput("constructs/LongRunningLoop.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "499500", result)
+}
+
+func TestOutput_Construct_Maps(t *testing.T) {
+	result, err := getVMOutput("constructs/Maps.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "{alice: 30, bob: 25}|55|{alice: 31, bob: 25, carol: 41}|true false|true false|[alice, carol] [31, 41] 2|"+
+		"one yes nothing|pq|alice=31;carol=41;|{}", result)
+}
+
+func TestOutput_Construct_NativeFunction_Clock(t *testing.T) {
+	result, err := getVMOutput("constructs/NativeFunction_Clock.lox")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(result, "<native function clock ["))
+	assert.Contains(t, result, " => ")
+
+	parts := strings.Split(result, " => ")
+	assert.Len(t, parts, 2)
+	assert.True(t, strings.HasPrefix(string(parts[0]), "<native function clock ["))
+	assert.True(t, strings.HasSuffix(string(parts[0]), "]>"))
+
+	unixTimestamp, err := strconv.ParseFloat(parts[1], 64)
+	assert.Nil(t, err)
+
+	oneHour, err := time.ParseDuration("1h")
+	assert.Nil(t, err)
+
+	laterTimestamp := time.Now().Add(oneHour)
+	programTimestamp := time.Unix(int64(unixTimestamp), 0)
+	assert.Greater(t, laterTimestamp, programTimestamp)
+}
+
+func TestOutput_Construct_NativeMath(t *testing.T) {
+	result, err := getVMOutput("constructs/NativeMath.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "<module math>|4 2 3|3 5 -1|3|Argument 1 of 'math.sqrt' must be a number, got string.|"+
+		"Expected at least 1 args, got 0.", result)
+}
+
+func TestOutput_Construct_NumericArithmeticOperations(t *testing.T) {
+	result, err := getVMOutput("constructs/NumericArithmeticOperations.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "3 -13 60 7.5 2", result)
+}
+
+func TestOutput_Construct_NumericComparisonOperations(t *testing.T) {
+	result, err := getVMOutput("constructs/NumericComparisonOperations.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "false true false false true true true false false true false true true false false true", result)
+}
+
+func TestOutput_Construct_ReturnAtEndOfFunction(t *testing.T) {
+	result, err := getVMOutput("constructs/ReturnAtEndOfFunction.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "This should be printed! Yay!", result)
+}
+
+func TestOutput_Construct_ReturnEarlyFromFunction(t *testing.T) {
+	result, err := getVMOutput("constructs/ReturnEarlyFromFunction.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "Yay!", result)
+}
+
+func TestOutput_Construct_ReturnNoValue(t *testing.T) {
+	result, err := getVMOutput("constructs/ReturnNoValue.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "0123", result)
+}
+
+func TestOutput_Construct_Scoping(t *testing.T) {
+	result, err := getVMOutput("constructs/Scoping.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "10 9 5 6 9", result)
+}
+
+func TestOutput_Construct_ShadowedClosure(t *testing.T) {
+	result, err := getVMOutput("constructs/ShadowedClosure.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "globalglobalblock12100", result)
+}
+
+func TestOutput_Construct_StringOperations(t *testing.T) {
+	result, err := getVMOutput("constructs/StringOperations.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello, world|true|true|true|count: 3|tab\tquote\"backslash\\dollar$|A😀|"+
+		"x = 2, x squared = 4, name = Lox!|nested inner Lox done|22|call <3>", result)
+}
+
+func TestOutput_Construct_WhileLoop(t *testing.T) {
+	result, err := getVMOutput("constructs/WhileLoop.lox")
+	assert.Nil(t, err)
+	assert.Equal(t, "4 3 2 1 0 ", result)
+}
@@ -0,0 +1,380 @@
+package vm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kaschnit/golox/pkg/ast/analyzer"
+	"github.com/kaschnit/golox/pkg/ast/astutil"
+	"github.com/kaschnit/golox/pkg/ast/emitter"
+	"github.com/kaschnit/golox/pkg/bytecode"
+	loxerr "github.com/kaschnit/golox/pkg/errors"
+	"github.com/kaschnit/golox/pkg/native"
+	"github.com/kaschnit/golox/test/programs"
+	"github.com/stretchr/testify/assert"
+)
+
+func interpretLines(w *VMWrapper, lines ...string) (string, error) {
+	var stdout strings.Builder
+	w.SetStdout(&stdout)
+	for _, line := range lines {
+		if err := w.InterpretLine(line); err != nil {
+			return "", err
+		}
+	}
+	return stdout.String(), nil
+}
+
+// Compile the line to a script that returns the value of its last expression statement.
+func compileLine(t *testing.T, line string) *bytecode.Function {
+	programAst, err := astutil.ParseLine(line)
+	assert.Nil(t, err)
+	_, err = analyzer.NewAstAnalyzer().VisitProgram(programAst)
+	assert.Nil(t, err)
+	script, err := emitter.CompileWithResult(programAst)
+	assert.Nil(t, err)
+	return script
+}
+
+func TestVM_ClosureCapturesVariableAfterScopeEnds(t *testing.T) {
+	result, err := interpretLines(NewVMWrapper(), `
+		fun makeCounter() {
+			var i = 0;
+			fun count() { i = i + 1; return i; }
+			return count;
+		}
+		var a = makeCounter();
+		var b = makeCounter();
+		print a(); print a(); print b(); print a();
+	`)
+	assert.Nil(t, err)
+	assert.Equal(t, "1213", result)
+}
+
+func TestVM_ClosuresShareCapturedVariable(t *testing.T) {
+	result, err := interpretLines(NewVMWrapper(), `
+		var get; var set;
+		{
+			var x = "before";
+			fun getX() { return x; }
+			fun setX(value) { x = value; }
+			get = getX; set = setX;
+		}
+		set("after");
+		print get();
+	`)
+	assert.Nil(t, err)
+	assert.Equal(t, "after", result)
+}
+
+func TestVM_NestedClosureCapturesThroughEnclosingFunction(t *testing.T) {
+	result, err := interpretLines(NewVMWrapper(), `
+		fun outer() {
+			var x = "outer";
+			fun middle() {
+				fun inner() { return x; }
+				return inner;
+			}
+			return middle();
+		}
+		print outer()();
+	`)
+	assert.Nil(t, err)
+	assert.Equal(t, "outer", result)
+}
+
+func TestVM_BoundMethodKeepsReceiver(t *testing.T) {
+	result, err := interpretLines(NewVMWrapper(), `
+		class Box { init(v) { this.v = v; } get() { return this.v; } }
+		var get = Box(42).get;
+		print get();
+	`)
+	assert.Nil(t, err)
+	assert.Equal(t, "42", result)
+}
+
+func TestVM_GlobalsPersistBetweenLines(t *testing.T) {
+	w := NewVMWrapper()
+	result, err := interpretLines(w, "var x = 1;", "fun inc() { x = x + 1; }", "inc(); inc();", "print x;")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", result)
+}
+
+func TestVM_ClockIsANumber(t *testing.T) {
+	w := NewVMWrapper()
+	result, err := interpretLines(w, "print clock() + 1 > clock();", "print math.sqrt(clock()) > 0;")
+	assert.Nil(t, err)
+	assert.Equal(t, "truetrue", result)
+}
+
+func TestVM_RecoversAfterRuntimeError(t *testing.T) {
+	w := NewVMWrapper()
+	_, err := interpretLines(w, "fun f() { return undefinedVar; }", "f();")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	result, err := interpretLines(w, "print 1 + 1;")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", result)
+}
+
+func TestVM_StackOverflow(t *testing.T) {
+	_, err := interpretLines(NewVMWrapper(), "fun f() { return f(); }", "f();")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Stack overflow.")
+}
+
+func TestVM_WrongArgCount(t *testing.T) {
+	_, err := interpretLines(NewVMWrapper(), "fun f(a, b) {}", "f(1);")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.Equal(t, "(", err.(*loxerr.LoxRuntimeError).Token.Lexeme)
+	assert.ErrorContains(t, err, "Expected 2 args, got 1.")
+}
+
+func TestVM_CallNonCallable(t *testing.T) {
+	_, err := interpretLines(NewVMWrapper(), `"abc"();`)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "not callable")
+}
+
+func TestVM_StepBudget_Exceeded(t *testing.T) {
+	w := NewVMWrapper()
+	w.SetStepBudget(10)
+
+	result, err := interpretLines(w, "var i = 0;\nwhile (true) {\n i = i + 1;\n}")
+	assert.Equal(t, "", result)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	runtimeErr := err.(*loxerr.LoxRuntimeError)
+	assert.Equal(t, "while", runtimeErr.Token.Lexeme)
+	assert.Equal(t, 2, runtimeErr.Token.Line)
+	assert.ErrorContains(t, runtimeErr, "Step budget of 10 exceeded.")
+}
+
+func TestVM_StepBudget_WithinBudget(t *testing.T) {
+	w := NewVMWrapper()
+	w.SetStepBudget(5)
+
+	// The budget applies to each script separately.
+	result, err := interpretLines(w,
+		"for (var i = 0; i < 5; i = i + 1) { print i; }",
+		"for (var i = 0; i < 5; i = i + 1) { print i; }",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "0123401234", result)
+}
+
+func TestVM_StepBudget_NotCatchable(t *testing.T) {
+	w := NewVMWrapper()
+	w.SetStepBudget(10)
+
+	_, err := interpretLines(w, "try { while (true) {} } catch (e) { print e; }")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Step budget of 10 exceeded.")
+}
+
+func TestVM_StepBudget_SharedWithImports(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Loop.lox"), []byte("var i = 0; while (i < 5) { i = i + 1; }"), 0o644))
+
+	w := NewVMWrapper()
+	w.SetSearchPath([]string{dir})
+	w.SetStepBudget(8)
+
+	// The module's loop iterations count against the budget of the script that imports it.
+	_, err := interpretLines(w, "import \"Loop.lox\" as loop;\nfor (var j = 0; j < 5; j = j + 1) {}")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Step budget of 8 exceeded.")
+	assert.Equal(t, 2, err.(*loxerr.LoxRuntimeError).Token.Line)
+}
+
+func TestVM_CaughtRuntimeErrorProperties(t *testing.T) {
+	result, err := interpretLines(NewVMWrapper(), "try {\n print missing;\n} catch (e) { print e.line + \" \" + e.message; }")
+	assert.Nil(t, err)
+	assert.Equal(t, "2 Variable 'missing' not defined", result)
+}
+
+func TestVM_ImportFromSearchPath(t *testing.T) {
+	w := NewVMWrapper()
+	w.SetSearchPath([]string{programs.GetPath("basic"), programs.GetPath("modules")})
+
+	result, err := interpretLines(w, "import \"Geometry.lox\" as g;", "print g.area(1);", "import \"Geometry.lox\" as h;")
+	assert.Nil(t, err)
+	assert.Equal(t, "loading geometry;3", result)
+}
+
+func TestVM_RunReturnsLastExpression(t *testing.T) {
+	vm := NewVM()
+	result, err := vm.Run(context.Background(), compileLine(t, "var a = 2; a * 3;"))
+	assert.Nil(t, err)
+	assert.Equal(t, 6.0, result)
+	assert.Empty(t, vm.stack)
+}
+
+func TestVM_CallFromGo(t *testing.T) {
+	vm := NewVM()
+	_, err := vm.Run(context.Background(), compileLine(t, "fun add(a, b) { return a + b; }"))
+	assert.Nil(t, err)
+
+	add, ok := vm.Global("add")
+	assert.True(t, ok)
+	result, err := vm.Call(add, 1.0, 2.0)
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, result)
+
+	_, err = vm.Call(add, 1.0)
+	assert.EqualError(t, err, "Expected 2 args, got 1.")
+	assert.Empty(t, vm.stack)
+	assert.Empty(t, vm.frames)
+}
+
+func TestVM_CallFromNative(t *testing.T) {
+	vm := NewVM()
+	vm.SetGlobal("apply", NewNativeFunction("apply", 2, func(args []interface{}) (interface{}, error) {
+		return vm.Call(args[0], args[1])
+	}))
+
+	result, err := vm.Run(context.Background(), compileLine(t, "apply(fun (x) { return x + 1; }, 1);"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, result)
+
+	// An error raised in the callback is caught by the script that called the native function.
+	result, err = vm.Run(context.Background(), compileLine(t, `
+		var caught = nil;
+		try {
+			apply(fun (x) { throw x; }, "thrown");
+		} catch (e) {
+			caught = e;
+		}
+		caught;
+	`))
+	assert.Nil(t, err)
+	assert.Equal(t, "thrown", result)
+	assert.Empty(t, vm.stack)
+	assert.Empty(t, vm.handlers)
+}
+
+func TestVM_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	vm := NewVM()
+	_, err := vm.Run(ctx, compileLine(t, "try { while (true) {} } catch (e) {}"))
+	assert.Equal(t, context.Canceled, err)
+
+	// The VM can run other scripts after a script is canceled.
+	result, err := vm.Run(context.Background(), compileLine(t, "1 + 1;"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, result)
+}
+
+func TestVM_ReadLine(t *testing.T) {
+	w := NewVMWrapper()
+	w.SetStdin(strings.NewReader("alice\nbob\n"))
+
+	result, err := interpretLines(w,
+		"var line = readLine();",
+		"while (line != nil) { print \"hello \" + line + \";\"; line = readLine(); }",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello alice;hello bob;", result)
+}
+
+func TestVM_MaxDepth(t *testing.T) {
+	w := NewVMWrapper()
+	w.SetMaxDepth(4)
+
+	result, err := interpretLines(w, "fun depth(n) { if (n == 1) return n; return depth(n - 1); }", "print depth(3);")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", result)
+
+	_, err = interpretLines(w, "print depth(4);")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.ErrorContains(t, err, "Stack overflow.")
+	assert.Equal(t, []loxerr.StackFrame{
+		{Function: "depth", File: "", Line: 1},
+		{Function: "depth", File: "", Line: 1},
+		{Function: "depth", File: "", Line: 1},
+		{Function: "<script>", File: "", Line: 1},
+	}, err.(*loxerr.LoxRuntimeError).Trace)
+}
+
+func TestVM_StackTraceOfRethrownError(t *testing.T) {
+	_, err := interpretLines(NewVMWrapper(),
+		"fun fail() {\n return nil + 1;\n}",
+		"fun retry() {\n try { fail(); } catch (e) { throw e; }\n}",
+		"retry();",
+	)
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	// The trace is the one of the original error, not of where it was rethrown.
+	assert.Equal(t, []loxerr.StackFrame{
+		{Function: "fail", File: "", Line: 2},
+		{Function: "retry", File: "", Line: 2},
+		{Function: "<script>", File: "", Line: 1},
+	}, err.(*loxerr.LoxRuntimeError).Trace)
+}
+
+func TestVM_EvaluateLine(t *testing.T) {
+	w := NewVMWrapper()
+	value, err := w.EvaluateLine("var x = 40;")
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+
+	value, err = w.EvaluateLine("x + 2")
+	assert.Nil(t, err)
+	assert.Equal(t, 42.0, value)
+
+	_, err = w.EvaluateLine("x + nil")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+
+	value, err = w.EvaluateLine("fun f() {\n  return x;\n}\nf();")
+	assert.Nil(t, err)
+	assert.Equal(t, 40.0, value)
+
+	_, err = w.EvaluateLine("x + 1 x")
+	assert.Error(t, err)
+}
+
+func TestVM_Globals(t *testing.T) {
+	w := NewVMWrapper()
+	_, err := interpretLines(w, "var x = [1];", "class A {}")
+	assert.Nil(t, err)
+
+	globals := w.Globals()
+	assert.Equal(t, "list", native.TypeName(globals["x"]))
+	assert.Equal(t, "class", native.TypeName(globals["A"]))
+	assert.Equal(t, "function", native.TypeName(globals["clock"]))
+	assert.Equal(t, "module", native.TypeName(globals["math"]))
+
+	value, err := w.EvaluateLine("A()")
+	assert.Nil(t, err)
+	assert.Equal(t, "instance", native.TypeName(value))
+}
+
+func TestVM_ClassHasNoInitProperty(t *testing.T) {
+	_, err := interpretLines(NewVMWrapper(), "class A { init() { this.x = 1; } }", "A.init();")
+	assert.IsType(t, &loxerr.LoxRuntimeError{}, err)
+	assert.Equal(t, loxerr.UndefinedProperty, err.(*loxerr.LoxRuntimeError).Code())
+	assert.ErrorContains(t, err, "Property 'init' is not defined on <class A [")
+}
+
+func TestVM_PrintsListsThatContainThemselves(t *testing.T) {
+	result, err := interpretLines(NewVMWrapper(),
+		"var zs = []; zs.push(zs); print zs;",
+		"var xs = []; var ys = [xs]; xs.push(ys); print xs; print ys;",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "[[...]][[[...]]][[[...]]]", result)
+}
+
+func TestVM_PrintsMapsThatContainThemselves(t *testing.T) {
+	result, err := interpretLines(NewVMWrapper(),
+		"var m = {}; m[\"self\"] = m; print m;",
+		"var xs = []; var n = {\"xs\": xs}; xs.push(n); print n; print xs;",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "{self: {...}}{xs: [{...}]}[{xs: [...]}]", result)
+}
@@ -0,0 +1,163 @@
+package vm
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+
+	"github.com/kaschnit/golox/pkg/ast"
+	"github.com/kaschnit/golox/pkg/ast/analyzer"
+	"github.com/kaschnit/golox/pkg/ast/astutil"
+	"github.com/kaschnit/golox/pkg/ast/emitter"
+	"github.com/kaschnit/golox/pkg/bytecode"
+	"github.com/kaschnit/golox/pkg/module"
+	"github.com/kaschnit/golox/pkg/native"
+	"github.com/kaschnit/golox/pkg/parser"
+)
+
+type VMWrapper struct {
+	analyzer *analyzer.AstAnalyzer
+	vm       *VM
+}
+
+func NewVMWrapper() *VMWrapper {
+	return &VMWrapper{
+		analyzer: analyzer.NewAstAnalyzer(),
+		vm:       NewVM(),
+	}
+}
+
+// Limit the number of loop iterations each program may run. A budget of 0 means there is no limit.
+func (w *VMWrapper) SetStepBudget(budget int) {
+	w.vm.SetStepBudget(budget)
+}
+
+// Set the maximum depth of nested calls before a stack overflow is reported.
+func (w *VMWrapper) SetMaxDepth(depth int) {
+	w.vm.SetMaxDepth(depth)
+}
+
+// Set the directories searched for imported modules that aren't found relative to the importing file.
+func (w *VMWrapper) SetSearchPath(searchPath []string) {
+	w.vm.SetSearchPath(searchPath)
+}
+
+// Set the writer that the output of programs is written to.
+func (w *VMWrapper) SetStdout(stdout io.Writer) {
+	w.vm.SetStdout(stdout)
+}
+
+// Set the reader that programs read input from.
+func (w *VMWrapper) SetStdin(stdin io.Reader) {
+	w.vm.SetStdin(stdin)
+}
+
+// Get the global variables and their values, including the native functions.
+func (w *VMWrapper) Globals() map[string]interface{} {
+	return w.vm.Globals()
+}
+
+// Define the native functions of the registry as globals.
+func (w *VMWrapper) Install(registry *native.Registry) {
+	w.vm.Install(registry)
+}
+
+func (w *VMWrapper) InterpretSourceFile(path string) error {
+	programAst, err := parser.ParseSourceFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Imports in the file are relative to the directory containing it.
+	dir, file := w.vm.main.dir, w.vm.main.file
+	w.vm.main.dir, w.vm.main.file = filepath.Dir(path), path
+	defer func() {
+		w.vm.main.dir, w.vm.main.file = dir, file
+	}()
+
+	return w.vm.modules.RunMain(path, func() error {
+		return w.interpret(programAst)
+	})
+}
+
+func (w *VMWrapper) InterpretLine(line string) error {
+	programAst, err := astutil.ParseLine(line)
+	if err != nil {
+		return err
+	}
+	return w.interpret(programAst)
+}
+
+// Run source code entered at a REPL, returning the value of its last statement if it's an expression
+// statement, or nil otherwise. The semicolon after a final expression statement may be left out.
+func (w *VMWrapper) EvaluateLine(line string) (interface{}, error) {
+	programAst, err := astutil.ParseReplInput(line)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.analyzer.VisitProgram(programAst); err != nil {
+		return nil, err
+	}
+	script, err := emitter.CompileWithResult(programAst)
+	if err != nil {
+		return nil, err
+	}
+	return w.vm.Run(context.Background(), script)
+}
+
+// Analyze and compile the program, then execute it on the VM.
+func (w *VMWrapper) interpret(programAst *ast.Program) error {
+	script, err := compile(w.analyzer, programAst)
+	if err != nil {
+		return err
+	}
+
+	return w.vm.Interpret(script)
+}
+
+// Analyze the program with the analyzer, then compile it to a script.
+func compile(analyzer *analyzer.AstAnalyzer, programAst *ast.Program) (*bytecode.Function, error) {
+	_, err := analyzer.VisitProgram(programAst)
+	if err != nil {
+		return nil, err
+	}
+	return emitter.Compile(programAst)
+}
+
+// Get the module imported from the path by a file in the directory fromDir, executing the module
+// on a VM of its own the first time it's imported.
+func (vm *VM) importModule(importPath string, fromDir string) (*Module, error) {
+	path, err := vm.modules.Resolve(importPath, fromDir)
+	if err != nil {
+		return nil, err
+	}
+
+	mod, err := vm.modules.Load(path, func(path string, programAst *ast.Program) (interface{}, error) {
+		script, err := compile(analyzer.NewAstAnalyzer(), programAst)
+		if err != nil {
+			return nil, err
+		}
+
+		moduleVM := NewVM()
+		moduleVM.stepBudget = vm.stepBudget
+		moduleVM.steps = vm.steps
+		moduleVM.maxDepth = vm.maxDepth
+		moduleVM.modules = vm.modules
+		moduleVM.main.dir = filepath.Dir(path)
+		moduleVM.main.file = path
+		moduleVM.stdout = vm.stdout
+		moduleVM.stdin = vm.stdin
+		for _, registry := range vm.natives {
+			moduleVM.Install(registry)
+		}
+		if _, err := moduleVM.runScript(vm.ctx, script); err != nil {
+			return nil, err
+		}
+
+		return NewModule(module.Name(path), moduleVM.main.globals, module.Exports(programAst)), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mod.(*Module), nil
+}